@@ -0,0 +1,97 @@
+// AnhCao 2024
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
+	"github.com/AnhCaooo/electric-notifications/internal/db"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+var apiKeyUsage = `usage:
+  electric-notifications apikey create -name <service> -scopes <scope>[,<scope>...]
+  electric-notifications apikey list
+  electric-notifications apikey revoke <id>
+
+scopes: ` + strings.Join(models.APIKeyScopes, ", ")
+
+// runAPIKeyCommand manages the API keys of the services from the command line,
+// ex: to create the first key before any admin can call the API
+func runAPIKeyCommand(out io.Writer, mongo *db.Mongo, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", apiKeyUsage)
+	}
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		flags.SetOutput(out)
+		name := flags.String("name", "", "name of the service which uses the key")
+		scopes := flags.String("scopes", "", "comma separated scopes of the key")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		request := models.APIKeyRequest{Name: *name}
+		for _, scope := range strings.Split(*scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				request.Scopes = append(request.Scopes, scope)
+			}
+		}
+		if err := request.Validate(); err != nil {
+			return err
+		}
+		key, prefix, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			return err
+		}
+		apiKey, err := mongo.InsertAPIKey(models.APIKey{Name: request.Name, Prefix: prefix, Hash: hash, Scopes: request.Scopes})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created API key %s for %s with scopes %s\n", apiKey.ID.Hex(), apiKey.Name, strings.Join(apiKey.Scopes, ", "))
+		fmt.Fprintf(out, "key (it is not shown again): %s\n", key)
+		return nil
+	case "list":
+		keys, err := mongo.GetAPIKeys()
+		if err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tNAME\tPREFIX\tSCOPES\tCREATED\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				key.ID.Hex(), key.Name, key.Prefix, strings.Join(key.Scopes, ","),
+				key.CreatedAt.Format(time.RFC3339), formatOptionalTime(key.LastUsedAt), formatOptionalTime(key.RevokedAt))
+		}
+		return table.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("missing ID of the API key\n%s", apiKeyUsage)
+		}
+		id, err := bson.ObjectIDFromHex(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid API key ID '%s'", args[1])
+		}
+		if err = mongo.RevokeAPIKey(id); err != nil {
+			return fmt.Errorf("failed to revoke API key %s: %s", args[1], err.Error())
+		}
+		fmt.Fprintf(out, "revoked API key %s\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("unknown command '%s'\n%s", args[0], apiKeyUsage)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,112 @@
+// AnhCao 2024
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
+	"github.com/AnhCaooo/electric-notifications/internal/db"
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestRunAPIKeyCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		// reply of the database to the insert or update of the key
+		reply      bson.D
+		wantErr    bool
+		wantOutput string
+		wantInsert bool
+	}{
+		{
+			name:       "create key",
+			args:       []string{"create", "-name", "billing", "-scopes", "notifications:send, deliveries:read"},
+			wantOutput: "for billing with scopes notifications:send, deliveries:read",
+			wantInsert: true,
+		},
+		{
+			name:    "create key with unknown scope",
+			args:    []string{"create", "-name", "billing", "-scopes", "users:delete"},
+			wantErr: true,
+		},
+		{
+			name:    "create key without name",
+			args:    []string{"create", "-scopes", "notifications:send"},
+			wantErr: true,
+		},
+		{
+			name:       "revoke key",
+			args:       []string{"revoke", "6790b1c2d3e4f5a6b7c8d9e0"},
+			reply:      dbtest.Written(1),
+			wantOutput: "revoked API key 6790b1c2d3e4f5a6b7c8d9e0",
+		},
+		{
+			name:    "revoke unknown or revoked key",
+			args:    []string{"revoke", "6790b1c2d3e4f5a6b7c8d9e0"},
+			reply:   dbtest.Written(0),
+			wantErr: true,
+		},
+		{
+			name:    "revoke invalid ID",
+			args:    []string{"revoke", "billing"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"delete"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := dbtest.NewServer(func(command bson.M) bson.D {
+				return tt.reply
+			})
+			client, err := server.Client()
+			if err != nil {
+				t.Fatalf("failed to connect to fake server: %v", err)
+			}
+			mongo := db.NewMongo(context.Background(), &models.Database{Name: "test", Collection: "tokens"}, zap.NewNop())
+			if err = mongo.Open(client); err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			server.Reset()
+
+			var out bytes.Buffer
+			err = runAPIKeyCommand(&out, mongo, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runAPIKeyCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("output %q, want %q", out.String(), tt.wantOutput)
+			}
+
+			inserts := server.Commands("insert")
+			if (len(inserts) == 1) != tt.wantInsert {
+				t.Fatalf("sent %d inserts, want insert %v", len(inserts), tt.wantInsert)
+			}
+			if !tt.wantInsert {
+				return
+			}
+			// only the hash of the printed key is stored
+			key := regexp.MustCompile(`key \(it is not shown again\): (\S+)`).FindStringSubmatch(out.String())
+			if key == nil {
+				t.Fatalf("output %q does not contain the key", out.String())
+			}
+			stored := inserts[0]["documents"].(bson.A)[0].(bson.M)
+			if stored["hash"] != auth.HashAPIKey(key[1]) || !strings.HasPrefix(key[1], stored["prefix"].(string)) {
+				t.Errorf("stored key %v, want the hash and the prefix of %s", stored, key[1])
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	// time zones of scheduled notifications are available also when the system has no time zone database
+	_ "time/tzdata"
 
 	_ "github.com/AnhCaooo/electric-notifications/docs"
 	"github.com/AnhCaooo/electric-notifications/internal/api"
@@ -15,9 +17,15 @@ import (
 	"github.com/AnhCaooo/electric-notifications/internal/config"
 	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/db"
+	"github.com/AnhCaooo/electric-notifications/internal/email"
 	"github.com/AnhCaooo/electric-notifications/internal/firebase"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
 	"github.com/AnhCaooo/electric-notifications/internal/rabbitmq"
+	"github.com/AnhCaooo/electric-notifications/internal/ratelimit"
+	"github.com/AnhCaooo/electric-notifications/internal/scheduler"
+	"github.com/AnhCaooo/electric-notifications/internal/webhook"
+	"github.com/AnhCaooo/electric-notifications/internal/webpush"
 	"github.com/AnhCaooo/go-goods/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -54,15 +62,61 @@ func main() {
 	}
 	defer mongo.Client.Disconnect(ctx)
 
+	// Manage the API keys of the services instead of starting the service, ex: `electric-notifications apikey list`
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKeyCommand(os.Stdout, mongo, os.Args[2:]); err != nil {
+			logger.Error(constants.Server, zap.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	cache := cache.NewCache(logger)
+	// Budgets of outbound requests per channel. Every channel is created once, so the HTTP server,
+	// the RabbitMQ consumer and the scheduler share the budget of the channel
+	limiter := func(channel string) *ratelimit.Limiter {
+		return ratelimit.New(channel, configuration.RateLimits[channel])
+	}
 	// Initialize FCM connection
-	firebase := firebase.NewFirebase(logger, ctx)
+	firebase := firebase.NewFirebase(logger, ctx, &configuration.Push, limiter(models.ChannelPush))
 	if err = firebase.EstablishConnection(); err != nil {
 		logger.Error(constants.Server, zap.Error(err))
 		os.Exit(1)
 	}
+	// Route all notifications through the dispatcher, according to the channels that every user selected
+	dispatcher := notifier.NewDispatcher(
+		logger,
+		mongo,
+		notifier.NewFCM(firebase, mongo),
+		notifier.NewWebhook(logger, webhook.NewSender(&configuration.Webhooks, limiter(models.ChannelWebhook)), mongo),
+	)
+	// Email channel is optional and only enabled when the SMTP server is configured
+	var mailer *email.Mailer
+	if configuration.Email.Host != "" {
+		mailer = email.NewMailer(&configuration.Email, limiter(models.ChannelEmail))
+		dispatcher.Register(notifier.NewEmail(mailer, mongo))
+	}
+	// Web Push channel is optional and only enabled when the VAPID key pair is configured
+	if configuration.VAPID.PrivateKey != "" {
+		webPushClient, err := webpush.NewClient(&configuration.VAPID, limiter(models.ChannelWebPush))
+		if err != nil {
+			logger.Error(constants.Server, zap.Error(err))
+			os.Exit(1)
+		}
+		dispatcher.Register(notifier.NewWebPush(logger, webPushClient, mongo, &configuration.Push))
+	}
+	// Store the notifications of users in their inbox, and record the outcome of every notification in the delivery log
+	inbox := notifier.NewInbox(logger, dispatcher, mongo)
+	journal := notifier.NewJournal(logger, inbox, mongo)
+	// every notifier applies the dry-run mode itself, so neither the inbox nor the delivery log store anything
+	// whichever of them the HTTP server, the RabbitMQ consumer or the scheduler sends through
+	inbox.SetDryRun(configuration.DryRun)
+	journal.SetDryRun(configuration.DryRun)
+	if configuration.DryRun {
+		logger.Warn("dry-run mode is enabled, notifications are validated but not delivered")
+	}
 	// Start server
-	run(ctx, logger, configuration, mongo, firebase, cache)
+	run(ctx, logger, configuration, mongo, journal, mailer, cache)
 }
 
 // run initializes and starts the HTTP server, sets up signal handling for graceful shutdown,
@@ -72,7 +126,8 @@ func run(
 	logger *zap.Logger,
 	config *models.Config,
 	mongo *db.Mongo,
-	firebase *firebase.Firebase,
+	notifier notifier.Notifier,
+	mailer *email.Mailer,
 	cache *cache.Cache,
 ) {
 	// Channel to listen for termination signals
@@ -86,14 +141,17 @@ func run(
 	stopChan := make(chan struct{})
 
 	// HTTP server
-	httpServer := api.NewHTTPServer(cache, config, ctx, firebase, logger, mongo)
+	httpServer := api.NewHTTPServer(cache, config, ctx, notifier, mailer, logger, mongo)
 	httpServer.Start(1, errChan, &wg)
 	// RabbitMQ consumer
-	rabbitMQ := rabbitmq.NewRabbit(ctx, &config.MessageBroker, logger, mongo, firebase)
+	rabbitMQ := rabbitmq.NewRabbit(ctx, config, logger, mongo, notifier)
 	if err := rabbitMQ.EstablishConnection(); err != nil {
 		logger.Fatal("failed to establish connection with RabbitMQ", zap.Error(err))
 	}
 	rabbitMQ.StartConsumers(&wg, errChan, stopChan)
+	// Scheduler for persisted notifications (ex: reminders)
+	jobScheduler := scheduler.NewScheduler(ctx, &config.Scheduler, logger, mongo, notifier)
+	jobScheduler.Start(3, &wg, errChan, stopChan)
 
 	// Monitor all errors from errChan and log them
 	go func() {
@@ -111,5 +169,5 @@ func run(
 	wg.Wait()
 	// Signal all errors to stop
 	close(errChan)
-	logger.Info("HTTP server and RabbitMQ exited gracefully")
+	logger.Info("HTTP server, RabbitMQ and scheduler exited gracefully")
 }
@@ -18,9 +18,48 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/notifications": {
+        "/v1/admin/apikeys": {
+            "get": {
+                "description": "Returns every key, including the revoked ones, the latest first. Only admins may use it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the API keys of the services",
+                "responses": {
+                    "200": {
+                        "description": "List of API keys",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "If the user is not an admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the keys from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "It retrieves the user ID from the request context and decodes the request body to get the notification message.\nThen validates the user ID and retrieves the associated device tokens from the database. Finally, it sends the notification message to the retrieved device tokens using Firebase.",
+                "description": "The service sends the key in the ` + "`" + `X-API-Key` + "`" + ` header instead of a bearer token, and may only use the endpoints of the scopes of the key: ` + "`" + `notifications:send` + "`" + ` allows ` + "`" + `POST /v1/notifications` + "`" + ` for any user and ` + "`" + `deliveries:read` + "`" + ` allows ` + "`" + `GET /v1/deliveries` + "`" + ` for any user.\nThe key is only returned in this response, it is stored as a hash. Only admins may use it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -28,26 +67,140 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "admin"
                 ],
-                "summary": "Sends notifications to user devices",
+                "summary": "Create an API key for a service",
                 "parameters": [
                     {
-                        "description": "represents a message to be sent to a user.",
+                        "description": "the name of the service and the scopes of the key",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.NotificationMessage"
+                            "$ref": "#/definitions/models.APIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "If the token is successfully inserted into the database.",
+                    "201": {
+                        "description": "The key, which is not available later on",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "If the user is not an admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error storing the key into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/apikeys/{id}": {
+            "delete": {
+                "description": "The key is rejected right away. Only admins may use it.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the API key",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "The key was revoked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid API key ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "If the user is not an admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If there is no active API key with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error updating the key in the database.",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/v1/admin/broadcasts": {
+            "post": {
+                "description": "Stores the broadcast and sends it to every user of the segment in the background. The progress is returned by ` + "`" + `GET /v1/admin/broadcasts/{id}` + "`" + `. Only admins may use it.\nIf the segment filters the devices (platforms, locales, app versions or areas), only the matching devices of the users receive the broadcast, and it is not sent by email or to webhooks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Broadcast a notification to a segment of users",
+                "parameters": [
+                    {
+                        "description": "the segment and the notification. Either ` + "`" + `title` + "`" + ` or ` + "`" + `body` + "`" + ` of the message is required.",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BroadcastRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "The broadcast was accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Broadcast"
+                        }
                     },
                     "400": {
                         "description": "Invalid request",
@@ -62,13 +215,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "If the user ID in the request body does not match the user ID in the context.",
+                        "description": "If the user is not an admin",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "If there is an error retrieving the device tokens or sending the notifications, it responds with an internal server error.",
+                        "description": "If there is an error storing the broadcast into the database.",
                         "schema": {
                             "type": "string"
                         }
@@ -76,9 +229,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/token": {
+        "/v1/admin/broadcasts/preview": {
             "post": {
-                "description": "It extracts the user ID from the request context and decodes the request body to get the notification token details. If the user ID in the request body does not match the user ID in the context, it returns a forbidden error.",
+                "description": "Counts the users who have a device matching the filters of the segment and, if the segment has a category, who opted in to it. Only admins may use it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -86,25 +239,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "admin"
                 ],
-                "summary": "Create a notification token that contains the user ID and device token",
+                "summary": "Preview the recipients of a broadcast",
                 "parameters": [
                     {
-                        "description": "represents a token used for sending notifications to  one or more specific device.",
+                        "description": "the users who receive the broadcast. An empty segment targets every user.",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.NotificationToken"
+                            "$ref": "#/definitions/models.Segment"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "If the token is successfully inserted into the database.",
+                        "description": "Number of users the segment targets",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.BroadcastPreview"
                         }
                     },
                     "400": {
@@ -120,57 +273,3051 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "If the user ID in the request body does not match the user ID in the context.",
+                        "description": "If the user is not an admin",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "If there is an error inserting the token into the database.",
+                        "description": "If there is an error retrieving the users from the database.",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.NotificationMessage": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Hello, World!"
-                },
-                "userId": {
-                    "type": "string",
-                    "example": "1234567890"
+        },
+        "/v1/admin/broadcasts/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the progress of a broadcast",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the broadcast",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The broadcast and its progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.Broadcast"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid broadcast ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "If the user is not an admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the broadcast does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the broadcast from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "models.NotificationToken": {
-            "type": "object",
-            "properties": {
-                "deviceId": {
-                    "description": "Identifier of the device associated with the notification token.\ntodo: maybe this could be a slice instead of single deviceID. This way we can send notifications to multiple devices that user has.",
-                    "type": "string",
-                    "example": "1234567890"
-                },
-                "id": {
-                    "description": "Unique identifier for the notification token.",
-                    "type": "string",
-                    "example": "1234567890"
-                },
-                "timestamp": {
-                    "description": "The time when the notification token was created.",
-                    "type": "string",
-                    "example": "2025-01-02 14:00:00 +0200 EET"
-                },
-                "userId": {
-                    "description": "Identifier of the user associated with the notification token.",
-                    "type": "string",
-                    "example": "1234567890"
+        "/v1/appliances": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appliances"
+                ],
+                "summary": "Get all appliances of the user",
+                "responses": {
+                    "200": {
+                        "description": "List of appliances",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Appliance"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the appliances from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "It registers an appliance (ex: dishwasher 2h/1.2 kWh) for the user in the access token. The appliance is only planned to run inside of the allowed local time range, which may wrap over midnight (ex: from 22:00 to 07:00).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appliances"
+                ],
+                "summary": "Register an appliance",
+                "parameters": [
+                    {
+                        "description": "represents the appliance to be registered",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Appliance"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The registered appliance",
+                        "schema": {
+                            "$ref": "#/definitions/models.Appliance"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error inserting the appliance into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/appliances/{id}": {
+            "delete": {
+                "tags": [
+                    "appliances"
+                ],
+                "summary": "Delete an appliance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the appliance",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "The appliance was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid appliance ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have an appliance with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error deleting the appliance from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/contract": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contract"
+                ],
+                "summary": "Get the electricity contract of the user",
+                "responses": {
+                    "200": {
+                        "description": "The contract of the user",
+                        "schema": {
+                            "$ref": "#/definitions/models.Contract"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user has not configured a contract",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the contract from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "It stores the contract of the user in the access token, replacing the previous one. The contract is used to report effective total prices (spot + margin + transfer fee + monthly fees per kWh) in all analysis and notifications, and to hint when the fixed-price contract would be cheaper.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contract"
+                ],
+                "summary": "Configure the electricity contract of the user",
+                "parameters": [
+                    {
+                        "description": "represents the contract of the user",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Contract"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The stored contract",
+                        "schema": {
+                            "$ref": "#/definitions/models.Contract"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error storing the contract into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "contract"
+                ],
+                "summary": "Delete the electricity contract of the user",
+                "responses": {
+                    "204": {
+                        "description": "The contract was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user has not configured a contract",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error deleting the contract from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/deliveries": {
+            "get": {
+                "description": "Returns what was sent to the user through every channel and whether it was delivered, the latest first.\nServices which call the API with an API key of the ` + "`" + `deliveries:read` + "`" + ` scope give the user in ` + "`" + `userId` + "`" + `.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get the delivery log of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time since which the deliveries are returned. Defaults to 7 days ago.",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only return the deliveries of given notification",
+                        "name": "notificationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the user whose deliveries are returned, required with an API key",
+                        "name": "userId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeliveryLog"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ` + "`" + `since` + "`" + ` time",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the deliveries from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inbox": {
+            "get": {
+                "description": "Returns the notifications which were sent to the user, the latest first, together with the number of unread notifications.\nThe older notifications are returned by giving the ` + "`" + `nextCursor` + "`" + ` of the page as ` + "`" + `before` + "`" + `.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inbox"
+                ],
+                "summary": "Get the inbox of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only return the notifications of given category (ex: reminder)",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of notifications in the page, at most 100. Defaults to 20.",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor of the page, returns the notifications older than it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of the inbox",
+                        "schema": {
+                            "$ref": "#/definitions/models.InboxPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the inbox from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inbox/read": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inbox"
+                ],
+                "summary": "Mark all notifications as read",
+                "responses": {
+                    "200": {
+                        "description": "Number of unread notifications left",
+                        "schema": {
+                            "$ref": "#/definitions/models.InboxCount"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error updating the notifications in the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inbox/unread": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inbox"
+                ],
+                "summary": "Get the number of unread notifications",
+                "responses": {
+                    "200": {
+                        "description": "Number of unread notifications",
+                        "schema": {
+                            "$ref": "#/definitions/models.InboxCount"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error counting the notifications in the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inbox/{id}/read": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inbox"
+                ],
+                "summary": "Mark a notification as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the notification in the inbox",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of unread notifications left",
+                        "schema": {
+                            "$ref": "#/definitions/models.InboxCount"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have a notification with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error updating the notification in the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications": {
+            "post": {
+                "description": "It retrieves the user ID from the request context and decodes the request body to get the notification message.\nThen validates the user ID and sends the notification message to the user through every delivery channel (ex: the devices of the user using Firebase).\nThe response contains the ID of the notification and the outcome of every recipient (ex: device). If some of the deliveries failed, the status is 207 and the failed recipients have the reason of the failure.\nWith ` + "`" + `dryRun=true` + "`" + ` (or when the service runs in dry-run mode) the notification is only validated and rendered, and the outcome of every recipient is returned without delivering anything.\nWith ` + "`" + `sendAt` + "`" + ` the notification is scheduled instead, and sent at given time even if the service restarts in between. The scheduled notification is returned with status 202.\nServices which call the API with an API key of the ` + "`" + `notifications:send` + "`" + ` scope send the notification to the user given in ` + "`" + `userId` + "`" + `, and are recorded in the delivery log.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Sends notifications to user devices",
+                "parameters": [
+                    {
+                        "description": "represents a message to be sent to all devices that user has. Either ` + "`" + `title` + "`" + ` or ` + "`" + `body` + "`" + ` is required.",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "validate and render the notification without delivering it",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request. A retry with the same key returns the response of the first request without sending the notification again",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If every delivery succeeded. In dry-run mode, what would have been sent to every recipient.",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationResult"
+                        }
+                    },
+                    "202": {
+                        "description": "If the notification was scheduled.",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "207": {
+                        "description": "If some of the deliveries failed.",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "If the API key of the service does not have the ` + "`" + `notifications:send` + "`" + ` scope.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "If a request with the same ` + "`" + `Idempotency-Key` + "`" + ` is still being processed.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "If the ` + "`" + `Idempotency-Key` + "`" + ` was already used for a different request.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the device tokens or the notification could not be sent through any channel, it responds with an internal server error.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications/scheduled/{id}": {
+            "get": {
+                "description": "Returns the notification together with the state of its sending, ex: pending until it is sent.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get a scheduled notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the scheduled notification",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The scheduled notification",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have a scheduled notification with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the notification from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Cancel a scheduled notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the scheduled notification",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "The notification was cancelled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have a scheduled notification with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "If the notification is already being sent or has been sent",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error deleting the notification from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/planner/ev": {
+            "post": {
+                "description": "It allocates the requested energy to the cheapest hours between now and the deadline using today's and tomorrow's effective prices of the user's contract, at most ` + "`" + `maxPowerKW` + "`" + ` per hour. The hours do not need to be contiguous and an hour may be used only partially.\nIf ` + "`" + `notify` + "`" + ` is true, a summary of the plan is also sent to the devices of the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planner"
+                ],
+                "summary": "Plan electric car charging before a deadline",
+                "parameters": [
+                    {
+                        "description": "represents the charging need",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChargingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The charging plan. If ` + "`" + `fulfilled` + "`" + ` is false, the requested energy does not fit before the deadline and the plan charges as much as possible.",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChargingPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If there are no prices available before the deadline",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the prices or sending the notification.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/preferences": {
+            "get": {
+                "description": "It returns the delivery channels that the user selected and the email address of the email channel. Users who have not selected any channels receive notifications from the default channels.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Get the notification preferences of the user",
+                "responses": {
+                    "200": {
+                        "description": "The preferences of the user",
+                        "schema": {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the preferences from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/preferences/categories": {
+            "put": {
+                "description": "It stores the categories of broadcasts (ex: tips) which the user opted in to. Broadcasts which target a category are only sent to the users who opted in to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Opt in to categories of broadcasts",
+                "parameters": [
+                    {
+                        "description": "the selected categories",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CategorySelection"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The updated preferences",
+                        "schema": {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error storing the preferences into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/preferences/channels": {
+            "put": {
+                "description": "It stores the delivery channels which the user receives notifications from. The email channel only delivers to a confirmed email address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Select the delivery channels of the user",
+                "parameters": [
+                    {
+                        "description": "the selected delivery channels",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChannelSelection"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The updated preferences",
+                        "schema": {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error storing the preferences into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/preferences/email": {
+            "put": {
+                "description": "It stores the email address as unconfirmed and sends an email with a confirmation link to it. The address receives notifications only after the link has been opened. Registering a new address replaces the previous one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Register the email address of the user",
+                "parameters": [
+                    {
+                        "description": "the email address of the user",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmailAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "The confirmation email was sent",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error storing the address or sending the confirmation email.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "If the email channel is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/preferences/email/verify": {
+            "get": {
+                "description": "It confirms the email address whose confirmation link contains given token. The link is valid for 24 hours and can be used only once.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Confirm the email address of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the token of the confirmation link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The email address was confirmed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "If the token is missing",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the token is unknown, already used or expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error updating the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/prices/{date}": {
+            "get": {
+                "description": "It returns the price series of given date where every price is the effective total price of the hour according to the contract of the user. Without a contract the spot prices are returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prices"
+                ],
+                "summary": "Get the effective prices of a date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "date of the price series in format YYYY-MM-DD",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The effective price series",
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceSeries"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid date",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If there is no price series stored for the date",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the prices or the contract",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/prices/{date}/chart.png": {
+            "get": {
+                "description": "It renders a bar chart of the price series of given date where the hours that are cheaper than the average price of the day are highlighted.\nThe image changes only when the prices of the date are stored again, so the response can be revalidated with its ETag. This endpoint does not require authentication because the image is fetched by the device's operating system when showing a notification.",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "prices"
+                ],
+                "summary": "Get the price chart of a date as PNG image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "date of the price series in format YYYY-MM-DD",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PNG image of the price chart",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid date",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If there is no price series stored for the date",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the prices or rendering the chart",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/recommendations": {
+            "get": {
+                "description": "It finds the cheapest start time within the allowed time range of each appliance of the user, together with the estimated cost of the run based on the effective prices of the user's contract. Appliances which run does not fit into the allowed time range of the day are left out.\nThe prices of the previous day are included, so a time range over midnight (ex: 22:00 - 07:00) is planned from the evening before the date until the morning of the date. Runs which would start in the past are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appliances"
+                ],
+                "summary": "Get appliance scheduling recommendations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "date of the prices in format YYYY-MM-DD, defaults to tomorrow",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of recommendations",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Recommendation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid date",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the prices of the date are not available",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the appliances or prices from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reminders": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "Get all reminders of the user",
+                "responses": {
+                    "200": {
+                        "description": "List of reminders",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Reminder"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the reminders from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "It creates a reminder for the user in the access token. Every time new prices are published, the service computes when the cheapest window of ` + "`" + `windowHours` + "`" + ` hours (type ` + "`" + `cheapest_window` + "`" + `) or each period below ` + "`" + `threshold` + "`" + ` (type ` + "`" + `threshold` + "`" + `) begins, and sends a push notification ` + "`" + `minutesBefore` + "`" + ` minutes before it.\nIf the prices of today or tomorrow are already published, the reminders for them are scheduled right away.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "Create a reminder for cheap price periods",
+                "parameters": [
+                    {
+                        "description": "represents the reminder to be created",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Reminder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The created reminder",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reminder"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error inserting the reminder into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reminders/{id}": {
+            "delete": {
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "Delete a reminder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the reminder",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "The reminder was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid reminder ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have a reminder with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error deleting the reminder from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/token": {
+            "post": {
+                "description": "It extracts the user ID from the request context and decodes the request body to get the notification token details. If the user ID in the request body does not match the user ID in the context, it returns a forbidden error.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Create a notification token that contains the user ID and device tokens",
+                "parameters": [
+                    {
+                        "description": "represents a token used for sending notifications to  one or more specific device.",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the token is successfully inserted into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "If the user ID in the request body does not match the user ID in the context.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error inserting the token into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhooks of the user",
+                "responses": {
+                    "200": {
+                        "description": "List of webhooks, without their secrets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error retrieving the webhooks from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "It registers an HTTPS URL which receives every notification of the user as a signed JSON POST request. The response contains the secret which signs the requests; it is not returned again.\nEvery request has the headers ` + "`" + `X-Webhook-Timestamp` + "`" + ` (unix seconds) and ` + "`" + `X-Webhook-Signature` + "`" + ` (\"sha256=\" + hex HMAC-SHA256 of \"\u003ctimestamp\u003e.\u003cbody\u003e\" with the secret). Reject requests with an old timestamp to prevent replays.\nFailed deliveries (non-2xx responses) are retried with backoff, and the webhook is disabled after too many consecutive failed deliveries.\nThe host of the URL must be public: addresses in loopback, private, link-local and other internal ranges are rejected. A user may register a limited number of webhooks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "represents the webhook to be registered",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The registered webhook together with its secret",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "If the user already has the maximum number of webhooks",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error inserting the webhook into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{id}": {
+            "delete": {
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "The webhook was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have a webhook with given ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error deleting the webhook from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webpush/key": {
+            "get": {
+                "description": "It returns the public key of the service which is given as ` + "`" + `applicationServerKey` + "`" + ` to ` + "`" + `PushManager.subscribe()` + "`" + ` in the browser.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webpush"
+                ],
+                "summary": "Get the public VAPID key",
+                "responses": {
+                    "200": {
+                        "description": "The public VAPID key",
+                        "schema": {
+                            "$ref": "#/definitions/models.VAPIDKey"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "If the Web Push channel is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webpush/subscriptions": {
+            "post": {
+                "description": "It stores the subscription of a browser (the result of ` + "`" + `PushSubscription.toJSON()` + "`" + `) next to the device tokens of the user. Registering the same endpoint again refreshes the subscription.\nThe endpoint must belong to a known push service: FCM (Chromium based browsers), Mozilla autopush (Firefox), Apple (Safari) or WNS (legacy Edge).",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webpush"
+                ],
+                "summary": "Register a Web Push subscription",
+                "parameters": [
+                    {
+                        "description": "the subscription of the browser",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebPushSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The subscription was registered",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error storing the subscription into the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "If the Web Push channel is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "webpush"
+                ],
+                "summary": "Delete a Web Push subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "endpoint of the subscription",
+                        "name": "endpoint",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "The subscription was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "If the endpoint is missing",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthenticated/Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user does not have a subscription with given endpoint",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "If there is an error deleting the subscription from the database.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "When the key was created, last used and revoked. A revoked key is not accepted anymore.",
+                    "type": "string",
+                    "example": "2025-01-22T10:00:00Z"
+                },
+                "createdBy": {
+                    "description": "Identifier of the admin who created the key, empty if it was created with the command line.",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6790b1c2d3e4f5a6b7c8d9e0"
+                },
+                "lastUsedAt": {
+                    "type": "string",
+                    "example": "2025-01-22T12:00:00Z"
+                },
+                "name": {
+                    "description": "Name of the service which uses the key. It is recorded in the delivery log of the notifications that the service sends.",
+                    "type": "string",
+                    "example": "billing"
+                },
+                "prefix": {
+                    "description": "Beginning of the key, so the key can be recognized without revealing it.",
+                    "type": "string",
+                    "example": "en_Xk3v9QaB"
+                },
+                "revokedAt": {
+                    "type": "string",
+                    "example": "2025-02-01T08:00:00Z"
+                },
+                "scopes": {
+                    "description": "What the service may do with the key (ex: notifications:send).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notifications:send"
+                    ]
+                }
+            }
+        },
+        "models.APIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Name of the service which uses the key.",
+                    "type": "string",
+                    "example": "billing"
+                },
+                "scopes": {
+                    "description": "What the service may do with the key, any of: notifications:send, deliveries:read.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notifications:send"
+                    ]
+                }
+            }
+        },
+        "models.APNSPush": {
+            "type": "object",
+            "properties": {
+                "badge": {
+                    "description": "Badge number shown on the app icon. Nil leaves the badge untouched, zero removes it.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "sound": {
+                    "description": "Sound to play when the notification is shown (ex: \"default\").",
+                    "type": "string",
+                    "example": "default"
+                },
+                "threadId": {
+                    "description": "Identifier which groups the notifications of the same thread.",
+                    "type": "string",
+                    "example": "prices"
+                }
+            }
+        },
+        "models.AndroidPush": {
+            "type": "object",
+            "properties": {
+                "channelId": {
+                    "description": "Notification channel which the notification is posted to.",
+                    "type": "string",
+                    "example": "prices"
+                },
+                "color": {
+                    "description": "Color of the notification icon in format #rrggbb.",
+                    "type": "string",
+                    "example": "#2ea043"
+                },
+                "notificationCount": {
+                    "description": "Number of items the notification represents, shown as the badge of the app by some launchers.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "priority": {
+                    "description": "Delivery priority of the message: \"normal\" or \"high\".",
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "high"
+                    ],
+                    "example": "high"
+                },
+                "sound": {
+                    "description": "Sound to play when the notification is shown (ex: \"default\").",
+                    "type": "string",
+                    "example": "default"
+                }
+            }
+        },
+        "models.Appliance": {
+            "type": "object",
+            "properties": {
+                "allowedFrom": {
+                    "description": "The earliest local time (HH:MM) when the appliance may start.",
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "allowedTo": {
+                    "description": "The local time (HH:MM) by which the run must be finished. It may be on the next day, ex: from 22:00 to 07:00.",
+                    "type": "string",
+                    "example": "07:00"
+                },
+                "createdAt": {
+                    "description": "The time when the appliance was created.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "durationHours": {
+                    "description": "How many hours a single run of the appliance takes, in whole hours between 1 and 24 (ex: a run of 1.5 hours is given as 2).",
+                    "type": "integer",
+                    "example": 2
+                },
+                "energyKWh": {
+                    "description": "How much energy a single run of the appliance consumes in kWh.",
+                    "type": "number",
+                    "example": 1.2
+                },
+                "id": {
+                    "description": "Unique identifier for the appliance.",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "name": {
+                    "description": "Name of the appliance.",
+                    "type": "string",
+                    "example": "Dishwasher"
+                },
+                "userId": {
+                    "description": "Identifier of the user who owns the appliance.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.Broadcast": {
+            "type": "object",
+            "properties": {
+                "completedAt": {
+                    "type": "string",
+                    "example": "2025-01-02 14:03:00 +0200 EET"
+                },
+                "createdAt": {
+                    "description": "The time when the broadcast was created, started and completed.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "createdBy": {
+                    "description": "Identifier of the admin who created the broadcast.",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "error": {
+                    "description": "The error which stopped the broadcast.",
+                    "type": "string"
+                },
+                "failure": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "id": {
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "message": {
+                    "description": "The notification which is sent.",
+                    "$ref": "#/definitions/models.NotificationMessage"
+                },
+                "processed": {
+                    "description": "How many users the broadcast has been sent to.",
+                    "type": "integer",
+                    "example": 600
+                },
+                "segment": {
+                    "description": "The users who receive the broadcast.",
+                    "$ref": "#/definitions/models.Segment"
+                },
+                "startedAt": {
+                    "type": "string",
+                    "example": "2025-01-02 14:00:05 +0200 EET"
+                },
+                "status": {
+                    "description": "The current state of the broadcast.",
+                    "type": "string",
+                    "example": "running"
+                },
+                "success": {
+                    "description": "How many deliveries succeeded and failed so far.",
+                    "type": "integer",
+                    "example": 1100
+                },
+                "total": {
+                    "description": "How many users the segment targets, known once the sending has started.",
+                    "type": "integer",
+                    "example": 1250
+                }
+            }
+        },
+        "models.BroadcastPreview": {
+            "type": "object",
+            "properties": {
+                "recipients": {
+                    "type": "integer",
+                    "example": 1250
+                }
+            }
+        },
+        "models.BroadcastRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "The notification. The user ID of the message is ignored, as every user receives the notification.",
+                    "$ref": "#/definitions/models.NotificationMessage"
+                },
+                "segment": {
+                    "$ref": "#/definitions/models.Segment"
+                }
+            }
+        },
+        "models.CategorySelection": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "description": "Categories of broadcasts which the user receives. Empty opts out of every category.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "tips",
+                        "news"
+                    ]
+                }
+            }
+        },
+        "models.ChannelSelection": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Delivery channels which the user receives notifications from. At least one channel is required.",
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "push",
+                            "email",
+                            "webhook",
+                            "webpush"
+                        ]
+                    },
+                    "example": [
+                        "push",
+                        "email"
+                    ]
+                }
+            }
+        },
+        "models.ChargingPlan": {
+            "type": "object",
+            "properties": {
+                "fulfilled": {
+                    "description": "Whether the requested energy fits before the deadline. If not, the plan charges as much as possible.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "schedule": {
+                    "description": "The charging slots in chronological order.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChargingSlot"
+                    }
+                },
+                "totalCost": {
+                    "description": "The total cost of the plan.",
+                    "type": "number",
+                    "example": 52.4
+                },
+                "totalEnergyKWh": {
+                    "description": "The total energy that is charged by the plan in kWh.",
+                    "type": "number",
+                    "example": 30
+                },
+                "unit": {
+                    "description": "Currency unit of the costs.",
+                    "type": "string",
+                    "example": "c"
+                }
+            }
+        },
+        "models.ChargingRequest": {
+            "type": "object",
+            "properties": {
+                "deadline": {
+                    "description": "The time by which the charging must be finished.",
+                    "type": "string",
+                    "example": "2024-12-09T07:00:00+02:00"
+                },
+                "energyKWh": {
+                    "description": "How much energy has to be charged in kWh.",
+                    "type": "number",
+                    "example": 30
+                },
+                "maxPowerKW": {
+                    "description": "The maximum charging power in kW.",
+                    "type": "number",
+                    "example": 11
+                },
+                "notify": {
+                    "description": "Whether the plan is also sent to the devices of the user as a push notification.",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.ChargingSlot": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "description": "The cost of the energy charged during the slot, in the currency unit of the prices (ex: c).",
+                    "type": "number",
+                    "example": 9.16
+                },
+                "end": {
+                    "description": "The time when the charging ends in UTC.",
+                    "type": "string",
+                    "example": "2024-12-09T01:40:00Z"
+                },
+                "energyKWh": {
+                    "description": "The energy charged during the slot in kWh.",
+                    "type": "number",
+                    "example": 7.33
+                },
+                "price": {
+                    "description": "The price of the entry.",
+                    "type": "number",
+                    "example": 1.25
+                },
+                "start": {
+                    "description": "The time when the charging starts in UTC.",
+                    "type": "string",
+                    "example": "2024-12-09T01:00:00Z"
+                },
+                "startTime": {
+                    "description": "The local time when the charging starts, same format as ` + "`" + `Data.Time` + "`" + `.",
+                    "type": "string",
+                    "example": "2024-12-09 03:00:00"
+                }
+            }
+        },
+        "models.Contract": {
+            "type": "object",
+            "properties": {
+                "fixedPrice": {
+                    "description": "Price of a fixed-price contract to compare the spot contract with. Zero disables the comparison.",
+                    "type": "number",
+                    "example": 8.5
+                },
+                "margin": {
+                    "description": "Margin that the retailer adds on top of the spot price.",
+                    "type": "number",
+                    "example": 0.49
+                },
+                "monthlyConsumptionKWh": {
+                    "description": "Estimated monthly consumption in kWh, used to spread the monthly fees over every consumed kWh.\nZero leaves the monthly fees out of the hourly price.",
+                    "type": "number",
+                    "example": 500
+                },
+                "monthlyFees": {
+                    "description": "Monthly basic fees of the retailer and the network operator together, in the currency unit of the prices (ex: c).",
+                    "type": "number",
+                    "example": 1000
+                },
+                "transfer": {
+                    "description": "Transfer tariff of the network operator.",
+                    "$ref": "#/definitions/models.TransferTariff"
+                },
+                "updatedAt": {
+                    "description": "The time when the contract was last updated.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "userId": {
+                    "description": "Identifier of the user who owns the contract.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "When the key was created, last used and revoked. A revoked key is not accepted anymore.",
+                    "type": "string",
+                    "example": "2025-01-22T10:00:00Z"
+                },
+                "createdBy": {
+                    "description": "Identifier of the admin who created the key, empty if it was created with the command line.",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6790b1c2d3e4f5a6b7c8d9e0"
+                },
+                "key": {
+                    "description": "The key, which the service sends in the ` + "`" + `X-API-Key` + "`" + ` header.",
+                    "type": "string",
+                    "example": "en_Xk3v9QaB7tLmN2pR5sUvW8yZ1cEfHjK4"
+                },
+                "lastUsedAt": {
+                    "type": "string",
+                    "example": "2025-01-22T12:00:00Z"
+                },
+                "name": {
+                    "description": "Name of the service which uses the key. It is recorded in the delivery log of the notifications that the service sends.",
+                    "type": "string",
+                    "example": "billing"
+                },
+                "prefix": {
+                    "description": "Beginning of the key, so the key can be recognized without revealing it.",
+                    "type": "string",
+                    "example": "en_Xk3v9QaB"
+                },
+                "revokedAt": {
+                    "type": "string",
+                    "example": "2025-02-01T08:00:00Z"
+                },
+                "scopes": {
+                    "description": "What the service may do with the key (ex: notifications:send).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notifications:send"
+                    ]
+                }
+            }
+        },
+        "models.Data": {
+            "type": "object",
+            "properties": {
+                "includeVat": {
+                    "description": "IncludeVat is legacy property that return string value and value \"0\" means no VAT included and string \"1\" is included",
+                    "type": "string",
+                    "enum": [
+                        "0",
+                        "1"
+                    ],
+                    "example": "1"
+                },
+                "isToday": {
+                    "description": "IsToday indicates whether the current time is today or not",
+                    "type": "boolean",
+                    "example": false
+                },
+                "orig_time": {
+                    "description": "the current time where server is located",
+                    "type": "string",
+                    "example": "2024-12-09 00:00:00"
+                },
+                "price": {
+                    "description": "the price of specified time range",
+                    "type": "number",
+                    "example": 2.47
+                },
+                "time": {
+                    "description": "the current time.",
+                    "type": "string",
+                    "example": "2024-12-09 00:00:00"
+                },
+                "time_utc": {
+                    "description": "timestamp in UTC format",
+                    "type": "string",
+                    "example": "2024-12-08 22:00:00"
+                },
+                "vat_factor": {
+                    "description": "amount of VAT that applies to electric price.",
+                    "type": "number",
+                    "example": 1.255
+                }
+            }
+        },
+        "models.DeliveryLog": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "How many times the delivery was attempted.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "category": {
+                    "type": "string",
+                    "example": "daily_prices"
+                },
+                "channel": {
+                    "description": "Delivery channel which was used (ex: push).",
+                    "type": "string",
+                    "example": "push"
+                },
+                "completedAt": {
+                    "type": "string",
+                    "example": "2024-12-16T19:00:01Z"
+                },
+                "error": {
+                    "description": "Human readable reason of the failure.",
+                    "type": "string",
+                    "example": "Requested entity was not found."
+                },
+                "errorCode": {
+                    "description": "Machine readable reason of the failure (ex: UNREGISTERED).",
+                    "type": "string",
+                    "example": "UNREGISTERED"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6760a7d5e13f1c2a9c8b4567"
+                },
+                "messageId": {
+                    "description": "Identifier of the message given by the channel (ex: FCM message ID).",
+                    "type": "string",
+                    "example": "projects/app/messages/0:1234"
+                },
+                "notificationId": {
+                    "description": "Identifier of the notification which the delivery belongs to.",
+                    "type": "string",
+                    "example": "6760a7d5e13f1c2a9c8b4566"
+                },
+                "recipient": {
+                    "description": "The recipient within the channel, ex: device token or topic.",
+                    "type": "string",
+                    "example": "fcm-device-token"
+                },
+                "sentAt": {
+                    "description": "When the sending of the notification started and when the channel reported the outcome.",
+                    "type": "string",
+                    "example": "2024-12-16T19:00:00Z"
+                },
+                "service": {
+                    "description": "Name of the service which sent the notification with an API key, if any.",
+                    "type": "string",
+                    "example": "billing"
+                },
+                "source": {
+                    "description": "Where the notification came from (api, rabbitmq or scheduler).",
+                    "type": "string",
+                    "example": "rabbitmq"
+                },
+                "status": {
+                    "description": "Outcome of the delivery (delivered or failed).",
+                    "type": "string",
+                    "example": "delivered"
+                },
+                "title": {
+                    "description": "Title and category of the notification, so the notification can be recognized later on.",
+                    "type": "string",
+                    "example": "Electricity prices for tomorrow"
+                },
+                "userId": {
+                    "description": "Identifier of the user who received the notification.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.DeliveryResult": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "How many times the delivery was attempted, if the channel retries failed deliveries.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "channel": {
+                    "description": "Delivery channel which was used (ex: push).",
+                    "type": "string",
+                    "example": "push"
+                },
+                "error": {
+                    "description": "Human readable reason of the failure.",
+                    "type": "string",
+                    "example": "Requested entity was not found."
+                },
+                "errorCode": {
+                    "description": "Machine readable reason of the failure (ex: UNREGISTERED).",
+                    "type": "string",
+                    "example": "UNREGISTERED"
+                },
+                "messageId": {
+                    "description": "Identifier of the message given by the channel (ex: FCM message ID).",
+                    "type": "string",
+                    "example": "projects/app/messages/0:1234"
+                },
+                "preview": {
+                    "description": "What would have been sent to the recipient in dry-run mode, ex: the rendered email or the JSON payload of a webhook.",
+                    "type": "string",
+                    "example": "{\"title\":\"Electricity prices for tomorrow\"}"
+                },
+                "recipient": {
+                    "description": "The recipient within the channel, ex: device token or topic.",
+                    "type": "string",
+                    "example": "fcm-device-token"
+                },
+                "success": {
+                    "description": "Whether the notification was accepted by the channel.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "userId": {
+                    "description": "Identifier of the user who owns the recipient, if known.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.EmailAddress": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Email address which receives the notifications of the email channel.",
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
+        "models.InboxCount": {
+            "type": "object",
+            "properties": {
+                "unread": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.InboxItem": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body text of the notification.",
+                    "type": "string",
+                    "example": "Tomorrow price is 2.47 c/kWh"
+                },
+                "category": {
+                    "description": "Category of the notification (ex: reminder).",
+                    "type": "string",
+                    "example": "reminder"
+                },
+                "clickAction": {
+                    "description": "The action which is triggered when the user taps on the notification.",
+                    "type": "string",
+                    "example": "OPEN_PRICES"
+                },
+                "createdAt": {
+                    "description": "The time when the notification was sent.",
+                    "type": "string",
+                    "example": "2024-12-16T19:00:00Z"
+                },
+                "data": {
+                    "description": "Custom key-value pairs of the notification.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "imageUrl": {
+                    "description": "URL of the image of the notification.",
+                    "type": "string",
+                    "example": "https://example.com/v1/prices/2024-12-09/chart.png"
+                },
+                "notificationId": {
+                    "description": "Identifier of the notification in the delivery log.",
+                    "type": "string",
+                    "example": "6760a7d5e13f1c2a9c8b4566"
+                },
+                "read": {
+                    "description": "Whether the user has read the notification, and when.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "readAt": {
+                    "type": "string",
+                    "example": "2024-12-16T19:05:00Z"
+                },
+                "title": {
+                    "description": "Title of the notification.",
+                    "type": "string",
+                    "example": "Electricity prices for tomorrow"
+                },
+                "userId": {
+                    "description": "Identifier of the user who received the notification.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.InboxPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InboxItem"
+                    }
+                },
+                "nextCursor": {
+                    "description": "Cursor of the next page, given as ` + "`" + `before` + "`" + ` to get the older notifications. Empty on the last page.",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "unread": {
+                    "description": "How many notifications of the user are unread.",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.Job": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Number of times the job has been attempted.",
+                    "type": "integer",
+                    "example": 0
+                },
+                "createdAt": {
+                    "description": "The time when the job was created.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "id": {
+                    "description": "Unique identifier for the job.",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "kind": {
+                    "description": "The kind of the job (ex: reminder).",
+                    "type": "string",
+                    "example": "reminder"
+                },
+                "lastError": {
+                    "description": "The error of the last failed attempt.",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "The notification which is sent when the job is executed.",
+                    "$ref": "#/definitions/models.NotificationMessage"
+                },
+                "referenceId": {
+                    "description": "Identifier of the resource which created the job (ex: reminder ID).",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f11"
+                },
+                "runAt": {
+                    "description": "The time when the job should be executed.",
+                    "type": "string",
+                    "example": "2025-01-03 02:45:00 +0200 EET"
+                },
+                "service": {
+                    "description": "Name of the service which scheduled the notification with an API key, if any.",
+                    "type": "string",
+                    "example": "billing"
+                },
+                "status": {
+                    "description": "The current state of the job.",
+                    "type": "string",
+                    "example": "pending"
+                },
+                "timeZone": {
+                    "description": "The time zone which the run time was given in, if any.",
+                    "type": "string",
+                    "example": "Europe/Helsinki"
+                }
+            }
+        },
+        "models.NotificationMessage": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body text of the notification.",
+                    "type": "string",
+                    "example": "Tomorrow price is 2.47 c/kWh"
+                },
+                "category": {
+                    "description": "Category of the notification (ex: reminder). It selects the platform-specific configuration of the category.",
+                    "type": "string",
+                    "example": "reminder"
+                },
+                "clickAction": {
+                    "description": "The action (ex: Android intent filter, iOS category or web URL) which is triggered when the user taps on the notification.",
+                    "type": "string",
+                    "example": "OPEN_PRICES"
+                },
+                "data": {
+                    "description": "Custom key-value pairs which are delivered to the app together with the notification.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "imageUrl": {
+                    "description": "URL of an image that is shown in the expanded notification.",
+                    "type": "string",
+                    "example": "https://example.com/v1/prices/2024-12-09/chart.png"
+                },
+                "message": {
+                    "description": "Deprecated: use ` + "`" + `body` + "`" + ` instead. It is used as the body when the body is empty.",
+                    "type": "string",
+                    "example": "Hello, World!"
+                },
+                "platformOverrides": {
+                    "description": "Platform-specific configuration of this message, applied on top of the defaults and the category configuration.",
+                    "$ref": "#/definitions/models.PlatformConfig"
+                },
+                "title": {
+                    "description": "Title of the notification.",
+                    "type": "string",
+                    "example": "Electricity prices for tomorrow"
+                },
+                "userId": {
+                    "description": "Identifier of the user who receives the notification.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.NotificationRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body text of the notification.",
+                    "type": "string",
+                    "example": "Tomorrow price is 2.47 c/kWh"
+                },
+                "category": {
+                    "description": "Category of the notification (ex: reminder). It selects the platform-specific configuration of the category.",
+                    "type": "string",
+                    "example": "reminder"
+                },
+                "clickAction": {
+                    "description": "The action (ex: Android intent filter, iOS category or web URL) which is triggered when the user taps on the notification.",
+                    "type": "string",
+                    "example": "OPEN_PRICES"
+                },
+                "data": {
+                    "description": "Custom key-value pairs which are delivered to the app together with the notification.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "imageUrl": {
+                    "description": "URL of an image that is shown in the expanded notification.",
+                    "type": "string",
+                    "example": "https://example.com/v1/prices/2024-12-09/chart.png"
+                },
+                "message": {
+                    "description": "Deprecated: use ` + "`" + `body` + "`" + ` instead. It is used as the body when the body is empty.",
+                    "type": "string",
+                    "example": "Hello, World!"
+                },
+                "platformOverrides": {
+                    "description": "Platform-specific configuration of this message, applied on top of the defaults and the category configuration.",
+                    "$ref": "#/definitions/models.PlatformConfig"
+                },
+                "sendAt": {
+                    "description": "The time when the notification is sent, in RFC 3339 format (ex: \"2025-01-03T07:00:00+02:00\"),\nor as a local time without offset (ex: \"2025-01-03T07:00:00\") in the time zone of ` + "`" + `timeZone` + "`" + `. Empty sends right away.",
+                    "type": "string",
+                    "example": "2025-01-03T07:00:00"
+                },
+                "timeZone": {
+                    "description": "IANA time zone of ` + "`" + `sendAt` + "`" + ` when it has no offset (ex: \"Europe/Helsinki\"). Defaults to UTC.",
+                    "type": "string",
+                    "example": "Europe/Helsinki"
+                },
+                "title": {
+                    "description": "Title of the notification.",
+                    "type": "string",
+                    "example": "Electricity prices for tomorrow"
+                },
+                "userId": {
+                    "description": "Identifier of the user who receives the notification.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.NotificationResult": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "description": "Whether the notification was only validated and rendered, without being delivered.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "error": {
+                    "description": "Errors of the channels which failed as a whole, if any.",
+                    "type": "string",
+                    "example": "email: failed to connect to SMTP server"
+                },
+                "failure": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "description": "The notification which was sent.",
+                    "$ref": "#/definitions/models.NotificationMessage"
+                },
+                "notificationId": {
+                    "description": "Identifier of the notification, under which its deliveries are recorded in the delivery log.",
+                    "type": "string",
+                    "example": "6760a7d5e13f1c2a9c8b4566"
+                },
+                "results": {
+                    "description": "The outcome for every recipient of every channel, ex: the status and the reason of the failure of every device.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DeliveryResult"
+                    }
+                },
+                "success": {
+                    "description": "How many deliveries succeeded and failed.",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.NotificationToken": {
+            "type": "object",
+            "properties": {
+                "appVersion": {
+                    "description": "Version of the app on the device. Used to target broadcasts.",
+                    "type": "string",
+                    "example": "1.4.0"
+                },
+                "area": {
+                    "description": "Electricity price area of the user on the device (ex: \"FI\"). Used to target broadcasts.",
+                    "type": "string",
+                    "example": "FI"
+                },
+                "deviceId": {
+                    "description": "Identifier of the device associated with the notification token.\ntodo: maybe this could be a slice instead of single deviceID. This way we can send notifications to multiple devices that user has.",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "id": {
+                    "description": "Unique identifier for the notification token.",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "locale": {
+                    "description": "Language of the app on the device as BCP 47 tag (ex: \"fi-FI\"). Used to target broadcasts.",
+                    "type": "string",
+                    "example": "fi-FI"
+                },
+                "platform": {
+                    "description": "Platform of the device: \"android\", \"ios\" or \"web\". It decides which platform-specific configuration is sent to the device.",
+                    "type": "string",
+                    "enum": [
+                        "android",
+                        "ios",
+                        "web"
+                    ],
+                    "example": "android"
+                },
+                "timestamp": {
+                    "description": "The time when the notification token was created.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "userId": {
+                    "description": "Identifier of the user associated with the notification token.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.PlatformConfig": {
+            "type": "object",
+            "properties": {
+                "android": {
+                    "$ref": "#/definitions/models.AndroidPush"
+                },
+                "apns": {
+                    "$ref": "#/definitions/models.APNSPush"
+                },
+                "webpush": {
+                    "$ref": "#/definitions/models.WebPush"
+                }
+            }
+        },
+        "models.Preferences": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "description": "Categories of broadcasts which the user opted in to (ex: \"tips\").",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "tips",
+                        "news"
+                    ]
+                },
+                "channels": {
+                    "description": "Delivery channels which the user receives notifications from. If not set, the default channels are used.",
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "push",
+                            "email",
+                            "webhook",
+                            "webpush"
+                        ]
+                    },
+                    "example": [
+                        "push",
+                        "email"
+                    ]
+                },
+                "email": {
+                    "description": "Email address which receives the notifications of the email channel.",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "emailVerified": {
+                    "description": "Whether the user has confirmed the email address. Unconfirmed addresses never receive notifications.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "updatedAt": {
+                    "description": "The time when the preferences were last updated.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "userId": {
+                    "description": "Identifier of the user who owns the preferences.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.PriceSeries": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Data"
+                    }
+                },
+                "name": {
+                    "description": "unit of electric price",
+                    "type": "string",
+                    "example": "c/kWh"
+                }
+            }
+        },
+        "models.Recommendation": {
+            "type": "object",
+            "properties": {
+                "applianceId": {
+                    "description": "Identifier of the appliance.",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "endTime": {
+                    "description": "The local time when the run is finished.",
+                    "type": "string",
+                    "example": "2024-12-09 04:00:00"
+                },
+                "estimatedCost": {
+                    "description": "Estimated cost of the run, in the currency unit of the price series (ex: c).",
+                    "type": "number",
+                    "example": 3.12
+                },
+                "name": {
+                    "description": "Name of the appliance.",
+                    "type": "string",
+                    "example": "Dishwasher"
+                },
+                "startTime": {
+                    "description": "The local time when the appliance should be started.",
+                    "type": "string",
+                    "example": "2024-12-09 02:00:00"
+                },
+                "unit": {
+                    "description": "Currency unit of the estimated cost.",
+                    "type": "string",
+                    "example": "c"
+                }
+            }
+        },
+        "models.Reminder": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "The time when the reminder was created.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "id": {
+                    "description": "Unique identifier for the reminder.",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "minutesBefore": {
+                    "description": "How many minutes before the period begins the reminder is sent.",
+                    "type": "integer",
+                    "example": 15
+                },
+                "threshold": {
+                    "description": "Price threshold in the unit of the price series (ex: c/kWh), compared with the effective price of the user's contract.\nOnly used with type ` + "`" + `threshold` + "`" + `.",
+                    "type": "number",
+                    "example": 5
+                },
+                "type": {
+                    "description": "The kind of price event to be reminded about.",
+                    "type": "string",
+                    "enum": [
+                        "cheapest_window",
+                        "threshold"
+                    ],
+                    "example": "cheapest_window"
+                },
+                "userId": {
+                    "description": "Identifier of the user who owns the reminder.",
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "windowHours": {
+                    "description": "Length of the cheapest window in hours. Only used with type ` + "`" + `cheapest_window` + "`" + `.",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.Segment": {
+            "type": "object",
+            "properties": {
+                "appVersions": {
+                    "description": "Versions of the app on the devices (ex: 1.4.0).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "1.4.0"
+                    ]
+                },
+                "areas": {
+                    "description": "Electricity price areas of the devices (ex: FI).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "FI"
+                    ]
+                },
+                "category": {
+                    "description": "Category of broadcasts which the users opted in to (ex: tips).",
+                    "type": "string",
+                    "example": "tips"
+                },
+                "locales": {
+                    "description": "Languages of the app on the devices (ex: fi-FI).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "fi-FI"
+                    ]
+                },
+                "platforms": {
+                    "description": "Platforms of the devices (ex: android).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "android",
+                        "ios"
+                    ]
+                }
+            }
+        },
+        "models.TransferTariff": {
+            "type": "object",
+            "properties": {
+                "dayPrice": {
+                    "description": "Transfer fee during the day.",
+                    "type": "number",
+                    "example": 4.28
+                },
+                "nightFrom": {
+                    "description": "The local time (HH:MM) when the night tariff begins.",
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "nightPrice": {
+                    "description": "Transfer fee during the night.",
+                    "type": "number",
+                    "example": 2.63
+                },
+                "nightTo": {
+                    "description": "The local time (HH:MM) when the night tariff ends.",
+                    "type": "string",
+                    "example": "07:00"
+                }
+            }
+        },
+        "models.VAPIDKey": {
+            "type": "object",
+            "properties": {
+                "publicKey": {
+                    "description": "Public VAPID key on the P-256 curve, base64url encoded.",
+                    "type": "string",
+                    "example": "BEl62iUYgUivxIkv69yViEuiBIa-Ib9-SkvMeAtA3LFgDzkrxZJjSgSnfckjBJuBkr3qBUYIHBQFLXYp5Nksh8U"
+                }
+            }
+        },
+        "models.WebPush": {
+            "type": "object",
+            "properties": {
+                "badge": {
+                    "description": "URL of the small monochrome badge image.",
+                    "type": "string",
+                    "example": "https://example.com/badge.png"
+                },
+                "icon": {
+                    "description": "URL of the icon shown in the notification.",
+                    "type": "string",
+                    "example": "https://example.com/icon.png"
+                }
+            }
+        },
+        "models.WebPushKeys": {
+            "type": "object",
+            "properties": {
+                "auth": {
+                    "description": "Authentication secret of the subscription, base64url encoded.",
+                    "type": "string",
+                    "example": "tBHItJI5svbpez7KI4CCXg"
+                },
+                "p256dh": {
+                    "description": "Public key of the browser on the P-256 curve, base64url encoded.",
+                    "type": "string",
+                    "example": "BNcRdreALRFXTkOOUHK1EtK2wtaz5Ry4YfYCA_0QTpQtUbVlUls0VJXg7A8u-Ts1XbjhazAkj7I99e8QcYP7DkM"
+                }
+            }
+        },
+        "models.WebPushSubscription": {
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "description": "URL of the push service which delivers the notifications to the browser.",
+                    "type": "string",
+                    "example": "https://fcm.googleapis.com/fcm/send/dpH5lCsTSSM:APA91bH"
+                },
+                "keys": {
+                    "description": "Encryption keys of the subscription.",
+                    "$ref": "#/definitions/models.WebPushKeys"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "consecutiveFailures": {
+                    "description": "Number of failed deliveries since the last successful one.",
+                    "type": "integer",
+                    "example": 0
+                },
+                "createdAt": {
+                    "description": "The time when the webhook was created.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "disabledAt": {
+                    "description": "The time when the webhook was disabled automatically.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "enabled": {
+                    "description": "Whether the webhook receives notifications. It is disabled automatically after too many consecutive failed deliveries.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "description": "Unique identifier for the webhook.",
+                    "type": "string",
+                    "example": "677e5c2b8f1b2c0a4d3e2f10"
+                },
+                "lastDeliveryAt": {
+                    "description": "The time of the last delivery.",
+                    "type": "string",
+                    "example": "2025-01-02 14:00:00 +0200 EET"
+                },
+                "lastError": {
+                    "description": "Reason of the last failed delivery.",
+                    "type": "string",
+                    "example": "unexpected status code 500"
+                },
+                "lastStatusCode": {
+                    "description": "HTTP status code of the last delivery attempt, zero if the endpoint could not be reached.",
+                    "type": "integer",
+                    "example": 200
+                },
+                "secret": {
+                    "description": "Secret which signs the requests. It is only returned when the webhook is created.",
+                    "type": "string",
+                    "example": "whsec_9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "url": {
+                    "description": "HTTPS URL which receives the notifications.",
+                    "type": "string",
+                    "example": "https://automation.example.com/hooks/electricity"
+                },
+                "userId": {
+                    "description": "Identifier of the user who owns the webhook.",
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "models.WebhookRequest": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "description": "HTTPS URL which receives the notifications.",
+                    "type": "string",
+                    "example": "https://automation.example.com/hooks/electricity"
                 }
             }
         }
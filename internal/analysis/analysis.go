@@ -0,0 +1,118 @@
+// AnhCao 2024
+//
+// Package analysis finds interesting periods (ex: the cheapest hours) from electric price series.
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// Period represents consecutive entries of a price series
+type Period struct {
+	Start     time.Time // the time when the period begins in UTC
+	End       time.Time // the time when the period ends in UTC
+	StartTime string    // the local time when the period begins, same format as `models.Data.Time`
+	Average   float64   // the average price during the period
+}
+
+// Slot returns the duration of a single entry of the price series. Series with only one
+// entry are considered to be hourly.
+func Slot(series models.PriceSeries) (time.Duration, error) {
+	if len(series.Data) < 2 {
+		return time.Hour, nil
+	}
+	first, err := ParseTimeUTC(series.Data[0])
+	if err != nil {
+		return 0, err
+	}
+	second, err := ParseTimeUTC(series.Data[1])
+	if err != nil {
+		return 0, err
+	}
+	if !second.After(first) {
+		return 0, fmt.Errorf("price series is not in chronological order")
+	}
+	return second.Sub(first), nil
+}
+
+// ParseTimeUTC returns the UTC time of a price entry
+func ParseTimeUTC(data models.Data) (time.Time, error) {
+	t, err := time.ParseInLocation(constants.PriceTimeLayout, data.TimeUTC, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse price time '%s': %s", data.TimeUTC, err.Error())
+	}
+	return t, nil
+}
+
+// CheapestWindow finds the consecutive hours with the lowest average price from the price series.
+func CheapestWindow(series models.PriceSeries, hours int) (*Period, error) {
+	slot, err := Slot(series)
+	if err != nil {
+		return nil, err
+	}
+	size := int(time.Duration(hours) * time.Hour / slot)
+	if size <= 0 || size > len(series.Data) {
+		return nil, fmt.Errorf("window of %d hours does not fit into the price series", hours)
+	}
+
+	bestStart, bestSum := 0, math.Inf(1)
+	var sum float64
+	for idx, data := range series.Data {
+		sum += data.Price
+		if idx >= size {
+			sum -= series.Data[idx-size].Price
+		}
+		if idx >= size-1 && sum < bestSum {
+			bestStart, bestSum = idx-size+1, sum
+		}
+	}
+	return newPeriod(series.Data[bestStart:bestStart+size], slot)
+}
+
+// PeriodsBelow finds all the periods which price is below the given threshold.
+func PeriodsBelow(series models.PriceSeries, threshold float64) ([]Period, error) {
+	slot, err := Slot(series)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]Period, 0)
+	start := -1
+	for idx := 0; idx <= len(series.Data); idx++ {
+		below := idx < len(series.Data) && series.Data[idx].Price < threshold
+		if below && start < 0 {
+			start = idx
+		}
+		if !below && start >= 0 {
+			period, err := newPeriod(series.Data[start:idx], slot)
+			if err != nil {
+				return nil, err
+			}
+			periods = append(periods, *period)
+			start = -1
+		}
+	}
+	return periods, nil
+}
+
+func newPeriod(data []models.Data, slot time.Duration) (*Period, error) {
+	start, err := ParseTimeUTC(data[0])
+	if err != nil {
+		return nil, err
+	}
+	var sum float64
+	for _, d := range data {
+		sum += d.Price
+	}
+	return &Period{
+		Start:     start,
+		End:       start.Add(time.Duration(len(data)) * slot),
+		StartTime: data[0].Time,
+		Average:   sum / float64(len(data)),
+	}, nil
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"net/http"
 	"sync"
@@ -13,9 +14,11 @@ import (
 	"github.com/AnhCaooo/electric-notifications/internal/api/middleware"
 	"github.com/AnhCaooo/electric-notifications/internal/api/routes"
 	"github.com/AnhCaooo/electric-notifications/internal/cache"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/db"
-	"github.com/AnhCaooo/electric-notifications/internal/firebase"
+	"github.com/AnhCaooo/electric-notifications/internal/email"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
 	httpSwagger "github.com/swaggo/http-swagger" // http-swagger middleware
 	"go.uber.org/zap"
 )
@@ -24,7 +27,8 @@ type API struct {
 	cache    *cache.Cache
 	config   *models.Config
 	ctx      context.Context
-	firebase *firebase.Firebase
+	notifier notifier.Notifier
+	mailer   *email.Mailer
 	logger   *zap.Logger
 	mongo    *db.Mongo
 	server   *http.Server
@@ -37,7 +41,8 @@ func NewHTTPServer(
 	cache *cache.Cache,
 	config *models.Config,
 	ctx context.Context,
-	firebase *firebase.Firebase,
+	notifier notifier.Notifier,
+	mailer *email.Mailer,
 	logger *zap.Logger,
 	mongo *db.Mongo,
 ) *API {
@@ -45,7 +50,8 @@ func NewHTTPServer(
 		cache:    cache,
 		config:   config,
 		ctx:      ctx,
-		firebase: firebase,
+		notifier: notifier,
+		mailer:   mailer,
 		logger:   logger,
 		mongo:    mongo,
 	}
@@ -53,6 +59,7 @@ func NewHTTPServer(
 
 // Start initializes and starts the API server in a separate goroutine for a given worker.
 // It sets up the server configuration, assigns the worker ID, and starts the server in a new goroutine.
+// The server serves HTTPS when the TLS certificate is configured, plain HTTP otherwise.
 // If the server encounters an error, it sends the error to the provided error channel.
 func (a *API) Start(workerID int, errChan chan<- error, wg *sync.WaitGroup) {
 	a.workerID = workerID
@@ -61,11 +68,26 @@ func (a *API) Start(workerID int, errChan chan<- error, wg *sync.WaitGroup) {
 		Addr:    fmt.Sprintf(":%s", a.config.Server.Port),
 		Handler: a.newMuxRouter(),
 	}
+	var tlsErr error
+	if a.config.Server.TLS.CertFile != "" {
+		a.server.TLSConfig, tlsErr = newTLSConfig(&a.config.Server.TLS, a.logger)
+	}
 
 	a.wg.Add(1)
 	go func() {
-		a.logger.Info(fmt.Sprintf("[worker_%d] Server starting...", a.workerID), zap.String("port", a.config.Server.Port))
-		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		a.logger.Info(fmt.Sprintf("[worker_%d] Server starting...", a.workerID), zap.String("port", a.config.Server.Port), zap.Bool("tls", a.server.TLSConfig != nil))
+		var err error
+		switch {
+		case tlsErr != nil:
+			// the server never falls back to plain HTTP when HTTPS is configured
+			err = tlsErr
+		case a.server.TLSConfig != nil:
+			// the certificate is provided by the TLS configuration, which reloads it when its files change
+			err = a.server.ListenAndServeTLS("", "")
+		default:
+			err = a.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errChan <- fmt.Errorf("[worker_%d] error in worker: %s", a.workerID, err.Error())
 		}
 	}()
@@ -77,9 +99,9 @@ func (a *API) Start(workerID int, errChan chan<- error, wg *sync.WaitGroup) {
 // applies to all endpoints, like cache, database, CORS, auth middleware, and logging
 func (a *API) newMuxRouter() *mux.Router {
 	// Initialize Middleware
-	middleware := middleware.NewMiddleware(a.logger, a.config, a.workerID)
+	middleware := middleware.NewMiddleware(a.logger, a.config, a.mongo, a.workerID)
 	// Initialize Handler
-	apiHandler := handlers.NewHandler(a.logger, a.cache, a.mongo, a.firebase, a.workerID)
+	apiHandler := handlers.NewHandler(a.logger, a.cache, a.config, a.mongo, a.notifier, a.mailer, a.workerID)
 	// Initialize Endpoints pool
 	endpoints := routes.InitializeEndpoints(apiHandler)
 
@@ -95,9 +117,14 @@ func (a *API) newMuxRouter() *mux.Router {
 
 	// swagger endpoint for API documentation
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	// runtime metrics, ex: wait times of the rate limits of the delivery channels. Only admins may read them,
+	// as they also expose the command line and the memory statistics of the process.
+	r.Handle("/debug/vars", middleware.Authorize([]string{constants.RoleAdmin}, nil)(expvar.Handler())).Methods("GET")
 	// Apply endpoint handlers
 	for _, endpoint := range endpoints {
-		r.HandleFunc(endpoint.Path, endpoint.Handler).Methods(endpoint.Method)
+		// endpoints which require roles, ex: admin endpoints, are only handled for the callers that have the roles,
+		// and services with an API key may only use the endpoints of its scopes
+		r.Handle(endpoint.Path, middleware.Authorize(endpoint.Roles, endpoint.Scopes)(endpoint.Handler)).Methods(endpoint.Method)
 	}
 
 	r.MethodNotAllowedHandler = http.HandlerFunc(apiHandler.NotAllowed)
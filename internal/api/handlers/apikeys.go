@@ -0,0 +1,133 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// CreateAPIKey creates an API key with which another backend service calls the API on behalf of any user.
+//
+//	@Summary		Create an API key for a service
+//	@Description	The service sends the key in the `X-API-Key` header instead of a bearer token, and may only use the endpoints of the scopes of the key: `notifications:send` allows `POST /v1/notifications` for any user and `deliveries:read` allows `GET /v1/deliveries` for any user.
+//	@Description	The key is only returned in this response, it is stored as a hash. Only admins may use it.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.APIKeyRequest	true	"the name of the service and the scopes of the key"
+//	@Success		201		{object}	models.CreatedAPIKey	"The key, which is not available later on"
+//	@Failure		400		{string}	string					"Invalid request"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		403		{string}	string					"If the user is not an admin"
+//	@Failure		500		{string}	string					"If there is an error storing the key into the database."
+//	@Router			/v1/admin/apikeys [post]
+func (h Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.APIKeyRequest](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = reqBody.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to generate API key", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	apiKey, err := h.mongo.InsertAPIKey(models.APIKey{
+		Name:      reqBody.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    reqBody.Scopes,
+		CreatedBy: userId,
+	})
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to insert API key", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] create API key successfully", h.workerID), zap.String("service", apiKey.Name), zap.String("prefix", apiKey.Prefix))
+	if err = encode.EncodeResponse(w, http.StatusCreated, models.CreatedAPIKey{APIKey: *apiKey, Key: key}); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// GetAPIKeys returns the API keys of the services, without the keys themselves.
+//
+//	@Summary		Get the API keys of the services
+//	@Description	Returns every key, including the revoked ones, the latest first. Only admins may use it.
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		models.APIKey	"List of API keys"
+//	@Failure		401	{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		403	{string}	string			"If the user is not an admin"
+//	@Failure		500	{string}	string			"If there is an error retrieving the keys from the database."
+//	@Router			/v1/admin/apikeys [get]
+func (h Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.mongo.GetAPIKeys()
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get API keys", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, keys); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// RevokeAPIKey revokes an API key, so the service can not call the API with it anymore.
+//
+//	@Summary		Revoke an API key
+//	@Description	The key is rejected right away. Only admins may use it.
+//	@Tags			admin
+//	@Param			id	path		string	true	"ID of the API key"
+//	@Success		204	{string}	string	"The key was revoked"
+//	@Failure		400	{string}	string	"Invalid API key ID"
+//	@Failure		401	{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		403	{string}	string	"If the user is not an admin"
+//	@Failure		404	{string}	string	"If there is no active API key with given ID"
+//	@Failure		500	{string}	string	"If there is an error updating the key in the database."
+//	@Router			/v1/admin/apikeys/{id} [delete]
+func (h Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.RevokeAPIKey(id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to revoke API key", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] revoke API key successfully", h.workerID), zap.String("id", id.Hex()))
+	w.WriteHeader(http.StatusNoContent)
+}
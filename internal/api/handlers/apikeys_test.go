@@ -0,0 +1,122 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "key is created",
+			body:       `{"name":"billing","scopes":["notifications:send"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "unknown scope",
+			body:       `{"name":"billing","scopes":["users:delete"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "key without scopes",
+			body:       `{"name":"billing"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongo, server := newTestDatabase(t, func(command bson.M) bson.D {
+				return nil
+			})
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{}, mongo: mongo}
+			req := httptest.NewRequest(http.MethodPost, "/v1/admin/apikeys", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.CreateAPIKey(rec, req.WithContext(context.WithValue(req.Context(), constants.UserIdKey, "admin")))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("CreateAPIKey() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			inserts := server.Commands("insert")
+			if tt.wantStatus != http.StatusCreated {
+				if len(inserts) != 0 {
+					t.Errorf("sent %d inserts, want none", len(inserts))
+				}
+				return
+			}
+			var created models.CreatedAPIKey
+			if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(inserts) != 1 {
+				t.Fatalf("sent %d inserts, want 1", len(inserts))
+			}
+			// the key is only returned once, the database stores its hash
+			stored := inserts[0]["documents"].(bson.A)[0].(bson.M)
+			if created.Key == "" || stored["hash"] != auth.HashAPIKey(created.Key) || stored["createdBy"] != "admin" {
+				t.Errorf("stored key %v, want the hash of %s created by the admin", stored, created.Key)
+			}
+			if strings.Contains(rec.Body.String(), `"hash"`) {
+				t.Errorf("response %s contains the hash of the key", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		matched    int
+		wantStatus int
+	}{
+		{
+			name:       "active key is revoked",
+			id:         "6790b1c2d3e4f5a6b7c8d9e0",
+			matched:    1,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "unknown or already revoked key",
+			id:         "6790b1c2d3e4f5a6b7c8d9e0",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid ID",
+			id:         "billing",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongo, _ := newTestDatabase(t, func(command bson.M) bson.D {
+				return dbtest.Written(tt.matched)
+			})
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{}, mongo: mongo}
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/v1/admin/apikeys/"+tt.id, nil), map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+			handler.RevokeAPIKey(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("RevokeAPIKey() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/helpers"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/planner"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// CreateAppliance registers an appliance which usage is planned to the cheapest hours.
+//
+//	@Summary		Register an appliance
+//	@Description	It registers an appliance (ex: dishwasher 2h/1.2 kWh) for the user in the access token. The appliance is only planned to run inside of the allowed local time range, which may wrap over midnight (ex: from 22:00 to 07:00).
+//	@Tags			appliances
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.Appliance	true	"represents the appliance to be registered"
+//	@Success		201		{object}	models.Appliance	"The registered appliance"
+//	@Failure		400		{string}	string				"Invalid request"
+//	@Failure		401		{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string				"If there is an error inserting the appliance into the database."
+//	@Router			/v1/appliances [post]
+func (h Handler) CreateAppliance(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.Appliance](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reqBody.UserId = userId
+	if err = planner.ValidateAppliance(reqBody); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid appliance", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	appliance, err := h.mongo.InsertAppliance(reqBody)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to insert appliance", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusCreated, appliance); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] create appliance successfully", h.workerID))
+}
+
+// GetAppliances returns all appliances of the user.
+//
+//	@Summary		Get all appliances of the user
+//	@Tags			appliances
+//	@Produce		json
+//	@Success		200	{array}		models.Appliance	"List of appliances"
+//	@Failure		401	{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500	{string}	string				"If there is an error retrieving the appliances from the database."
+//	@Router			/v1/appliances [get]
+func (h Handler) GetAppliances(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	appliances, err := h.mongo.GetAppliances(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get appliances", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, appliances); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// DeleteAppliance deletes an appliance of the user.
+//
+//	@Summary		Delete an appliance
+//	@Tags			appliances
+//	@Param			id	path		string	true	"ID of the appliance"
+//	@Success		204	{string}	string	"The appliance was deleted"
+//	@Failure		400	{string}	string	"Invalid appliance ID"
+//	@Failure		401	{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string	"If the user does not have an appliance with given ID"
+//	@Failure		500	{string}	string	"If there is an error deleting the appliance from the database."
+//	@Router			/v1/appliances/{id} [delete]
+func (h Handler) DeleteAppliance(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid appliance ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.DeleteAppliance(userId, id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "appliance not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to delete appliance", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRecommendations returns the cheapest start time of every appliance of the user.
+//
+//	@Summary		Get appliance scheduling recommendations
+//	@Description	It finds the cheapest start time within the allowed time range of each appliance of the user, together with the estimated cost of the run based on the effective prices of the user's contract. Appliances which run does not fit into the allowed time range of the day are left out.
+//	@Description	The prices of the previous day are included, so a time range over midnight (ex: 22:00 - 07:00) is planned from the evening before the date until the morning of the date. Runs which would start in the past are left out.
+//	@Tags			appliances
+//	@Produce		json
+//	@Param			date	query		string					false	"date of the prices in format YYYY-MM-DD, defaults to tomorrow"
+//	@Success		200		{array}		models.Recommendation	"List of recommendations"
+//	@Failure		400		{string}	string					"Invalid date"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		404		{string}	string					"If the prices of the date are not available"
+//	@Failure		500		{string}	string					"If there is an error retrieving the appliances or prices from the database."
+//	@Router			/v1/recommendations [get]
+func (h Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		_, date = helpers.MarketDates(time.Now())
+	}
+	day, err := time.Parse(constants.DateLayout, date)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid date '%s', expected format YYYY-MM-DD", date), http.StatusBadRequest)
+		return
+	}
+
+	// the prices of the previous day are joined, so a time range over midnight is planned from the evening before the date
+	days := make([]models.PriceSeries, 0, 2)
+	for _, priceDate := range []string{day.AddDate(0, 0, -1).Format(constants.DateLayout), date} {
+		prices, err := h.mongo.GetDailyPrices(priceDate)
+		if err == mongo.ErrNoDocuments && priceDate != date {
+			continue
+		}
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, fmt.Sprintf("prices of %s are not available", date), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get prices", h.workerID, constants.Server), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		days = append(days, prices.Prices)
+	}
+	series, err := planner.UpcomingPrices(time.Now().UTC(), days...)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to join prices", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	appliances, err := h.mongo.GetAppliances(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get appliances", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	effective, err := h.effectivePrices(userId, series)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to compute effective prices", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	recommendations, err := planner.PlanAppliances(appliances, effective)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to plan appliances", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, recommendations); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
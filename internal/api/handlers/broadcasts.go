@@ -0,0 +1,148 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// PreviewBroadcast returns how many users a segment targets, before the broadcast is sent.
+//
+//	@Summary		Preview the recipients of a broadcast
+//	@Description	Counts the users who have a device matching the filters of the segment and, if the segment has a category, who opted in to it. Only admins may use it.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.Segment			true	"the users who receive the broadcast. An empty segment targets every user."
+//	@Success		200		{object}	models.BroadcastPreview	"Number of users the segment targets"
+//	@Failure		400		{string}	string					"Invalid request"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		403		{string}	string					"If the user is not an admin"
+//	@Failure		500		{string}	string					"If there is an error retrieving the users from the database."
+//	@Router			/v1/admin/broadcasts/preview [post]
+func (h Handler) PreviewBroadcast(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := encode.DecodeRequest[models.Segment](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userIDs, err := h.mongo.GetSegmentUserIDs(reqBody)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get users of segment", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, models.BroadcastPreview{Recipients: len(userIDs)}); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// CreateBroadcast sends a notification to every user of a segment in the background.
+//
+//	@Summary		Broadcast a notification to a segment of users
+//	@Description	Stores the broadcast and sends it to every user of the segment in the background. The progress is returned by `GET /v1/admin/broadcasts/{id}`. Only admins may use it.
+//	@Description	If the segment filters the devices (platforms, locales, app versions or areas), only the matching devices of the users receive the broadcast, and it is not sent by email or to webhooks.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.BroadcastRequest	true	"the segment and the notification. Either `title` or `body` of the message is required."
+//	@Success		202		{object}	models.Broadcast		"The broadcast was accepted"
+//	@Failure		400		{string}	string					"Invalid request"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		403		{string}	string					"If the user is not an admin"
+//	@Failure		500		{string}	string					"If there is an error storing the broadcast into the database."
+//	@Router			/v1/admin/broadcasts [post]
+func (h Handler) CreateBroadcast(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.BroadcastRequest](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if reqBody.Message.Title == "" && reqBody.Message.GetBody() == "" {
+		http.Error(w, "`title` or `body` of the message is required", http.StatusBadRequest)
+		return
+	}
+	reqBody.Message.UserId = ""
+
+	broadcast, err := h.mongo.InsertBroadcast(models.Broadcast{
+		Segment:   reqBody.Segment,
+		Message:   reqBody.Message,
+		CreatedBy: userId,
+	})
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to insert broadcast", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	job := models.Job{
+		Kind:        models.JobKindBroadcast,
+		ReferenceId: broadcast.ID.Hex(),
+		RunAt:       time.Now().UTC(),
+		Message:     reqBody.Message,
+	}
+	if err = h.mongo.InsertJob(job); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to schedule broadcast", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] create broadcast successfully", h.workerID), zap.String("broadcast_id", broadcast.ID.Hex()))
+	if err = encode.EncodeResponse(w, http.StatusAccepted, broadcast); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// GetBroadcast returns a broadcast together with the progress of its sending.
+//
+//	@Summary		Get the progress of a broadcast
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id	path		string				true	"ID of the broadcast"
+//	@Success		200	{object}	models.Broadcast	"The broadcast and its progress"
+//	@Failure		400	{string}	string				"Invalid broadcast ID"
+//	@Failure		401	{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		403	{string}	string				"If the user is not an admin"
+//	@Failure		404	{string}	string				"If the broadcast does not exist"
+//	@Failure		500	{string}	string				"If there is an error retrieving the broadcast from the database."
+//	@Router			/v1/admin/broadcasts/{id} [get]
+func (h Handler) GetBroadcast(w http.ResponseWriter, r *http.Request) {
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid broadcast ID", http.StatusBadRequest)
+		return
+	}
+
+	broadcast, err := h.mongo.GetBroadcast(id)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get broadcast", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if broadcast == nil {
+		http.Error(w, "broadcast not found", http.StatusNotFound)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, broadcast); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
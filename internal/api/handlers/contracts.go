@@ -0,0 +1,183 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/pricing"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// PutContract configures the electricity contract of the user.
+//
+//	@Summary		Configure the electricity contract of the user
+//	@Description	It stores the contract of the user in the access token, replacing the previous one. The contract is used to report effective total prices (spot + margin + transfer fee + monthly fees per kWh) in all analysis and notifications, and to hint when the fixed-price contract would be cheaper.
+//	@Tags			contract
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.Contract	true	"represents the contract of the user"
+//	@Success		200		{object}	models.Contract	"The stored contract"
+//	@Failure		400		{string}	string			"Invalid request"
+//	@Failure		401		{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string			"If there is an error storing the contract into the database."
+//	@Router			/v1/contract [put]
+func (h Handler) PutContract(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.Contract](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reqBody.UserId = userId
+	if err = pricing.Validate(reqBody); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid contract", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	contract, err := h.mongo.UpsertContract(reqBody)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to upsert contract", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, contract); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] update contract successfully", h.workerID))
+}
+
+// GetContract returns the electricity contract of the user.
+//
+//	@Summary		Get the electricity contract of the user
+//	@Tags			contract
+//	@Produce		json
+//	@Success		200	{object}	models.Contract	"The contract of the user"
+//	@Failure		401	{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string			"If the user has not configured a contract"
+//	@Failure		500	{string}	string			"If there is an error retrieving the contract from the database."
+//	@Router			/v1/contract [get]
+func (h Handler) GetContract(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	contract, err := h.mongo.GetContract(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get contract", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if contract == nil {
+		http.Error(w, "contract not found", http.StatusNotFound)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, contract); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// DeleteContract deletes the electricity contract of the user, so plain spot prices are reported again.
+//
+//	@Summary		Delete the electricity contract of the user
+//	@Tags			contract
+//	@Success		204	{string}	string	"The contract was deleted"
+//	@Failure		401	{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string	"If the user has not configured a contract"
+//	@Failure		500	{string}	string	"If there is an error deleting the contract from the database."
+//	@Router			/v1/contract [delete]
+func (h Handler) DeleteContract(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.mongo.DeleteContract(userId)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "contract not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to delete contract", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPrices returns the effective total prices of a date for the user.
+//
+//	@Summary		Get the effective prices of a date
+//	@Description	It returns the price series of given date where every price is the effective total price of the hour according to the contract of the user. Without a contract the spot prices are returned.
+//	@Tags			prices
+//	@Produce		json
+//	@Param			date	path		string				true	"date of the price series in format YYYY-MM-DD"
+//	@Success		200		{object}	models.PriceSeries	"The effective price series"
+//	@Failure		400		{string}	string				"Invalid date"
+//	@Failure		401		{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		404		{string}	string				"If there is no price series stored for the date"
+//	@Failure		500		{string}	string				"If there is an error retrieving the prices or the contract"
+//	@Router			/v1/prices/{date} [get]
+func (h Handler) GetPrices(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	date := mux.Vars(r)["date"]
+	if _, err := time.Parse(constants.DateLayout, date); err != nil {
+		http.Error(w, fmt.Sprintf("invalid date '%s', expected format YYYY-MM-DD", date), http.StatusBadRequest)
+		return
+	}
+
+	prices, err := h.mongo.GetDailyPrices(date)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, fmt.Sprintf("prices of %s are not available", date), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get prices", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	effective, err := h.effectivePrices(userId, prices.Prices)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to compute effective prices", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, effective); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// effectivePrices converts the spot prices into the effective total prices according to the contract of the user
+func (h Handler) effectivePrices(userId string, series models.PriceSeries) (models.PriceSeries, error) {
+	contract, err := h.mongo.GetContract(userId)
+	if err != nil {
+		return models.PriceSeries{}, err
+	}
+	return pricing.Effective(contract, series)
+}
@@ -0,0 +1,67 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// defaultDeliveriesPeriod is how far back the deliveries are returned if `since` is not given
+const defaultDeliveriesPeriod = 7 * 24 * time.Hour
+
+// GetDeliveries returns the delivery log of the notifications which were sent to the user.
+//
+//	@Summary		Get the delivery log of the user
+//	@Description	Returns what was sent to the user through every channel and whether it was delivered, the latest first.
+//	@Description	Services which call the API with an API key of the `deliveries:read` scope give the user in `userId`.
+//	@Tags			notifications
+//	@Produce		json
+//	@Param			since			query		string	false	"RFC 3339 time since which the deliveries are returned. Defaults to 7 days ago."
+//	@Param			notificationId	query		string	false	"only return the deliveries of given notification"
+//	@Param			userId			query		string	false	"the user whose deliveries are returned, required with an API key"
+//	@Success		200				{array}		models.DeliveryLog	"List of deliveries"
+//	@Failure		400				{string}	string				"Invalid `since` time"
+//	@Failure		401				{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500				{string}	string				"If there is an error retrieving the deliveries from the database."
+//	@Router			/v1/deliveries [get]
+func (h Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if _, isService := r.Context().Value(constants.ServiceKey).(string); isService {
+		// services read the deliveries of any user, which is given in the request
+		userId = r.URL.Query().Get("userId")
+		if userId == "" {
+			http.Error(w, "`userId` is required when reading with an API key", http.StatusBadRequest)
+			return
+		}
+	} else if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	since := time.Now().UTC().Add(-defaultDeliveriesPeriod)
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid since '%s', expected RFC 3339 time", value), http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	deliveries, err := h.mongo.GetDeliveries(userId, r.URL.Query().Get("notificationId"), since)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get deliveries", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, deliveries); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
@@ -8,15 +8,20 @@ import (
 
 	"github.com/AnhCaooo/electric-notifications/internal/cache"
 	"github.com/AnhCaooo/electric-notifications/internal/db"
-	"github.com/AnhCaooo/electric-notifications/internal/firebase"
+	"github.com/AnhCaooo/electric-notifications/internal/email"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
 	"go.uber.org/zap"
 )
 
 type Handler struct {
 	logger   *zap.Logger
 	cache    *cache.Cache
+	config   *models.Config
 	mongo    *db.Mongo
-	firebase *firebase.Firebase
+	notifier notifier.Notifier
+	// nil if the email channel is not configured
+	mailer   *email.Mailer
 	workerID int
 }
 
@@ -24,8 +29,10 @@ type Handler struct {
 func NewHandler(
 	logger *zap.Logger,
 	cache *cache.Cache,
+	config *models.Config,
 	mongo *db.Mongo,
-	firebase *firebase.Firebase,
+	notifier notifier.Notifier,
+	mailer *email.Mailer,
 	workerID int,
 ) *Handler {
 	if mongo == nil {
@@ -34,8 +41,10 @@ func NewHandler(
 	return &Handler{
 		logger:   logger,
 		cache:    cache,
+		config:   config,
 		mongo:    mongo,
-		firebase: firebase,
+		notifier: notifier,
+		mailer:   mailer,
 		workerID: workerID,
 	}
 }
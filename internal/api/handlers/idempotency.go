@@ -0,0 +1,174 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+const (
+	// IdempotencyKeyHeader is the header which carries the idempotency key of a request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response which was replayed from an earlier request with the same idempotency key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL = 24 * time.Hour
+	// defaultIdempotencyLockTimeout is how long an idempotency key is reserved for a request that is being processed,
+	// if not configured. The reservation is renewed until the request finishes.
+	defaultIdempotencyLockTimeout = time.Minute
+	maxIdempotencyKeyLength       = 255
+)
+
+// Idempotent makes the handler safe to retry: the response of the first request with an `Idempotency-Key` header
+// is stored, and a retry with the same key returns the stored response without executing the handler again.
+// A different request with the same key is rejected with 422, and a retry while the first request is still
+// being processed is rejected with 409, however long the first request takes. Server errors are not stored, so the request can be retried.
+// Requests without the header are handled as they are.
+func (h Handler) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		userId, ok := r.Context().Value(constants.UserIdKey).(string)
+		if service, isService := r.Context().Value(constants.ServiceKey).(string); isService {
+			// the keys of a service are kept apart from the keys of the users
+			userId, ok = "service:"+service, true
+		}
+		if !ok {
+			http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, fmt.Sprintf("`%s` must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to read request", h.workerID, constants.Client), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.New()
+		fmt.Fprintf(hash, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.RawQuery)
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		lockTimeout := h.config.Server.IdempotencyLockTimeout
+		if lockTimeout <= 0 {
+			lockTimeout = defaultIdempotencyLockTimeout
+		}
+		existing, err := h.mongo.ClaimIdempotencyKey(userId, key, requestHash, time.Now().UTC().Add(lockTimeout))
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to claim idempotency key", h.workerID, constants.Server), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if existing != nil {
+			h.replay(w, existing, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		// the renewal only matches a key which is still being processed, so it may outlive the completion below
+		defer h.renewIdempotencyKey(userId, key, lockTimeout)()
+		next(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			if err := h.mongo.ReleaseIdempotencyKey(userId, key); err != nil {
+				h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to release idempotency key", h.workerID, constants.Server), zap.Error(err))
+			}
+			return
+		}
+		ttl := h.config.Server.IdempotencyTTL
+		if ttl <= 0 {
+			ttl = defaultIdempotencyTTL
+		}
+		err = h.mongo.CompleteIdempotencyKey(userId, key, recorder.statusCode, w.Header().Get("Content-Type"), recorder.body.Bytes(), time.Now().UTC().Add(ttl))
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to store response of idempotency key", h.workerID, constants.Server), zap.Error(err))
+		}
+	}
+}
+
+// renewIdempotencyKey extends the reservation of the idempotency key periodically while the request is being processed,
+// so a retry of a slow request is rejected instead of executing it again. It returns a function which stops the renewal.
+func (h Handler) renewIdempotencyKey(userId string, key string, lockTimeout time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(lockTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := h.mongo.ExtendIdempotencyKey(userId, key, time.Now().UTC().Add(lockTimeout)); err != nil {
+					h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to extend idempotency key", h.workerID, constants.Server), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// replay writes the stored response of an earlier request with the same idempotency key
+func (h Handler) replay(w http.ResponseWriter, record *models.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		http.Error(w, fmt.Sprintf("`%s` was already used for a different request", IdempotencyKeyHeader), http.StatusUnprocessableEntity)
+		return
+	}
+	if record.Status == models.IdempotencyProcessing {
+		http.Error(w, fmt.Sprintf("a request with the same `%s` is still being processed", IdempotencyKeyHeader), http.StatusConflict)
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] replay response of idempotency key", h.workerID), zap.String("key", record.Key))
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// responseRecorder passes the response through to the client and keeps a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
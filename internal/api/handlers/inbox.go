@@ -0,0 +1,194 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// Size of the inbox pages
+const (
+	defaultInboxLimit = 20
+	maxInboxLimit     = 100
+)
+
+// GetInbox returns a page of the notifications in the inbox of the user, the latest first.
+//
+//	@Summary		Get the inbox of the user
+//	@Description	Returns the notifications which were sent to the user, the latest first, together with the number of unread notifications.
+//	@Description	The older notifications are returned by giving the `nextCursor` of the page as `before`.
+//	@Tags			inbox
+//	@Produce		json
+//	@Param			category	query		string	false	"only return the notifications of given category (ex: reminder)"
+//	@Param			limit		query		int		false	"number of notifications in the page, at most 100. Defaults to 20."
+//	@Param			before		query		string	false	"cursor of the page, returns the notifications older than it"
+//	@Success		200			{object}	models.InboxPage	"Page of the inbox"
+//	@Failure		400			{string}	string				"Invalid limit or cursor"
+//	@Failure		401			{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500			{string}	string				"If there is an error retrieving the inbox from the database."
+//	@Router			/v1/inbox [get]
+func (h Handler) GetInbox(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := defaultInboxLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxInboxLimit {
+			http.Error(w, fmt.Sprintf("invalid limit '%s', expected a number between 1 and %d", value, maxInboxLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	var before bson.ObjectID
+	if value := query.Get("before"); value != "" {
+		parsed, err := bson.ObjectIDFromHex(value)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		before = parsed
+	}
+
+	items, err := h.mongo.GetInbox(userId, query.Get("category"), before, int64(limit))
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get inbox", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	unread, err := h.mongo.CountUnread(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to count unread notifications", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page := models.InboxPage{Items: items, Unread: unread}
+	if len(items) == limit {
+		page.NextCursor = items[len(items)-1].ID.Hex()
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, page); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// GetUnreadCount returns how many notifications in the inbox of the user are unread.
+//
+//	@Summary		Get the number of unread notifications
+//	@Tags			inbox
+//	@Produce		json
+//	@Success		200	{object}	models.InboxCount	"Number of unread notifications"
+//	@Failure		401	{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500	{string}	string				"If there is an error counting the notifications in the database."
+//	@Router			/v1/inbox/unread [get]
+func (h Handler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	unread, err := h.mongo.CountUnread(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to count unread notifications", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, models.InboxCount{Unread: unread}); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// MarkInboxItemRead marks a notification in the inbox of the user as read.
+//
+//	@Summary		Mark a notification as read
+//	@Tags			inbox
+//	@Produce		json
+//	@Param			id	path		string				true	"ID of the notification in the inbox"
+//	@Success		200	{object}	models.InboxCount	"Number of unread notifications left"
+//	@Failure		400	{string}	string				"Invalid notification ID"
+//	@Failure		401	{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string				"If the user does not have a notification with given ID"
+//	@Failure		500	{string}	string				"If there is an error updating the notification in the database."
+//	@Router			/v1/inbox/{id}/read [post]
+func (h Handler) MarkInboxItemRead(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.MarkRead(userId, id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "notification not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to mark notification as read", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeUnreadCount(w, userId)
+}
+
+// MarkInboxRead marks every notification in the inbox of the user as read.
+//
+//	@Summary		Mark all notifications as read
+//	@Tags			inbox
+//	@Produce		json
+//	@Success		200	{object}	models.InboxCount	"Number of unread notifications left"
+//	@Failure		401	{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500	{string}	string				"If there is an error updating the notifications in the database."
+//	@Router			/v1/inbox/read [post]
+func (h Handler) MarkInboxRead(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	marked, err := h.mongo.MarkAllRead(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to mark notifications as read", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] mark notifications as read successfully", h.workerID), zap.Int64("marked", marked))
+	h.writeUnreadCount(w, userId)
+}
+
+// writeUnreadCount responds with the number of unread notifications of the user,
+// so the app can update its badge after marking notifications as read
+func (h Handler) writeUnreadCount(w http.ResponseWriter, userId string) {
+	unread, err := h.mongo.CountUnread(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to count unread notifications", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, models.InboxCount{Unread: unread}); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
@@ -4,11 +4,17 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.uber.org/zap"
 
 	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
 	"github.com/AnhCaooo/go-goods/encode"
 )
 
@@ -49,6 +55,14 @@ func (h Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	reqBody.UserId = userId
+	switch reqBody.Platform {
+	case "", models.PlatformAndroid, models.PlatformIOS, models.PlatformWeb:
+	default:
+		errMsg := fmt.Sprintf("[worker_%d] %s unsupported platform '%s'", h.workerID, constants.Client, reqBody.Platform)
+		h.logger.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
 
 	// Insert the token into the database
 	err = h.mongo.InsertToken(reqBody)
@@ -64,33 +78,52 @@ func (h Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
 //
 //	@Summary		Sends notifications to user devices
 //	@Description	It retrieves the user ID from the request context and decodes the request body to get the notification message.
-//	@Description	Then validates the user ID and retrieves the associated device tokens from the database. Finally, it sends the notification message to the retrieved device tokens using Firebase.
+//	@Description	Then validates the user ID and sends the notification message to the user through every delivery channel (ex: the devices of the user using Firebase).
+//	@Description	The response contains the ID of the notification and the outcome of every recipient (ex: device). If some of the deliveries failed, the status is 207 and the failed recipients have the reason of the failure.
+//	@Description	With `dryRun=true` (or when the service runs in dry-run mode) the notification is only validated and rendered, and the outcome of every recipient is returned without delivering anything.
+//	@Description	With `sendAt` the notification is scheduled instead, and sent at given time even if the service restarts in between. The scheduled notification is returned with status 202.
+//	@Description	Services which call the API with an API key of the `notifications:send` scope send the notification to the user given in `userId`, and are recorded in the delivery log.
 //
 //	@Tags			notifications
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		models.NotificationMessage	true	"represents a message to be sent to all devices that user has."
-//	@Success		200	{object}	string "If the token is successfully inserted into the database.""
+//	@Param			payload	body		models.NotificationRequest	true	"represents a message to be sent to all devices that user has. Either `title` or `body` is required."
+//	@Param			dryRun	query		bool						false	"validate and render the notification without delivering it"
+//	@Param			Idempotency-Key	header	string				false	"unique key of the request. A retry with the same key returns the response of the first request without sending the notification again"
+//	@Success		200	{object}	models.NotificationResult "If every delivery succeeded. In dry-run mode, what would have been sent to every recipient."
+//	@Success		202	{object}	models.Job "If the notification was scheduled."
+//	@Success		207	{object}	models.NotificationResult "If some of the deliveries failed."
 //	@Failure		400	{string}	string "Invalid request"
 //	@Failure		403	{string}	string "If the user ID in the request body does not match the user ID in the context."
 //	@Failure		401	{string}	string "Unauthenticated/Unauthorized"
-//	@Failure		500	{string}	string "If there is an error retrieving the device tokens or sending the notifications, it responds with an internal server error."
+//	@Failure		403	{string}	string "If the API key of the service does not have the `notifications:send` scope."
+//	@Failure		409	{string}	string "If a request with the same `Idempotency-Key` is still being processed."
+//	@Failure		422	{string}	string "If the `Idempotency-Key` was already used for a different request."
+//	@Failure		500	{string}	string "If there is an error retrieving the device tokens or the notification could not be sent through any channel, it responds with an internal server error."
 //	@Router			/v1/notifications [post]
-//
-// todo: any response value?
 func (h Handler) SendNotifications(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(constants.UserIdKey).(string)
-	if !ok {
+	service, isService := r.Context().Value(constants.ServiceKey).(string)
+	if !ok && !isService {
 		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
 		return
 	}
 
-	reqBody, err := encode.DecodeRequest[models.NotificationMessage](r)
+	request, err := encode.DecodeRequest[models.NotificationRequest](r)
 	if err != nil {
 		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	reqBody := request.NotificationMessage
+	if isService {
+		// services send notifications to any user, which is given in the request
+		if reqBody.UserId == "" {
+			http.Error(w, "`userId` is required when sending with an API key", http.StatusBadRequest)
+			return
+		}
+		userId = reqBody.UserId
+	}
 
 	if reqBody.UserId != "" && reqBody.UserId != userId {
 		errMsg := fmt.Sprintf("[worker_%d] %s given `user_id` %s is different from `user_id` in `access_token`", h.workerID, constants.Client, reqBody.UserId)
@@ -99,19 +132,198 @@ func (h Handler) SendNotifications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	reqBody.UserId = userId
+	if reqBody.Title == "" && reqBody.GetBody() == "" {
+		errMsg := fmt.Sprintf("[worker_%d] %s `title` or `body` is required", h.workerID, constants.Client)
+		h.logger.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	sendAt, err := request.ScheduledTime()
+	if err != nil {
+		errMsg := fmt.Sprintf("[worker_%d] %s %s", h.workerID, constants.Client, err.Error())
+		h.logger.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if !sendAt.IsZero() {
+		if r.URL.Query().Get("dryRun") != "" {
+			http.Error(w, "`dryRun` can not be combined with `sendAt`", http.StatusBadRequest)
+			return
+		}
+		h.scheduleNotification(w, reqBody, sendAt, request.TimeZone, service)
+		return
+	}
+
+	ctx := notifier.WithSource(r.Context(), models.SourceAPI)
+	dryRun := h.config.DryRun
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		requested, err := strconv.ParseBool(value)
+		if err != nil {
+			errMsg := fmt.Sprintf("[worker_%d] %s invalid `dryRun` value '%s'", h.workerID, constants.Client, value)
+			h.logger.Error(errMsg)
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+		// the service wide dry-run mode can not be turned off per request
+		dryRun = dryRun || requested
+	}
+	if dryRun {
+		ctx = notifier.WithDryRun(ctx)
+	}
+	notificationId := bson.NewObjectID().Hex()
+	ctx = notifier.WithNotificationId(ctx, notificationId)
 
-	// retrieve all associated device tokens with given userId
-	tokens, err := h.mongo.GetTokens(reqBody.UserId)
+	results, err := h.notifier.SendToUser(ctx, reqBody.UserId, reqBody)
+	if err != nil && len(results) == 0 {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to send notifications", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := models.NotificationResult{
+		NotificationId: notificationId,
+		DryRun:         dryRun,
+		Message:        reqBody,
+		Results:        results,
+	}
+	response.Success, response.Failure = notifier.CountResults(results)
+	status := http.StatusOK
 	if err != nil {
-		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get tokens", h.workerID, constants.Server), zap.Error(err))
+		// some channels delivered the notification, so the failed channels are reported together with the results
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to send notifications through some channels", h.workerID, constants.Server), zap.Error(err))
+		response.Error = err.Error()
+		status = http.StatusMultiStatus
+	}
+	if response.Failure > 0 {
+		status = http.StatusMultiStatus
+	}
+	h.logger.Info(
+		fmt.Sprintf("[worker_%d] send notifications successfully", h.workerID),
+		zap.String("notification_id", notificationId),
+		zap.Bool("dryRun", dryRun),
+		zap.Int("success", response.Success),
+		zap.Int("failure", response.Failure),
+	)
+	if err := encode.EncodeResponse(w, status, response); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+	}
+}
+
+// scheduleNotification persists the notification as a job which the scheduler sends at given time.
+// The service is empty unless a service scheduled the notification with an API key.
+func (h Handler) scheduleNotification(w http.ResponseWriter, message models.NotificationMessage, sendAt time.Time, timeZone string, service string) {
+	if sendAt.Before(time.Now().Add(-time.Minute)) {
+		http.Error(w, fmt.Sprintf("`sendAt` %s is in the past", sendAt.Format(time.RFC3339)), http.StatusBadRequest)
+		return
+	}
+	job := models.Job{
+		ID:       bson.NewObjectID(),
+		Kind:     models.JobKindNotification,
+		RunAt:    sendAt.UTC(),
+		TimeZone: timeZone,
+		Service:  service,
+		Message:  message,
+	}
+	if err := h.mongo.InsertJob(job); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to schedule notification", h.workerID, constants.Server), zap.Error(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = h.firebase.SendToMultiTokens(tokens, reqBody.UserId, reqBody.Message)
+	scheduled, err := h.mongo.GetScheduledNotification(message.UserId, job.ID)
+	if err != nil || scheduled == nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get scheduled notification", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, "failed to get scheduled notification", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] schedule notification successfully", h.workerID), zap.String("job_id", job.ID.Hex()), zap.Time("send_at", job.RunAt))
+	if err = encode.EncodeResponse(w, http.StatusAccepted, scheduled); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+	}
+}
+
+// GetScheduledNotification returns a notification which the user scheduled.
+//
+//	@Summary		Get a scheduled notification
+//	@Description	Returns the notification together with the state of its sending, ex: pending until it is sent.
+//	@Tags			notifications
+//	@Produce		json
+//	@Param			id	path		string		true	"ID of the scheduled notification"
+//	@Success		200	{object}	models.Job	"The scheduled notification"
+//	@Failure		400	{string}	string		"Invalid notification ID"
+//	@Failure		401	{string}	string		"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string		"If the user does not have a scheduled notification with given ID"
+//	@Failure		500	{string}	string		"If there is an error retrieving the notification from the database."
+//	@Router			/v1/notifications/scheduled/{id} [get]
+func (h Handler) GetScheduledNotification(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	scheduled, err := h.mongo.GetScheduledNotification(userId, id)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get scheduled notification", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if scheduled == nil {
+		http.Error(w, "scheduled notification not found", http.StatusNotFound)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, scheduled); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// CancelScheduledNotification cancels a notification which the user scheduled, as long as it has not been sent yet.
+//
+//	@Summary		Cancel a scheduled notification
+//	@Tags			notifications
+//	@Param			id	path		string	true	"ID of the scheduled notification"
+//	@Success		204	{string}	string	"The notification was cancelled"
+//	@Failure		400	{string}	string	"Invalid notification ID"
+//	@Failure		401	{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string	"If the user does not have a scheduled notification with given ID"
+//	@Failure		409	{string}	string	"If the notification is already being sent or has been sent"
+//	@Failure		500	{string}	string	"If there is an error deleting the notification from the database."
+//	@Router			/v1/notifications/scheduled/{id} [delete]
+func (h Handler) CancelScheduledNotification(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.CancelScheduledNotification(userId, id)
+	if err == mongo.ErrNoDocuments {
+		// tell apart a notification which does not exist from one which can not be cancelled anymore
+		scheduled, getErr := h.mongo.GetScheduledNotification(userId, id)
+		if getErr == nil && scheduled != nil {
+			http.Error(w, fmt.Sprintf("scheduled notification is %s and can not be cancelled", scheduled.Status), http.StatusConflict)
+			return
+		}
+		http.Error(w, "scheduled notification not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to send multi tokens", h.workerID, constants.Server), zap.Error(err))
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to cancel scheduled notification", h.workerID, constants.Server), zap.Error(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	h.logger.Info(fmt.Sprintf("[worker_%d] send tokens successfully", h.workerID))
+	h.logger.Info(fmt.Sprintf("[worker_%d] cancel scheduled notification successfully", h.workerID), zap.String("job_id", id.Hex()))
+	w.WriteHeader(http.StatusNoContent)
 }
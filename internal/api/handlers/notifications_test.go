@@ -0,0 +1,279 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
+)
+
+// unavailableNotifier fails every notification without any result, ex: when the preferences can not be read
+type unavailableNotifier struct {
+	notifier.Recorder
+}
+
+func (u *unavailableNotifier) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func TestSendNotifications(t *testing.T) {
+	tests := []struct {
+		name       string
+		notifier   notifier.Notifier
+		body       string
+		query      string
+		service    string
+		wantStatus int
+		wantDryRun bool
+	}{
+		{
+			name:       "every delivery succeeded",
+			notifier:   notifier.NewDispatcher(zap.NewNop(), nil, &notifier.Recorder{Name: models.ChannelPush}),
+			body:       `{"title":"Prices","body":"Cheap tonight"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "a channel failed while another delivered",
+			notifier: notifier.NewDispatcher(zap.NewNop(), nil,
+				&notifier.Recorder{Name: models.ChannelPush},
+				&notifier.Recorder{Name: models.ChannelEmail, Err: errors.New("smtp unavailable")}),
+			body:       `{"title":"Prices"}`,
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name:       "every delivery failed",
+			notifier:   notifier.NewDispatcher(zap.NewNop(), nil, &notifier.Recorder{Name: models.ChannelPush, Err: errors.New("unregistered")}),
+			body:       `{"title":"Prices"}`,
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name:       "notification could not be sent at all",
+			notifier:   &unavailableNotifier{},
+			body:       `{"title":"Prices"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "dry-run",
+			notifier:   notifier.NewDispatcher(zap.NewNop(), nil, &notifier.Recorder{Name: models.ChannelPush}),
+			body:       `{"title":"Prices"}`,
+			query:      "?dryRun=true",
+			wantStatus: http.StatusOK,
+			wantDryRun: true,
+		},
+		{
+			name:       "title or body is required",
+			notifier:   &notifier.Recorder{},
+			body:       `{"data":{"type":"prices"}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "message for another user",
+			notifier:   &notifier.Recorder{},
+			body:       `{"userId":"someone-else","title":"Prices"}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "service sends to given user",
+			notifier:   notifier.NewDispatcher(zap.NewNop(), nil, &notifier.Recorder{Name: models.ChannelPush}),
+			body:       `{"userId":"someone-else","title":"Prices"}`,
+			service:    "billing",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "service has to give the user",
+			notifier:   &notifier.Recorder{},
+			body:       `{"title":"Prices"}`,
+			service:    "billing",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{}, notifier: tt.notifier}
+			req := httptest.NewRequest(http.MethodPost, "/v1/notifications"+tt.query, strings.NewReader(tt.body))
+			ctx := context.WithValue(req.Context(), constants.UserIdKey, "user")
+			if tt.service != "" {
+				ctx = context.WithValue(req.Context(), constants.ServiceKey, tt.service)
+			}
+			rec := httptest.NewRecorder()
+			handler.SendNotifications(rec, req.WithContext(ctx))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("SendNotifications() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK && rec.Code != http.StatusMultiStatus {
+				return
+			}
+			var response models.NotificationResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.NotificationId == "" || response.DryRun != tt.wantDryRun || len(response.Results) != response.Success+response.Failure {
+				t.Errorf("SendNotifications() response = %+v", response)
+			}
+			if tt.wantStatus == http.StatusMultiStatus && response.Failure == 0 {
+				t.Errorf("SendNotifications() response has no failures: %+v", response)
+			}
+		})
+	}
+}
+
+func TestSendNotificationsDryRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceWide bool
+		query       string
+		body        string
+		wantStatus  int
+		wantDryRun  bool
+	}{
+		{
+			name:       "per request",
+			query:      "?dryRun=1",
+			wantStatus: http.StatusOK,
+			wantDryRun: true,
+		},
+		{
+			name:        "service wide mode can not be turned off per request",
+			serviceWide: true,
+			query:       "?dryRun=false",
+			wantStatus:  http.StatusOK,
+			wantDryRun:  true,
+		},
+		{
+			name:       "invalid value",
+			query:      "?dryRun=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "scheduled notification",
+			query:      "?dryRun=true",
+			body:       `{"title":"Prices","sendAt":"2030-01-01T08:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push := &notifier.Recorder{Name: models.ChannelPush}
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{DryRun: tt.serviceWide}, notifier: push}
+			body := tt.body
+			if body == "" {
+				body = `{"title":"Prices"}`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/notifications"+tt.query, strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			handler.SendNotifications(rec, req.WithContext(context.WithValue(req.Context(), constants.UserIdKey, "user")))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("SendNotifications() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			calls := push.Calls()
+			if tt.wantStatus != http.StatusOK {
+				if len(calls) != 0 {
+					t.Errorf("sent %d notifications, want none", len(calls))
+				}
+				return
+			}
+			var response models.NotificationResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(calls) != 1 || calls[0].DryRun != tt.wantDryRun || response.DryRun != tt.wantDryRun {
+				t.Errorf("calls %+v and response %+v, want dry-run %v", calls, response, tt.wantDryRun)
+			}
+		})
+	}
+}
+
+// resultNotifier reports given results of the delivery to every device of the user
+type resultNotifier struct {
+	notifier.Recorder
+	results []models.DeliveryResult
+	err     error
+}
+
+func (n *resultNotifier) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return n.results, n.err
+}
+
+func TestSendNotificationsResults(t *testing.T) {
+	delivered := models.DeliveryResult{Channel: models.ChannelPush, Recipient: "token-1", Success: true, MessageId: "projects/app/messages/1"}
+	unregistered := models.DeliveryResult{Channel: models.ChannelPush, Recipient: "token-2", ErrorCode: "UNREGISTERED", Error: "Requested entity was not found."}
+	tests := []struct {
+		name        string
+		results     []models.DeliveryResult
+		err         error
+		wantStatus  int
+		wantSuccess int
+		wantFailure int
+		wantError   bool
+	}{
+		{
+			name:        "every device received the notification",
+			results:     []models.DeliveryResult{delivered},
+			wantStatus:  http.StatusOK,
+			wantSuccess: 1,
+		},
+		{
+			name:        "a device is unregistered",
+			results:     []models.DeliveryResult{delivered, unregistered},
+			wantStatus:  http.StatusMultiStatus,
+			wantSuccess: 1,
+			wantFailure: 1,
+		},
+		{
+			name:        "a channel failed as a whole",
+			results:     []models.DeliveryResult{delivered},
+			err:         errors.New("email: smtp unavailable"),
+			wantStatus:  http.StatusMultiStatus,
+			wantSuccess: 1,
+			wantError:   true,
+		},
+		{
+			name:       "user has no recipients",
+			results:    []models.DeliveryResult{},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{}, notifier: &resultNotifier{results: tt.results, err: tt.err}}
+			req := httptest.NewRequest(http.MethodPost, "/v1/notifications", strings.NewReader(`{"title":"Prices"}`))
+			rec := httptest.NewRecorder()
+			handler.SendNotifications(rec, req.WithContext(context.WithValue(req.Context(), constants.UserIdKey, "user")))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("SendNotifications() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			var response models.NotificationResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Success != tt.wantSuccess || response.Failure != tt.wantFailure || (response.Error != "") != tt.wantError {
+				t.Errorf("SendNotifications() response = %+v, want %d succeeded and %d failed, error %v", response, tt.wantSuccess, tt.wantFailure, tt.wantError)
+			}
+			// every device is reported with its status and the reason of its failure
+			if len(response.Results) != len(tt.results) {
+				t.Fatalf("SendNotifications() results = %+v, want %+v", response.Results, tt.results)
+			}
+			for i, result := range response.Results {
+				if result != tt.results[i] {
+					t.Errorf("SendNotifications() result %d = %+v, want %+v", i, result, tt.results[i])
+				}
+			}
+			if response.Results == nil {
+				t.Errorf("SendNotifications() results are null, want a list")
+			}
+		})
+	}
+}
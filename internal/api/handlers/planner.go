@@ -0,0 +1,113 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/helpers"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/planner"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// PlanCharging plans the charging of an electric car to the cheapest hours before a deadline.
+//
+//	@Summary		Plan electric car charging before a deadline
+//	@Description	It allocates the requested energy to the cheapest hours between now and the deadline using today's and tomorrow's effective prices of the user's contract, at most `maxPowerKW` per hour. The hours do not need to be contiguous and an hour may be used only partially.
+//	@Description	If `notify` is true, a summary of the plan is also sent to the devices of the user.
+//	@Tags			planner
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.ChargingRequest	true	"represents the charging need"
+//	@Success		200		{object}	models.ChargingPlan		"The charging plan. If `fulfilled` is false, the requested energy does not fit before the deadline and the plan charges as much as possible."
+//	@Failure		400		{string}	string					"Invalid request"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		404		{string}	string					"If there are no prices available before the deadline"
+//	@Failure		500		{string}	string					"If there is an error retrieving the prices or sending the notification."
+//	@Router			/v1/planner/ev [post]
+func (h Handler) PlanCharging(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.ChargingRequest](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	if err = planner.ValidateChargingRequest(reqBody, now); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid charging request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// today's and tomorrow's prices cover the charging until tomorrow night
+	series := models.PriceSeries{}
+	today, tomorrow := helpers.MarketDates(now)
+	for _, date := range []string{today, tomorrow} {
+		prices, err := h.mongo.GetDailyPrices(date)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get prices", h.workerID, constants.Server), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		series.Name = prices.Prices.Name
+		series.Data = append(series.Data, prices.Prices.Data...)
+	}
+	if len(series.Data) == 0 {
+		http.Error(w, "prices are not available", http.StatusNotFound)
+		return
+	}
+
+	series, err = h.effectivePrices(userId, series)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to compute effective prices", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	plan, err := planner.PlanCharging(reqBody, series, now)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to plan charging", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(plan.Schedule) == 0 {
+		http.Error(w, "prices are not available before the deadline", http.StatusNotFound)
+		return
+	}
+
+	if reqBody.Notify {
+		_, err = h.notifier.SendToUser(r.Context(), userId, models.NotificationMessage{
+			UserId:   userId,
+			Title:    "EV charging plan",
+			Body:     helpers.GenerateChargingPlanMessage(plan),
+			Data:     map[string]string{constants.DataKeyType: constants.NotificationTypeChargingPlan},
+			Category: constants.NotificationTypeChargingPlan,
+		})
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to send notifications", h.workerID, constants.Server), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err = encode.EncodeResponse(w, http.StatusOK, plan); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] plan charging successfully", h.workerID))
+}
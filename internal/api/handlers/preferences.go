@@ -0,0 +1,271 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/email"
+	"github.com/AnhCaooo/electric-notifications/internal/helpers"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// how long an email confirmation link is valid
+const emailVerificationTTL = 24 * time.Hour
+
+// GetPreferences returns the notification preferences of the user.
+//
+//	@Summary		Get the notification preferences of the user
+//	@Description	It returns the delivery channels that the user selected and the email address of the email channel. Users who have not selected any channels receive notifications from the default channels.
+//	@Tags			preferences
+//	@Produce		json
+//	@Success		200	{object}	models.Preferences	"The preferences of the user"
+//	@Failure		401	{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500	{string}	string				"If there is an error retrieving the preferences from the database."
+//	@Router			/v1/preferences [get]
+func (h Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	preferences, err := h.mongo.GetPreferences(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get preferences", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if preferences == nil {
+		preferences = &models.Preferences{UserId: userId}
+	}
+	if len(preferences.Channels) == 0 {
+		preferences.Channels = models.DefaultChannels
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, preferences); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// PutChannels selects the delivery channels of the user.
+//
+//	@Summary		Select the delivery channels of the user
+//	@Description	It stores the delivery channels which the user receives notifications from. The email channel only delivers to a confirmed email address.
+//	@Tags			preferences
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.ChannelSelection	true	"the selected delivery channels"
+//	@Success		200		{object}	models.Preferences		"The updated preferences"
+//	@Failure		400		{string}	string					"Invalid request"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string					"If there is an error storing the preferences into the database."
+//	@Router			/v1/preferences/channels [put]
+func (h Handler) PutChannels(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.ChannelSelection](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(reqBody.Channels) == 0 {
+		http.Error(w, "at least one channel is required", http.StatusBadRequest)
+		return
+	}
+	for _, channel := range reqBody.Channels {
+		if !slices.Contains(models.SelectableChannels, channel) {
+			http.Error(w, fmt.Sprintf("unsupported channel '%s'", channel), http.StatusBadRequest)
+			return
+		}
+	}
+	slices.Sort(reqBody.Channels)
+
+	preferences, err := h.mongo.UpdateChannels(userId, slices.Compact(reqBody.Channels))
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to update channels", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, preferences); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] update channels successfully", h.workerID))
+}
+
+// PutCategories selects the categories of broadcasts which the user receives.
+//
+//	@Summary		Opt in to categories of broadcasts
+//	@Description	It stores the categories of broadcasts (ex: tips) which the user opted in to. Broadcasts which target a category are only sent to the users who opted in to it.
+//	@Tags			preferences
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.CategorySelection	true	"the selected categories"
+//	@Success		200		{object}	models.Preferences			"The updated preferences"
+//	@Failure		400		{string}	string						"Invalid request"
+//	@Failure		401		{string}	string						"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string						"If there is an error storing the preferences into the database."
+//	@Router			/v1/preferences/categories [put]
+func (h Handler) PutCategories(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.CategorySelection](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, category := range reqBody.Categories {
+		if category == "" {
+			http.Error(w, "category must not be empty", http.StatusBadRequest)
+			return
+		}
+	}
+	slices.Sort(reqBody.Categories)
+
+	preferences, err := h.mongo.UpdateCategories(userId, slices.Compact(reqBody.Categories))
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to update categories", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, preferences); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] update categories successfully", h.workerID))
+}
+
+// PutEmail registers the email address of the user for the email channel.
+//
+//	@Summary		Register the email address of the user
+//	@Description	It stores the email address as unconfirmed and sends an email with a confirmation link to it. The address receives notifications only after the link has been opened. Registering a new address replaces the previous one.
+//	@Tags			preferences
+//	@Accept			json
+//	@Param			payload	body		models.EmailAddress	true	"the email address of the user"
+//	@Success		202		{string}	string				"The confirmation email was sent"
+//	@Failure		400		{string}	string				"Invalid request"
+//	@Failure		401		{string}	string				"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string				"If there is an error storing the address or sending the confirmation email."
+//	@Failure		503		{string}	string				"If the email channel is not configured"
+//	@Router			/v1/preferences/email [put]
+func (h Handler) PutEmail(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+	if h.mailer == nil || h.config.Server.PublicURL == "" {
+		http.Error(w, "email channel is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.EmailAddress](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	address, err := mail.ParseAddress(reqBody.Email)
+	if err != nil || address.Address != reqBody.Email {
+		http.Error(w, fmt.Sprintf("invalid email address '%s'", reqBody.Email), http.StatusBadRequest)
+		return
+	}
+
+	token, hash, err := newVerificationToken()
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to generate verification token", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	expiresAt := time.Now().UTC().Add(emailVerificationTTL)
+	if err = h.mongo.SetEmail(userId, address.Address, hash, expiresAt); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to set email", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	link := helpers.BuildEmailVerificationURL(h.config.Server.PublicURL, token)
+	message, err := email.RenderVerification(address.Address, link)
+	if err == nil {
+		_, err = h.mailer.Send(r.Context(), message)
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to send verification email", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	h.logger.Info(fmt.Sprintf("[worker_%d] sent verification email successfully", h.workerID))
+}
+
+// VerifyEmail confirms the email address of a user. It is opened from the confirmation email, so it does not require an access token.
+//
+//	@Summary		Confirm the email address of a user
+//	@Description	It confirms the email address whose confirmation link contains given token. The link is valid for 24 hours and can be used only once.
+//	@Tags			preferences
+//	@Produce		plain
+//	@Param			token	query		string	true	"the token of the confirmation link"
+//	@Success		200		{string}	string	"The email address was confirmed"
+//	@Failure		400		{string}	string	"If the token is missing"
+//	@Failure		404		{string}	string	"If the token is unknown, already used or expired"
+//	@Failure		500		{string}	string	"If there is an error updating the database."
+//	@Router			/v1/preferences/email/verify [get]
+func (h Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	preferences, err := h.mongo.VerifyEmail(hashVerificationToken(token), time.Now().UTC())
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "the confirmation link is invalid or has expired", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to verify email", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "Your email address %s is confirmed.", preferences.Email)
+	h.logger.Info(fmt.Sprintf("[worker_%d] verify email successfully", h.workerID))
+}
+
+// newVerificationToken generates a random email confirmation token and the hash which is stored in the database
+func newVerificationToken() (token, hash string, err error) {
+	random := make([]byte, 32)
+	if _, err = rand.Read(random); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(random)
+	return token, hashVerificationToken(token), nil
+}
+
+// hashVerificationToken hashes the email confirmation token, so a leaked database does not reveal valid links
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,230 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/db"
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+	"github.com/AnhCaooo/electric-notifications/internal/email"
+	"github.com/AnhCaooo/electric-notifications/internal/email/emailtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// newTestDatabase returns a database stored on a fake server which answers with given reply function
+func newTestDatabase(t *testing.T, reply dbtest.ReplyFunc) (*db.Mongo, *dbtest.Server) {
+	t.Helper()
+	server := dbtest.NewServer(reply)
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to connect to fake server: %v", err)
+	}
+	mongo := db.NewMongo(context.Background(), &models.Database{Name: "test", Collection: "tokens"}, zap.NewNop())
+	if err = mongo.Open(client); err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	server.Reset()
+	return mongo, server
+}
+
+func TestPutChannels(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantChannels bson.A
+	}{
+		{
+			name:         "channels are stored once and sorted",
+			body:         `{"channels":["webhook","email","webhook"]}`,
+			wantStatus:   http.StatusOK,
+			wantChannels: bson.A{"email", "webhook"},
+		},
+		{
+			name:       "unsupported channel",
+			body:       `{"channels":["push","sms"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no channel",
+			body:       `{"channels":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongo, server := newTestDatabase(t, func(command bson.M) bson.D {
+				return dbtest.FindAndModify(bson.D{{Key: "userId", Value: "user"}})
+			})
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{}, mongo: mongo}
+			req := httptest.NewRequest(http.MethodPut, "/v1/preferences/channels", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.PutChannels(rec, req.WithContext(context.WithValue(req.Context(), constants.UserIdKey, "user")))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("PutChannels() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			updates := server.Commands("findAndModify")
+			if tt.wantChannels == nil {
+				if len(updates) != 0 {
+					t.Errorf("sent %d updates, want none", len(updates))
+				}
+				return
+			}
+			if len(updates) != 1 {
+				t.Fatalf("sent %d updates, want 1", len(updates))
+			}
+			channels := updates[0]["update"].(bson.M)["$set"].(bson.M)["channels"].(bson.A)
+			if !slices.Equal(channels, tt.wantChannels) {
+				t.Errorf("stored channels %v, want %v", channels, tt.wantChannels)
+			}
+		})
+	}
+}
+
+func TestPutEmail(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		noMailer   bool
+		wantStatus int
+	}{
+		{
+			name:       "address is registered and a confirmation is sent",
+			body:       `{"email":"user@example.com"}`,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "invalid address",
+			body:       `{"email":"not an address"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "address with a display name",
+			body:       `{"email":"User <user@example.com>"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "email channel is not configured",
+			body:       `{"email":"user@example.com"}`,
+			noMailer:   true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smtpServer, err := emailtest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer smtpServer.Close()
+			mongo, server := newTestDatabase(t, func(command bson.M) bson.D {
+				return dbtest.Written(1)
+			})
+			handler := &Handler{logger: zap.NewNop(), mongo: mongo, config: &models.Config{}}
+			handler.config.Server.PublicURL = "https://example.com"
+			if !tt.noMailer {
+				handler.mailer = email.NewMailer(smtpServer.Config("noreply@example.com"), nil)
+			}
+			req := httptest.NewRequest(http.MethodPut, "/v1/preferences/email", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.PutEmail(rec, req.WithContext(context.WithValue(req.Context(), constants.UserIdKey, "user")))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("PutEmail() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			updates := server.Commands("update")
+			messages := smtpServer.Messages()
+			if tt.wantStatus != http.StatusAccepted {
+				if len(updates) != 0 || len(messages) != 0 {
+					t.Errorf("sent %d updates and %d emails, want none", len(updates), len(messages))
+				}
+				return
+			}
+			if len(updates) != 1 || len(messages) != 1 {
+				t.Fatalf("sent %d updates and %d emails, want 1 of each", len(updates), len(messages))
+			}
+			// the address is unconfirmed until the link is opened, also if the user had confirmed another one before
+			set := updates[0]["updates"].(bson.A)[0].(bson.M)["u"].(bson.M)["$set"].(bson.M)
+			if set["email"] != "user@example.com" || set["emailVerified"] != false {
+				t.Errorf("$set = %v, want the unconfirmed address", set)
+			}
+			// only the hash of the token in the link is stored
+			token := regexp.MustCompile(`token=3D([0-9a-f]{64})`).FindStringSubmatch(strings.ReplaceAll(messages[0].Data, "=\r\n", ""))
+			if token == nil {
+				t.Fatalf("email does not contain a confirmation link:\n%s", messages[0].Data)
+			}
+			if set["verificationHash"] != hashVerificationToken(token[1]) {
+				t.Errorf("stored hash %v, want the hash of the token %s", set["verificationHash"], token[1])
+			}
+			if messages[0].To[0] != "user@example.com" {
+				t.Errorf("confirmation sent to %v, want user@example.com", messages[0].To)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		// the preferences which have a pending confirmation of the token, nil if there is none
+		pending    bson.D
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "pending confirmation",
+			query:      "?token=abc",
+			pending:    bson.D{{Key: "userId", Value: "user"}, {Key: "email", Value: "user@example.com"}, {Key: "emailVerified", Value: true}},
+			wantStatus: http.StatusOK,
+			wantBody:   "Your email address user@example.com is confirmed.",
+		},
+		{
+			name:       "unknown, used or expired token",
+			query:      "?token=abc",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "missing token",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongo, server := newTestDatabase(t, func(command bson.M) bson.D {
+				return dbtest.FindAndModify(tt.pending)
+			})
+			handler := &Handler{logger: zap.NewNop(), config: &models.Config{}, mongo: mongo}
+			rec := httptest.NewRecorder()
+			handler.VerifyEmail(rec, httptest.NewRequest(http.MethodGet, "/v1/preferences/email/verify"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("VerifyEmail() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("VerifyEmail() body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			commands := server.Commands("findAndModify")
+			if tt.query == "" {
+				if len(commands) != 0 {
+					t.Errorf("sent %d commands, want none", len(commands))
+				}
+				return
+			}
+			if len(commands) != 1 || commands[0]["query"].(bson.M)["verificationHash"] != hashVerificationToken("abc") {
+				t.Errorf("commands %v, want a look up of the hash of the token", commands)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/chart"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+)
+
+// GetPriceChart returns the bar chart image of the price series of given date.
+//
+//	@Summary		Get the price chart of a date as PNG image
+//	@Description	It renders a bar chart of the price series of given date where the hours that are cheaper than the average price of the day are highlighted.
+//	@Description	The image changes only when the prices of the date are stored again, so the response can be revalidated with its ETag. This endpoint does not require authentication because the image is fetched by the device's operating system when showing a notification.
+//	@Tags			prices
+//	@Produce		png
+//	@Param			date	path		string	true	"date of the price series in format YYYY-MM-DD"
+//	@Success		200		{file}		binary	"PNG image of the price chart"
+//	@Failure		400		{string}	string	"Invalid date"
+//	@Failure		404		{string}	string	"If there is no price series stored for the date"
+//	@Failure		500		{string}	string	"If there is an error retrieving the prices or rendering the chart"
+//	@Router			/v1/prices/{date}/chart.png [get]
+func (h Handler) GetPriceChart(w http.ResponseWriter, r *http.Request) {
+	date := mux.Vars(r)["date"]
+	if _, err := time.Parse(constants.DateLayout, date); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid date", h.workerID, constants.Client), zap.String("date", date))
+		http.Error(w, fmt.Sprintf("invalid date '%s', expected format YYYY-MM-DD", date), http.StatusBadRequest)
+		return
+	}
+
+	prices, err := h.mongo.GetDailyPrices(date)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, fmt.Sprintf("prices of %s are not available", date), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get prices", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the chart is cached per revision of the prices, so prices which are stored again (ex: corrected) get a new chart
+	cacheKey := fmt.Sprintf("chart_%s_%d", date, prices.UpdatedAt.UnixNano())
+	image, found := h.cache.Get(cacheKey)
+	if !found {
+		image, err = chart.RenderPNG(prices.Prices)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to render price chart", h.workerID, constants.Server), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.cache.SetExpiredAfterTimePeriod(cacheKey, image, 24*time.Hour)
+	}
+
+	imageBytes := image.([]byte)
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(imageBytes))
+	// the clients revalidate the image with its ETag, as the prices of the date may be stored again
+	w.Header().Set("Cache-Control", "public, no-cache")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(imageBytes)
+}
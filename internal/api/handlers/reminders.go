@@ -0,0 +1,167 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/helpers"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/reminders"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// CreateReminder creates a reminder which is sent before the cheapest window or threshold hour begins.
+//
+//	@Summary		Create a reminder for cheap price periods
+//	@Description	It creates a reminder for the user in the access token. Every time new prices are published, the service computes when the cheapest window of `windowHours` hours (type `cheapest_window`) or each period below `threshold` (type `threshold`) begins, and sends a push notification `minutesBefore` minutes before it.
+//	@Description	If the prices of today or tomorrow are already published, the reminders for them are scheduled right away.
+//	@Tags			reminders
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.Reminder	true	"represents the reminder to be created"
+//	@Success		201		{object}	models.Reminder	"The created reminder"
+//	@Failure		400		{string}	string			"Invalid request"
+//	@Failure		401		{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string			"If there is an error inserting the reminder into the database."
+//	@Router			/v1/reminders [post]
+func (h Handler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.Reminder](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reqBody.UserId = userId
+	if err = reminders.Validate(reqBody); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid reminder", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reminder, err := h.mongo.InsertReminder(reqBody)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to insert reminder", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// schedule the reminders for the prices which have already been published
+	// the prices are stored by the dates of the market, which begin before the UTC dates
+	now := time.Now().UTC()
+	today, tomorrow := helpers.MarketDates(now)
+	for _, date := range []string{today, tomorrow} {
+		if err = h.scheduleReminder(*reminder, date, now); err != nil {
+			h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to schedule reminder", h.workerID, constants.Server), zap.String("date", date), zap.Error(err))
+		}
+	}
+
+	if err = encode.EncodeResponse(w, http.StatusCreated, reminder); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] create reminder successfully", h.workerID))
+}
+
+// scheduleReminder schedules the jobs of a reminder for the prices of given date, if they are available
+func (h Handler) scheduleReminder(reminder models.Reminder, date string, now time.Time) error {
+	prices, err := h.mongo.GetDailyPrices(date)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	effective, err := h.effectivePrices(reminder.UserId, prices.Prices)
+	if err != nil {
+		return err
+	}
+	jobs, err := reminders.BuildJobs(reminder, effective, now)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err = h.mongo.InsertJob(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetReminders returns all reminders of the user.
+//
+//	@Summary		Get all reminders of the user
+//	@Tags			reminders
+//	@Produce		json
+//	@Success		200	{array}		models.Reminder	"List of reminders"
+//	@Failure		401	{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		500	{string}	string			"If there is an error retrieving the reminders from the database."
+//	@Router			/v1/reminders [get]
+func (h Handler) GetReminders(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	userReminders, err := h.mongo.GetReminders(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get reminders", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, userReminders); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// DeleteReminder deletes a reminder of the user together with its scheduled notifications.
+//
+//	@Summary		Delete a reminder
+//	@Tags			reminders
+//	@Param			id	path		string	true	"ID of the reminder"
+//	@Success		204	{string}	string	"The reminder was deleted"
+//	@Failure		400	{string}	string	"Invalid reminder ID"
+//	@Failure		401	{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string	"If the user does not have a reminder with given ID"
+//	@Failure		500	{string}	string	"If there is an error deleting the reminder from the database."
+//	@Router			/v1/reminders/{id} [delete]
+func (h Handler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid reminder ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.DeleteReminder(userId, id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "reminder not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to delete reminder", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,151 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/webhook"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// CreateWebhook registers a webhook which receives the notifications of the user.
+//
+//	@Summary		Register a webhook
+//	@Description	It registers an HTTPS URL which receives every notification of the user as a signed JSON POST request. The response contains the secret which signs the requests; it is not returned again.
+//	@Description	Every request has the headers `X-Webhook-Timestamp` (unix seconds) and `X-Webhook-Signature` ("sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>" with the secret). Reject requests with an old timestamp to prevent replays.
+//	@Description	Failed deliveries (non-2xx responses) are retried with backoff, and the webhook is disabled after too many consecutive failed deliveries.
+//	@Description	The host of the URL must be public: addresses in loopback, private, link-local and other internal ranges are rejected. A user may register a limited number of webhooks.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		models.WebhookRequest	true	"represents the webhook to be registered"
+//	@Success		201		{object}	models.Webhook			"The registered webhook together with its secret"
+//	@Failure		400		{string}	string					"Invalid request"
+//	@Failure		401		{string}	string					"Unauthenticated/Unauthorized"
+//	@Failure		409		{string}	string					"If the user already has the maximum number of webhooks"
+//	@Failure		500		{string}	string					"If there is an error inserting the webhook into the database."
+//	@Router			/v1/webhooks [post]
+func (h Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.WebhookRequest](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = webhook.ValidateURL(r.Context(), reqBody.URL); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid webhook", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	count, err := h.mongo.CountWebhooks(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to count webhooks", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if maxPerUser := webhook.MaxPerUser(&h.config.Webhooks); count >= int64(maxPerUser) {
+		h.logger.Info(fmt.Sprintf("[worker_%d] %s webhook limit reached", h.workerID, constants.Client), zap.Int64("count", count))
+		http.Error(w, fmt.Sprintf("a user may register at most %d webhooks", maxPerUser), http.StatusConflict)
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to generate webhook secret", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	hook, err := h.mongo.InsertWebhook(models.Webhook{UserId: userId, URL: reqBody.URL, Secret: secret})
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to insert webhook", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = encode.EncodeResponse(w, http.StatusCreated, hook); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+	h.logger.Info(fmt.Sprintf("[worker_%d] create webhook successfully", h.workerID))
+}
+
+// GetWebhooks returns all webhooks of the user together with the state of their deliveries.
+//
+//	@Summary		Get webhooks of the user
+//	@Tags			webhooks
+//	@Produce		json
+//	@Success		200	{array}		models.Webhook	"List of webhooks, without their secrets"
+//	@Failure		401	{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		500	{string}	string			"If there is an error retrieving the webhooks from the database."
+//	@Router			/v1/webhooks [get]
+func (h Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	webhooks, err := h.mongo.GetWebhooks(userId)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get webhooks", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	if err = encode.EncodeResponse(w, http.StatusOK, webhooks); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// DeleteWebhook deletes a webhook of the user.
+//
+//	@Summary		Delete a webhook
+//	@Tags			webhooks
+//	@Param			id	path		string	true	"ID of the webhook"
+//	@Success		204	{string}	string	"The webhook was deleted"
+//	@Failure		400	{string}	string	"Invalid webhook ID"
+//	@Failure		401	{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		404	{string}	string	"If the user does not have a webhook with given ID"
+//	@Failure		500	{string}	string	"If there is an error deleting the webhook from the database."
+//	@Router			/v1/webhooks/{id} [delete]
+func (h Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := bson.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.DeleteWebhook(userId, id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to delete webhook", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+// AnhCao 2024
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/webpush"
+	"github.com/AnhCaooo/go-goods/encode"
+)
+
+// GetVAPIDKey returns the public VAPID key which browsers subscribe to the Web Push channel with.
+//
+//	@Summary		Get the public VAPID key
+//	@Description	It returns the public key of the service which is given as `applicationServerKey` to `PushManager.subscribe()` in the browser.
+//	@Tags			webpush
+//	@Produce		json
+//	@Success		200	{object}	models.VAPIDKey	"The public VAPID key"
+//	@Failure		401	{string}	string			"Unauthenticated/Unauthorized"
+//	@Failure		503	{string}	string			"If the Web Push channel is not configured"
+//	@Router			/v1/webpush/key [get]
+func (h Handler) GetVAPIDKey(w http.ResponseWriter, r *http.Request) {
+	if h.config.VAPID.PrivateKey == "" {
+		http.Error(w, "web push channel is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if err := encode.EncodeResponse(w, http.StatusOK, models.VAPIDKey{PublicKey: h.config.VAPID.PublicKey}); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to encode response", h.workerID, constants.Server), zap.Error(err))
+		return
+	}
+}
+
+// CreateWebPushSubscription registers the Web Push subscription of a browser of the user.
+//
+//	@Summary		Register a Web Push subscription
+//	@Description	It stores the subscription of a browser (the result of `PushSubscription.toJSON()`) next to the device tokens of the user. Registering the same endpoint again refreshes the subscription.
+//	@Description	The endpoint must belong to a known push service: FCM (Chromium based browsers), Mozilla autopush (Firefox), Apple (Safari) or WNS (legacy Edge).
+//	@Tags			webpush
+//	@Accept			json
+//	@Param			payload	body		models.WebPushSubscription	true	"the subscription of the browser"
+//	@Success		201		{string}	string						"The subscription was registered"
+//	@Failure		400		{string}	string						"Invalid request"
+//	@Failure		401		{string}	string						"Unauthenticated/Unauthorized"
+//	@Failure		500		{string}	string						"If there is an error storing the subscription into the database."
+//	@Failure		503		{string}	string						"If the Web Push channel is not configured"
+//	@Router			/v1/webpush/subscriptions [post]
+func (h Handler) CreateWebPushSubscription(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+	if h.config.VAPID.PrivateKey == "" {
+		http.Error(w, "web push channel is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	reqBody, err := encode.DecodeRequest[models.WebPushSubscription](r)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to decode request", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = webpush.ValidateSubscription(reqBody); err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s invalid web push subscription", h.workerID, constants.Client), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.mongo.InsertToken(models.NotificationToken{
+		UserId:    userId,
+		DeviceId:  reqBody.Endpoint,
+		Platform:  models.PlatformWebPush,
+		Keys:      &reqBody.Keys,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to insert web push subscription", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	h.logger.Info(fmt.Sprintf("[worker_%d] insert web push subscription successfully", h.workerID))
+}
+
+// DeleteWebPushSubscription removes the Web Push subscription of a browser of the user, ex: when the user unsubscribes.
+//
+//	@Summary		Delete a Web Push subscription
+//	@Tags			webpush
+//	@Param			endpoint	query		string	true	"endpoint of the subscription"
+//	@Success		204			{string}	string	"The subscription was deleted"
+//	@Failure		400			{string}	string	"If the endpoint is missing"
+//	@Failure		401			{string}	string	"Unauthenticated/Unauthorized"
+//	@Failure		404			{string}	string	"If the user does not have a subscription with given endpoint"
+//	@Failure		500			{string}	string	"If there is an error deleting the subscription from the database."
+//	@Router			/v1/webpush/subscriptions [delete]
+func (h Handler) DeleteWebPushSubscription(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(constants.UserIdKey).(string)
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	endpoint := r.URL.Query().Get("endpoint")
+	if endpoint == "" {
+		http.Error(w, "endpoint is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.mongo.DeleteToken(userId, endpoint)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("[worker_%d] %s failed to delete web push subscription", h.workerID, constants.Server), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+// AnhCao 2024
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// APIKeyHeader is the header which carries the API key of a service
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyStore provides the API keys of the services
+type APIKeyStore interface {
+	// GetActiveAPIKey returns nil without an error if there is no active key with given hash
+	GetActiveAPIKey(hash string) (*models.APIKey, error)
+	TouchAPIKey(id bson.ObjectID, usedAt time.Time) error
+}
+
+// authenticateAPIKey verifies the API key of a service and adds the name of the service, the service role
+// and the scopes of the key to the context. The key is looked up on every request, so a revoked key is rejected right away.
+func (m Middleware) authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKey string) {
+	key, err := m.apiKeys.GetActiveAPIKey(auth.HashAPIKey(apiKey))
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get API key", m.workerID, constants.Server), zap.Error(err))
+		http.Error(w, "500 - Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if key == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		m.logger.Info(fmt.Sprintf("[worker_%d] unauthorized request: unknown or revoked API key", m.workerID))
+		w.Write([]byte("401 - Unauthorized"))
+		return
+	}
+	if err = m.apiKeys.TouchAPIKey(key.ID, time.Now().UTC()); err != nil {
+		m.logger.Error(fmt.Sprintf("[worker_%d] %s failed to record usage of API key", m.workerID, constants.Server), zap.Error(err))
+	}
+
+	ctx := context.WithValue(r.Context(), constants.ServiceKey, key.Name)
+	ctx = context.WithValue(ctx, constants.RolesKey, []string{constants.RoleService})
+	ctx = context.WithValue(ctx, constants.ScopesKey, key.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
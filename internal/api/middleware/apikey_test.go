@@ -0,0 +1,127 @@
+// AnhCao 2024
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// apiKeyStub stores the API keys by their hash. Like the database, it does not return revoked keys.
+type apiKeyStub struct {
+	keys    map[string]models.APIKey
+	err     error
+	touched []bson.ObjectID
+}
+
+func (s *apiKeyStub) GetActiveAPIKey(hash string) (*models.APIKey, error) {
+	key, ok := s.keys[hash]
+	if s.err != nil || !ok || key.RevokedAt != nil {
+		return nil, s.err
+	}
+	return &key, nil
+}
+
+func (s *apiKeyStub) TouchAPIKey(id bson.ObjectID, usedAt time.Time) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	revokedAt := time.Now().UTC()
+	active := models.APIKey{ID: bson.NewObjectID(), Name: "billing", Scopes: []string{models.ScopeNotificationsSend}}
+	revoked := models.APIKey{ID: bson.NewObjectID(), Name: "billing", Scopes: []string{models.ScopeNotificationsSend}, RevokedAt: &revokedAt}
+	readOnly := models.APIKey{ID: bson.NewObjectID(), Name: "reports", Scopes: []string{models.ScopeDeliveriesRead}}
+	keys := map[string]models.APIKey{
+		auth.HashAPIKey("en_active"):   active,
+		auth.HashAPIKey("en_revoked"):  revoked,
+		auth.HashAPIKey("en_readonly"): readOnly,
+	}
+
+	tests := []struct {
+		name   string
+		apiKey string
+		// scopes of the route
+		scopes      []string
+		storeErr    error
+		wantStatus  int
+		wantTouched bool
+	}{
+		{
+			name:        "key with the scope of the route",
+			apiKey:      "en_active",
+			scopes:      []string{models.ScopeNotificationsSend},
+			wantStatus:  http.StatusOK,
+			wantTouched: true,
+		},
+		{
+			name:       "revoked key",
+			apiKey:     "en_revoked",
+			scopes:     []string{models.ScopeNotificationsSend},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown key",
+			apiKey:     "en_unknown",
+			scopes:     []string{models.ScopeNotificationsSend},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "key without the scope of the route",
+			apiKey:      "en_readonly",
+			scopes:      []string{models.ScopeNotificationsSend},
+			wantStatus:  http.StatusForbidden,
+			wantTouched: true,
+		},
+		{
+			name:        "route which services can not use",
+			apiKey:      "en_active",
+			wantStatus:  http.StatusForbidden,
+			wantTouched: true,
+		},
+		{
+			name:       "failure of the store",
+			apiKey:     "en_active",
+			scopes:     []string{models.ScopeNotificationsSend},
+			storeErr:   errors.New("database unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &apiKeyStub{keys: keys, err: tt.storeErr}
+			m := NewMiddleware(zap.NewNop(), &models.Config{}, store, 1)
+			var service string
+			var scopes []string
+			handler := m.Authenticate(m.Authorize(nil, tt.scopes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				service, _ = r.Context().Value(constants.ServiceKey).(string)
+				scopes, _ = r.Context().Value(constants.ScopesKey).([]string)
+			})))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/notifications", nil)
+			req.Header.Set(APIKeyHeader, tt.apiKey)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && (service != active.Name || !slices.Equal(scopes, active.Scopes)) {
+				t.Errorf("handler got service %q with scopes %v, want %q with %v", service, scopes, active.Name, active.Scopes)
+			}
+			if (len(store.touched) == 1) != tt.wantTouched {
+				t.Errorf("usage recorded %d times, want recorded %v", len(store.touched), tt.wantTouched)
+			}
+		})
+	}
+}
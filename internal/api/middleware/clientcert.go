@@ -0,0 +1,44 @@
+// AnhCao 2024
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+)
+
+// clientIdentity returns the identity of the client certificate which the server verified against the configured
+// authorities (mutual TLS): the common name, or the first DNS name. It is empty if the client did not present a certificate.
+func clientIdentity(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+	if certificate.Subject.CommonName != "" {
+		return certificate.Subject.CommonName
+	}
+	if len(certificate.DNSNames) > 0 {
+		return certificate.DNSNames[0]
+	}
+	return ""
+}
+
+// authenticateClientCert authenticates an internal caller by its client certificate, if the identity of the certificate
+// is listed in the configuration. The caller is handled as a service with the configured scopes.
+// It reports false without writing a response if the identity is not listed.
+func (m Middleware) authenticateClientCert(w http.ResponseWriter, r *http.Request, next http.Handler, identity string) bool {
+	scopes, ok := m.config.Server.TLS.Clients[identity]
+	if identity == "" || !ok {
+		return false
+	}
+	m.logger.Info(fmt.Sprintf("[worker_%d] request authenticated with client certificate", m.workerID), zap.String("identity", identity))
+	ctx := context.WithValue(r.Context(), constants.ServiceKey, identity)
+	ctx = context.WithValue(ctx, constants.RolesKey, []string{constants.RoleService})
+	ctx = context.WithValue(ctx, constants.ScopesKey, scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+	return true
+}
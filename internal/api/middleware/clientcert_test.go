@@ -0,0 +1,131 @@
+// AnhCao 2024
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// newCertificate generates a certificate signed by given parent, or a self-signed authority if the parent is nil
+func newCertificate(t *testing.T, template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, any(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestAuthenticateClientCert(t *testing.T) {
+	authority := newCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "internal CA"}}, nil)
+	clients := map[string][]string{
+		"billing":           {models.ScopeNotificationsSend},
+		"reports":           {models.ScopeDeliveriesRead},
+		"scheduler.example": {models.ScopeNotificationsSend},
+	}
+
+	tests := []struct {
+		name string
+		// certificate which the client presents, nil for none
+		certificate *x509.Certificate
+		wantStatus  int
+		wantService string
+	}{
+		{
+			name:        "identity with the scope of the route",
+			certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}},
+			wantStatus:  http.StatusOK,
+			wantService: "billing",
+		},
+		{
+			name:        "identity of the DNS name",
+			certificate: &x509.Certificate{DNSNames: []string{"scheduler.example"}},
+			wantStatus:  http.StatusOK,
+			wantService: "scheduler.example",
+		},
+		{
+			name:        "identity without the scope of the route",
+			certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "reports"}},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "identity which is not configured",
+			certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:       "no certificate",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.Config{}
+			config.Server.TLS.Clients = clients
+			m := NewMiddleware(zap.NewNop(), config, &apiKeyStub{}, 1)
+			var service string
+			server := httptest.NewUnstartedServer(m.Authenticate(m.Authorize(nil, []string{models.ScopeNotificationsSend})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					service, _ = r.Context().Value(constants.ServiceKey).(string)
+				}),
+			)))
+			pool := x509.NewCertPool()
+			pool.AddCert(authority.Leaf)
+			server.TLS = &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}
+			server.StartTLS()
+			defer server.Close()
+
+			client := server.Client()
+			if tt.certificate != nil {
+				certificate := newCertificate(t, tt.certificate, &authority)
+				client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{certificate}
+			}
+			response, err := client.Post(server.URL+"/v1/notifications", "application/json", nil)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", response.StatusCode, tt.wantStatus)
+			}
+			if service != tt.wantService {
+				t.Errorf("handler got service %q, want %q", service, tt.wantService)
+			}
+		})
+	}
+}
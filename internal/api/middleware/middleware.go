@@ -7,9 +7,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/AnhCaooo/electric-notifications/internal/auth"
 	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
-	"github.com/AnhCaooo/go-goods/auth"
 	"go.uber.org/zap"
 )
 
@@ -17,13 +17,17 @@ type Middleware struct {
 	logger   *zap.Logger
 	config   *models.Config
 	workerID int
+	verifier *auth.Verifier
+	apiKeys  APIKeyStore
 }
 
-func NewMiddleware(logger *zap.Logger, config *models.Config, workerID int) *Middleware {
+func NewMiddleware(logger *zap.Logger, config *models.Config, apiKeys APIKeyStore, workerID int) *Middleware {
 	return &Middleware{
 		logger:   logger,
 		config:   config,
 		workerID: workerID,
+		apiKeys:  apiKeys,
+		verifier: auth.NewVerifier(config),
 	}
 }
 
@@ -35,15 +39,32 @@ func (m Middleware) Logger(next http.Handler) http.Handler {
 	})
 }
 
-// read the token from request and do verify the access token
+// read the token from request and do verify the access token. Services authenticate with an API key instead,
+// and internal callers may authenticate with their client certificate (mutual TLS).
 func (m Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.URL.Path, "/swagger/") {
+		// price chart images are fetched by the device's operating system without any credentials
+		isPriceChart := strings.HasPrefix(r.URL.Path, "/v1/prices/") && strings.HasSuffix(r.URL.Path, "/chart.png")
+		// email confirmation links are opened from the mailbox, the token in the link authenticates the request
+		isEmailVerification := r.URL.Path == "/v1/preferences/email/verify"
+		if strings.HasPrefix(r.URL.Path, "/swagger/") || isPriceChart || isEmailVerification {
 			next.ServeHTTP(w, r)
 			return
 		}
+		// the identity of a verified client certificate is available to every handler, whichever way the caller authenticates
+		identity := clientIdentity(r)
+		if identity != "" {
+			r = r.WithContext(context.WithValue(r.Context(), constants.ClientCertKey, identity))
+		}
+		if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+			m.authenticateAPIKey(w, r, next, apiKey)
+			return
+		}
 		tokenString := r.Header.Get("Authorization")
 		if tokenString == "" {
+			if m.authenticateClientCert(w, r, next, identity) {
+				return
+			}
 			w.WriteHeader(http.StatusForbidden)
 			m.logger.Info(fmt.Sprintf("[worker_%d] permission Denied: No token provided", m.workerID))
 			w.Write([]byte("403 - Forbidden"))
@@ -51,7 +72,7 @@ func (m Middleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
-		token, err := auth.VerifyToken(tokenString, m.config.Supabase.Auth.JwtSecret)
+		claims, err := m.verifier.Verify(tokenString)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			m.logger.Error(fmt.Sprintf("[worker_%d] unauthorized request", m.workerID), zap.Error(err))
@@ -60,7 +81,10 @@ func (m Middleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		// due to 'Supabase' authentication, it stores userId via "sub" field
-		userID, err := auth.ExtractValueFromTokenClaim(token, "sub")
+		userID, err := claims.GetSubject()
+		if err == nil && userID == "" {
+			err = fmt.Errorf("token has no subject")
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			m.logger.Error(fmt.Sprintf("[worker_%d] unauthorized request", m.workerID), zap.Error(err))
@@ -68,8 +92,9 @@ func (m Middleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Add userID to the context
+		// Add userID and the roles of the user to the context
 		ctx := context.WithValue(r.Context(), constants.UserIdKey, userID)
+		ctx = context.WithValue(ctx, constants.RolesKey, m.extractRoles(claims, userID))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,111 @@
+// AnhCao 2024
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+)
+
+// defaultRoleClaims are the claims of a Supabase token which contain the roles of the user,
+// which are set by the service_role into the app_metadata of the user
+var defaultRoleClaims = []string{"app_metadata.roles", "app_metadata.role"}
+
+// Authorize returns a middleware which only lets through the users that have any of given roles,
+// and the services whose API key or client certificate has any of given scopes. Without roles, every authenticated user is let through.
+// Without scopes, no service is let through.
+func (m Middleware) Authorize(roles []string, scopes []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, isService := r.Context().Value(constants.ServiceKey).(string); isService {
+				m.authorizeService(w, r, next, scopes)
+				return
+			}
+			if len(roles) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			callerRoles, _ := r.Context().Value(constants.RolesKey).([]string)
+			for _, role := range roles {
+				if slices.Contains(callerRoles, role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			m.logger.Info(fmt.Sprintf("[worker_%d] permission denied: missing role", m.workerID), zap.String("endpoint", r.URL.Path), zap.Strings("required", roles))
+			http.Error(w, fmt.Sprintf("403 - Forbidden: one of the roles %s is required", strings.Join(roles, ", ")), http.StatusForbidden)
+		})
+	}
+}
+
+// authorizeService only lets through the service if its API key or client certificate has any of given scopes
+func (m Middleware) authorizeService(w http.ResponseWriter, r *http.Request, next http.Handler, scopes []string) {
+	keyScopes, _ := r.Context().Value(constants.ScopesKey).([]string)
+	for _, scope := range scopes {
+		if slices.Contains(keyScopes, scope) {
+			next.ServeHTTP(w, r)
+			return
+		}
+	}
+	m.logger.Info(fmt.Sprintf("[worker_%d] permission denied: missing scope", m.workerID), zap.String("endpoint", r.URL.Path), zap.Strings("required", scopes))
+	if len(scopes) == 0 {
+		http.Error(w, "403 - Forbidden: the endpoint can not be used with an API key", http.StatusForbidden)
+		return
+	}
+	http.Error(w, fmt.Sprintf("403 - Forbidden: one of the scopes %s is required", strings.Join(scopes, ", ")), http.StatusForbidden)
+}
+
+// extractRoles returns the roles of the user from the configured claims of the token, together with the admin role
+// if the user is listed as an admin in the configuration
+func (m Middleware) extractRoles(claims jwt.MapClaims, userID string) []string {
+	paths := m.config.Supabase.Auth.RoleClaims
+	if len(paths) == 0 {
+		paths = defaultRoleClaims
+	}
+	var roles []string
+	for _, path := range paths {
+		roles = append(roles, claimValues(claims, path)...)
+	}
+	if slices.Contains(m.config.Supabase.Auth.Admins, userID) {
+		roles = append(roles, constants.RoleAdmin)
+	}
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// claimValues returns the strings of the claim at given path, which separates the nested claims by dots.
+// The claim may be a single string or a list of strings; anything else is ignored.
+func claimValues(claims map[string]any, path string) []string {
+	var value any = claims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if value, ok = object[key]; !ok {
+			return nil
+		}
+	}
+	switch value := value.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
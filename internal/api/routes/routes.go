@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/AnhCaooo/electric-notifications/internal/api/handlers"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
 )
 
 // Endpoint is the presentation of object which contains values for routing
@@ -12,6 +14,11 @@ type Endpoint struct {
 	Path    string
 	Handler http.HandlerFunc
 	Method  string
+	// Roles of which the caller needs any to use the endpoint (ex: admin). Empty allows every authenticated caller.
+	Roles []string
+	// Scopes of which a service needs any to use the endpoint with an API key or a client certificate (ex: notifications:send).
+	// Empty forbids services.
+	Scopes []string
 }
 
 func InitializeEndpoints(handler *handlers.Handler) []Endpoint {
@@ -27,8 +34,164 @@ func InitializeEndpoints(handler *handlers.Handler) []Endpoint {
 			Method:  "POST",
 		}, {
 			Path:    "/v1/notifications",
-			Handler: handler.SendNotifications,
+			Handler: handler.Idempotent(handler.SendNotifications),
 			Method:  "POST",
+			Scopes:  []string{models.ScopeNotificationsSend},
+		}, {
+			Path:    "/v1/notifications/scheduled/{id}",
+			Handler: handler.GetScheduledNotification,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/notifications/scheduled/{id}",
+			Handler: handler.CancelScheduledNotification,
+			Method:  "DELETE",
+		}, {
+			Path:    "/v1/deliveries",
+			Handler: handler.GetDeliveries,
+			Method:  "GET",
+			Scopes:  []string{models.ScopeDeliveriesRead},
+		}, {
+			Path:    "/v1/inbox",
+			Handler: handler.GetInbox,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/inbox/unread",
+			Handler: handler.GetUnreadCount,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/inbox/read",
+			Handler: handler.MarkInboxRead,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/inbox/{id}/read",
+			Handler: handler.MarkInboxItemRead,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/prices/{date}/chart.png",
+			Handler: handler.GetPriceChart,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/reminders",
+			Handler: handler.CreateReminder,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/reminders",
+			Handler: handler.GetReminders,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/reminders/{id}",
+			Handler: handler.DeleteReminder,
+			Method:  "DELETE",
+		}, {
+			Path:    "/v1/appliances",
+			Handler: handler.CreateAppliance,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/appliances",
+			Handler: handler.GetAppliances,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/appliances/{id}",
+			Handler: handler.DeleteAppliance,
+			Method:  "DELETE",
+		}, {
+			Path:    "/v1/recommendations",
+			Handler: handler.GetRecommendations,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/planner/ev",
+			Handler: handler.PlanCharging,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/contract",
+			Handler: handler.PutContract,
+			Method:  "PUT",
+		}, {
+			Path:    "/v1/contract",
+			Handler: handler.GetContract,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/contract",
+			Handler: handler.DeleteContract,
+			Method:  "DELETE",
+		}, {
+			Path:    "/v1/prices/{date}",
+			Handler: handler.GetPrices,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/preferences",
+			Handler: handler.GetPreferences,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/preferences/channels",
+			Handler: handler.PutChannels,
+			Method:  "PUT",
+		}, {
+			Path:    "/v1/preferences/categories",
+			Handler: handler.PutCategories,
+			Method:  "PUT",
+		}, {
+			Path:    "/v1/preferences/email",
+			Handler: handler.PutEmail,
+			Method:  "PUT",
+		}, {
+			Path:    "/v1/preferences/email/verify",
+			Handler: handler.VerifyEmail,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/webhooks",
+			Handler: handler.CreateWebhook,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/webhooks",
+			Handler: handler.GetWebhooks,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/webhooks/{id}",
+			Handler: handler.DeleteWebhook,
+			Method:  "DELETE",
+		}, {
+			Path:    "/v1/webpush/key",
+			Handler: handler.GetVAPIDKey,
+			Method:  "GET",
+		}, {
+			Path:    "/v1/webpush/subscriptions",
+			Handler: handler.CreateWebPushSubscription,
+			Method:  "POST",
+		}, {
+			Path:    "/v1/webpush/subscriptions",
+			Handler: handler.DeleteWebPushSubscription,
+			Method:  "DELETE",
+		}, {
+			Path:    "/v1/admin/broadcasts/preview",
+			Handler: handler.PreviewBroadcast,
+			Method:  "POST",
+			Roles:   []string{constants.RoleAdmin},
+		}, {
+			Path:    "/v1/admin/broadcasts",
+			Handler: handler.CreateBroadcast,
+			Method:  "POST",
+			Roles:   []string{constants.RoleAdmin},
+		}, {
+			Path:    "/v1/admin/broadcasts/{id}",
+			Handler: handler.GetBroadcast,
+			Method:  "GET",
+			Roles:   []string{constants.RoleAdmin},
+		}, {
+			Path:    "/v1/admin/apikeys",
+			Handler: handler.CreateAPIKey,
+			Method:  "POST",
+			Roles:   []string{constants.RoleAdmin},
+		}, {
+			Path:    "/v1/admin/apikeys",
+			Handler: handler.GetAPIKeys,
+			Method:  "GET",
+			Roles:   []string{constants.RoleAdmin},
+		}, {
+			Path:    "/v1/admin/apikeys/{id}",
+			Handler: handler.RevokeAPIKey,
+			Method:  "DELETE",
+			Roles:   []string{constants.RoleAdmin},
 		},
 	}
 }
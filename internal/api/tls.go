@@ -0,0 +1,156 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+const defaultTLSReloadInterval = 30 * time.Second
+
+// tlsVersions are the TLS versions which may be configured as the lowest accepted version
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certificateReloader provides the certificate of the server and the authorities of the client certificates,
+// and loads them again when their files change, so a renewed certificate is used without restarting the server.
+// The files are checked during the TLS handshakes, at most once per reload interval.
+type certificateReloader struct {
+	config   *models.TLS
+	logger   *zap.Logger
+	interval time.Duration
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	modTimes    map[string]time.Time
+	checkedAt   time.Time
+}
+
+// newTLSConfig creates the TLS configuration of the server. It fails if the files can not be loaded.
+func newTLSConfig(config *models.TLS, logger *zap.Logger) (*tls.Config, error) {
+	minVersion := uint16(tls.VersionTLS12)
+	if config.MinVersion != "" {
+		version, ok := tlsVersions[config.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS min_version '%s', expected 1.2 or 1.3", config.MinVersion)
+		}
+		minVersion = version
+	}
+	clientAuth := tls.NoClientCert
+	if config.ClientCAFile != "" {
+		switch config.ClientAuth {
+		case "", "optional":
+			clientAuth = tls.VerifyClientCertIfGiven
+		case "require":
+			clientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return nil, fmt.Errorf("unsupported TLS client_auth '%s', expected optional or require", config.ClientAuth)
+		}
+	}
+
+	reloader := &certificateReloader{
+		config:   config,
+		logger:   logger,
+		interval: config.ReloadInterval,
+	}
+	if reloader.interval <= 0 {
+		reloader.interval = defaultTLSReloadInterval
+	}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: minVersion,
+		// the server only starts with a certificate, which older Go versions only find here and not in GetConfigForClient
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			certificate, _ := reloader.current()
+			return certificate, nil
+		},
+		// every handshake gets the current certificate and client authorities
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			certificate, clientCAs := reloader.current()
+			return &tls.Config{
+				MinVersion:   minVersion,
+				Certificates: []tls.Certificate{*certificate},
+				ClientAuth:   clientAuth,
+				ClientCAs:    clientCAs,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}, nil
+}
+
+// current returns the certificate and the client authorities, after loading them again if their files changed.
+// If the changed files can not be loaded (ex: the key is not written yet), the previous ones are kept.
+func (c *certificateReloader) current() (*tls.Certificate, *x509.CertPool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checkedAt) >= c.interval {
+		c.checkedAt = time.Now()
+		if c.changed() {
+			if err := c.load(); err != nil {
+				c.logger.Error("failed to reload TLS certificate, keep using the previous one", zap.Error(err))
+			} else {
+				c.logger.Info("TLS certificate reloaded", zap.String("cert_file", c.config.CertFile))
+			}
+		}
+	}
+	return c.certificate, c.clientCAs
+}
+
+// changed reports whether any of the files was modified since it was loaded
+func (c *certificateReloader) changed() bool {
+	for file, modTime := range c.modTimes {
+		info, err := os.Stat(file)
+		if err == nil && !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the certificate, the private key and the client authorities from their files
+func (c *certificateReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range []string{c.config.CertFile, c.config.KeyFile, c.config.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS file: %s", err.Error())
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.config.CertFile, c.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %s", err.Error())
+	}
+	var clientCAs *x509.CertPool
+	if c.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS client CA file: %s", err.Error())
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("TLS client CA file has no certificates")
+		}
+	}
+
+	c.certificate, c.clientCAs, c.modTimes = &certificate, clientCAs, modTimes
+	return nil
+}
@@ -0,0 +1,138 @@
+// AnhCao 2024
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// writeCertificate writes a self-signed certificate with given common name and its key into the files
+func writeCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, block := range map[string]*pem.Block{certFile: {Type: "CERTIFICATE", Bytes: der}, keyFile: {Type: "EC PRIVATE KEY", Bytes: keyDER}} {
+		if err = os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// the modification time is set explicitly, as the clock of the file system may be coarse
+		if err = os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTLSConfigServesAndReloadsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	config := &models.TLS{
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		ReloadInterval: 10 * time.Millisecond,
+	}
+	writeCertificate(t, config.CertFile, config.KeyFile, "first", time.Now().Add(-time.Minute))
+
+	tlsConfig, err := newTLSConfig(config, zap.NewNop())
+	if err != nil {
+		t.Fatalf("newTLSConfig() error = %v", err)
+	}
+	if certificate, err := tlsConfig.GetCertificate(&tls.ClientHelloInfo{}); err != nil || certificate == nil {
+		t.Fatalf("GetCertificate() = %v, %v, want the certificate", certificate, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: tlsConfig,
+	}
+	served := make(chan error, 1)
+	// the certificate files are not given, like in Start
+	go func() { served <- server.ServeTLS(listener, "", "") }()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DisableKeepAlives: true,
+	}}
+	commonName := func() string {
+		t.Helper()
+		resp, err := client.Get("https://" + listener.Addr().String())
+		if err != nil {
+			select {
+			case serveErr := <-served:
+				t.Fatalf("server stopped: %v", serveErr)
+			default:
+			}
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if got := commonName(); got != "first" {
+		t.Fatalf("served certificate %q, want %q", got, "first")
+	}
+	writeCertificate(t, config.CertFile, config.KeyFile, "second", time.Now())
+	time.Sleep(2 * config.ReloadInterval)
+	if got := commonName(); got != "second" {
+		t.Errorf("served certificate %q after renewal, want %q", got, "second")
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeCertificate(t, certFile, keyFile, "server", time.Now())
+
+	tests := []struct {
+		name   string
+		config models.TLS
+	}{
+		{name: "missing files", config: models.TLS{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile}},
+		{name: "unsupported min version", config: models.TLS{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.1"}},
+		{name: "unsupported client auth", config: models.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, ClientAuth: "always"}},
+		{name: "client CA file without certificates", config: models.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTLSConfig(&tt.config, zap.NewNop()); err == nil {
+				t.Errorf("newTLSConfig() succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// AnhCao 2024
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// apiKeyPrefix tells the API keys of the service apart from other secrets, ex: in secret scanners
+	apiKeyPrefix = "en_"
+	apiKeyBytes  = 32
+	// apiKeyVisibleLength is how many characters of the key are stored, so the key can be recognized
+	apiKeyVisibleLength = len(apiKeyPrefix) + 8
+)
+
+// GenerateAPIKey creates a new random API key and returns it together with its visible beginning and its hash,
+// which are stored instead of the key
+func GenerateAPIKey() (key string, prefix string, hash string, err error) {
+	secret := make([]byte, apiKeyBytes)
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %s", err.Error())
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:apiKeyVisibleLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash under which the API key is stored. The keys are random,
+// so a fast hash is enough to make the stored hashes useless for calling the API.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,216 @@
+// AnhCao 2024
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefresh = time.Hour
+	// minJWKSRefresh limits how often tokens with unknown key IDs may refresh the keys
+	minJWKSRefresh = time.Minute
+	jwksTimeout    = 10 * time.Second
+	maxJWKSSize    = 1 << 20
+)
+
+// jwk represents a public key of a JWKS document (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet provides the public keys of a JWKS document, which is loaded from a URL or a local file.
+// The keys are cached and refreshed periodically, and right away when a key ID is unknown,
+// so the keys can be rotated by the issuer without restarting the service.
+type KeySet struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]any
+	loadedAt    time.Time
+	attemptedAt time.Time
+}
+
+// NewKeySet creates a new KeySet of the JWKS document at given URL, or in given file if the file is set.
+// The document is loaded when a key is requested for the first time.
+func NewKeySet(url string, file string, refresh time.Duration) *KeySet {
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+	return &KeySet{
+		url:     url,
+		file:    file,
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksTimeout},
+	}
+}
+
+// Key returns the public key (*rsa.PublicKey or *ecdsa.PublicKey) with given key ID.
+// Without a key ID, the only key of the document is returned.
+func (ks *KeySet) Key(kid string) (any, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	now := time.Now()
+	_, known := ks.keys[kid]
+	if kid == "" {
+		known = len(ks.keys) == 1
+	}
+	stale := now.Sub(ks.loadedAt) > ks.refresh
+	if (stale || !known) && now.Sub(ks.attemptedAt) > minJWKSRefresh {
+		ks.attemptedAt = now
+		keys, err := ks.load()
+		// the cached keys keep working if the issuer is temporarily unavailable
+		if err != nil && ks.keys == nil {
+			return nil, err
+		}
+		if err == nil {
+			ks.keys, ks.loadedAt = keys, now
+		}
+	}
+
+	if kid == "" {
+		if len(ks.keys) != 1 {
+			return nil, fmt.Errorf("token has no key ID")
+		}
+		for _, key := range ks.keys {
+			return key, nil
+		}
+	}
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID '%s'", kid)
+	}
+	return key, nil
+}
+
+// load reads and parses the JWKS document
+func (ks *KeySet) load() (map[string]any, error) {
+	var document []byte
+	var err error
+	if ks.file != "" {
+		if document, err = os.ReadFile(ks.file); err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %s", err.Error())
+		}
+	} else {
+		if document, err = ks.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	return parseJWKS(document)
+}
+
+// fetch downloads the JWKS document from the URL
+func (ks *KeySet) fetch() ([]byte, error) {
+	if ks.url == "" {
+		return nil, fmt.Errorf("JWKS is not configured")
+	}
+	res, err := ks.client.Get(ks.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", res.StatusCode)
+	}
+	document, err := io.ReadAll(io.LimitReader(res.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %s", err.Error())
+	}
+	return document, nil
+}
+
+// parseJWKS parses the RSA and P-256 EC signing keys of a JWKS document. Other keys are skipped.
+func parseJWKS(document []byte) (map[string]any, error) {
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(document, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %s", err.Error())
+	}
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		var publicKey any
+		var err error
+		switch key.Kty {
+		case "RSA":
+			publicKey, err = key.rsa()
+		case "EC":
+			publicKey, err = key.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s' in JWKS: %s", key.Kid, err.Error())
+		}
+		keys[key.Kid] = publicKey
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+func (key jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("unsupported RSA key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (key jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	if key.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve '%s'", key.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(key.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(key.Y)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, fmt.Errorf("invalid P-256 coordinates")
+	}
+	// validates that the point is on the curve
+	if _, err = ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
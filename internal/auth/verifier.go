@@ -0,0 +1,83 @@
+// AnhCao 2024
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+const defaultClockSkew = 30 * time.Second
+
+// Verifier validates the access tokens of the requests. HS256 tokens are verified with the shared secret,
+// RS256 and ES256 tokens with the public keys of the JWKS document.
+type Verifier struct {
+	secret []byte
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewVerifier creates a new Verifier from the authentication configuration.
+// Only the algorithms which have a configured key are accepted.
+func NewVerifier(config *models.Config) *Verifier {
+	cfg := config.Supabase.Auth
+	v := &Verifier{}
+	var methods []string
+	if cfg.JwtSecret != "" {
+		v.secret = []byte(cfg.JwtSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWKSURL != "" || cfg.JWKSFile != "" {
+		v.keys = NewKeySet(cfg.JWKSURL, cfg.JWKSFile, cfg.JWKSRefresh)
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+
+	clockSkew := cfg.ClockSkew
+	if clockSkew <= 0 {
+		clockSkew = defaultClockSkew
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(options...)
+	return v
+}
+
+// Verify validates the signature and the `exp`, `nbf`, `iat`, `iss` and `aud` claims of the token and returns its claims
+func (v *Verifier) Verify(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.key); err != nil {
+		return nil, fmt.Errorf("invalid token: %s", err.Error())
+	}
+	return claims, nil
+}
+
+// key returns the key which verifies the signature of the token
+func (v *Verifier) key(token *jwt.Token) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.secret == nil {
+			return nil, fmt.Errorf("HS256 tokens are not accepted")
+		}
+		return v.secret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if v.keys == nil {
+			return nil, fmt.Errorf("%s tokens are not accepted", token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,162 @@
+// AnhCao 2024
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+const (
+	testIssuer   = "https://project.supabase.co/auth/v1"
+	testAudience = "authenticated"
+	testSecret   = "shared-secret-of-the-hs256-tokens"
+)
+
+// writeJWKS writes a JWKS document with the public keys under their key IDs and returns its path
+func writeJWKS(t *testing.T, keys map[string]any) string {
+	t.Helper()
+	encode := func(value *big.Int) string { return base64.RawURLEncoding.EncodeToString(value.Bytes()) }
+	document := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			document.Keys = append(document.Keys, jwk{Kty: "RSA", Kid: kid, Use: "sig", N: encode(key.N), E: encode(big.NewInt(int64(key.E)))})
+		case *ecdsa.PublicKey:
+			document.Keys = append(document.Keys, jwk{Kty: "EC", Kid: kid, Use: "sig", Crv: "P-256", X: encode(key.X), Y: encode(key.Y)})
+		}
+	}
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := writeJWKS(t, map[string]any{"rsa-1": &rsaKey.PublicKey, "ec-1": &ecKey.PublicKey})
+	// the public key as a confused verifier would use it as the HMAC secret
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	now := time.Now()
+	claims := func(changes jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "user-1",
+			"iss": testIssuer,
+			"aud": testAudience,
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for key, value := range changes {
+			if value == nil {
+				delete(c, key)
+			} else {
+				c[key] = value
+			}
+		}
+		return c
+	}
+	noneToken := func() string {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil))
+		signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid RS256", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(nil), rsaKey)},
+		{name: "valid ES256", token: sign(t, jwt.SigningMethodES256, "ec-1", claims(nil), ecKey)},
+		{name: "valid HS256", secret: testSecret, token: sign(t, jwt.SigningMethodHS256, "", claims(nil), []byte(testSecret))},
+		{name: "audience list", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"aud": []string{"other", testAudience}}), rsaKey)},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"iss": "https://other.supabase.co/auth/v1"}), rsaKey), wantErr: true},
+		{name: "missing issuer", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"iss": nil}), rsaKey), wantErr: true},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"aud": "anon"}), rsaKey), wantErr: true},
+		{name: "missing audience", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"aud": nil}), rsaKey), wantErr: true},
+		{name: "expired", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}), rsaKey), wantErr: true},
+		{name: "expired within clock skew", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}), rsaKey)},
+		{name: "missing expiry", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"exp": nil}), rsaKey), wantErr: true},
+		{name: "not valid yet", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"nbf": now.Add(time.Hour).Unix()}), rsaKey), wantErr: true},
+		{name: "issued in the future", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(jwt.MapClaims{"iat": now.Add(time.Hour).Unix()}), rsaKey), wantErr: true},
+		{name: "alg none", token: noneToken(), wantErr: true},
+		{name: "HS256 signed with the PEM public key", token: sign(t, jwt.SigningMethodHS256, "rsa-1", claims(nil), publicPEM), wantErr: true},
+		{name: "HS256 signed with the DER public key", token: sign(t, jwt.SigningMethodHS256, "rsa-1", claims(nil), publicDER), wantErr: true},
+		{name: "HS256 with another secret", secret: testSecret, token: sign(t, jwt.SigningMethodHS256, "", claims(nil), publicPEM), wantErr: true},
+		{name: "unknown key ID", token: sign(t, jwt.SigningMethodRS256, "rsa-2", claims(nil), rsaKey), wantErr: true},
+		{name: "known key ID with another key", token: sign(t, jwt.SigningMethodRS256, "rsa-1", claims(nil), otherKey), wantErr: true},
+		{name: "key ID of another algorithm", token: sign(t, jwt.SigningMethodRS256, "ec-1", claims(nil), rsaKey), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.Config{}
+			config.Supabase.Auth.JWKSFile = jwksFile
+			config.Supabase.Auth.Issuer = testIssuer
+			config.Supabase.Auth.Audience = testAudience
+			config.Supabase.Auth.JwtSecret = tt.secret
+			verifier := NewVerifier(config)
+
+			got, err := verifier.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got["sub"] != "user-1" {
+				t.Errorf("Verify() sub = %v, want user-1", got["sub"])
+			}
+		})
+	}
+}
@@ -76,7 +76,7 @@ func (c *Cache) Get(key string) (interface{}, bool) {
 			zap.Time("expiration-time-in-utc-zone", value.Expiration),
 			zap.Time("current-time-in-utc-zone", time.Now()),
 		)
-		c.Delete(key)
+		delete(c.Data, key)
 		return nil, false
 	}
 	c.logger.Debug("cache living time",
@@ -88,5 +88,8 @@ func (c *Cache) Get(key string) (interface{}, bool) {
 
 // Delete cache based on receiving cache key. If key is not valid, then Delete is no-op
 func (c *Cache) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	delete(c.Data, key)
 }
@@ -0,0 +1,64 @@
+// AnhCao 2024
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name string
+		// change of the cache after the value "chart" was cached under "key" for an hour
+		change    func(c *Cache)
+		wantValue interface{}
+		wantFound bool
+	}{
+		{
+			name:      "cached value",
+			change:    func(c *Cache) {},
+			wantValue: "chart",
+			wantFound: true,
+		},
+		{
+			name:   "deleted value",
+			change: func(c *Cache) { c.Delete("key") },
+		},
+		{
+			name:      "replaced value",
+			change:    func(c *Cache) { c.SetExpiredAfterTimePeriod("key", "new chart", time.Hour) },
+			wantValue: "new chart",
+			wantFound: true,
+		},
+		{
+			name:   "expired value",
+			change: func(c *Cache) { c.SetExpiredAtTime("key", "new chart", time.Now().Add(-time.Second)) },
+		},
+		{
+			name:      "other key deleted",
+			change:    func(c *Cache) { c.Delete("other") },
+			wantValue: "chart",
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(zap.NewNop())
+			c.SetExpiredAfterTimePeriod("key", "chart", time.Hour)
+
+			tt.change(c)
+
+			value, found := c.Get("key")
+			if found != tt.wantFound || value != tt.wantValue {
+				t.Errorf("Get() = %v, %v, want %v, %v", value, found, tt.wantValue, tt.wantFound)
+			}
+			if !found {
+				if _, exists := c.Data["key"]; exists {
+					t.Error("Get() kept the value which is not valid anymore")
+				}
+			}
+		})
+	}
+}
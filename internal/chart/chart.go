@@ -0,0 +1,115 @@
+// AnhCao 2024
+//
+// Package chart renders electric price series into images that can be attached to notifications.
+// It relies only on the standard library `image` packages, so there is no font rendering:
+// the chart consists of one bar per price entry, a zero line and horizontal grid lines.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+const (
+	// FCM recommends images with 2:1 aspect ratio for expanded notifications
+	width   int = 1024
+	height  int = 512
+	padding int = 32
+	// number of horizontal grid lines drawn above the zero line
+	gridLines int = 4
+)
+
+var (
+	backgroundColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	gridColor       = color.RGBA{R: 224, G: 224, B: 224, A: 255}
+	axisColor       = color.RGBA{R: 96, G: 96, B: 96, A: 255}
+	// cheapColor is used for the hours that are cheaper than the average price of the day
+	cheapColor = color.RGBA{R: 46, G: 160, B: 67, A: 255}
+	// normalColor is used for the rest of the hours
+	normalColor = color.RGBA{R: 94, G: 129, B: 172, A: 255}
+)
+
+// RenderPNG draws a bar chart of given price series and returns it encoded as PNG.
+// Hours which price is below the average price of the series are highlighted as cheap hours.
+func RenderPNG(series models.PriceSeries) ([]byte, error) {
+	if len(series.Data) == 0 {
+		return nil, fmt.Errorf("price series is empty")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: backgroundColor}, image.Point{}, draw.Src)
+
+	minPrice, maxPrice, average := priceRange(series.Data)
+	// always keep zero inside of the drawn range so the bars have a common baseline
+	minPrice = math.Min(minPrice, 0)
+	maxPrice = math.Max(maxPrice, 0)
+	if maxPrice == minPrice {
+		maxPrice = minPrice + 1
+	}
+
+	plotTop, plotBottom := padding, height-padding
+	plotLeft, plotRight := padding, width-padding
+	// toY converts a price into the vertical pixel position
+	toY := func(price float64) int {
+		ratio := (price - minPrice) / (maxPrice - minPrice)
+		return plotBottom - int(math.Round(ratio*float64(plotBottom-plotTop)))
+	}
+	zeroY := toY(0)
+
+	// grid lines
+	for i := 1; i <= gridLines; i++ {
+		y := zeroY - (zeroY-plotTop)*i/gridLines
+		fillRect(img, plotLeft, y, plotRight, y+1, gridColor)
+	}
+
+	// bars
+	slot := float64(plotRight-plotLeft) / float64(len(series.Data))
+	gap := int(math.Max(1, slot/8))
+	for idx, data := range series.Data {
+		left := plotLeft + int(float64(idx)*slot) + gap
+		right := plotLeft + int(float64(idx+1)*slot) - gap
+		barColor := normalColor
+		if data.Price < average {
+			barColor = cheapColor
+		}
+		top, bottom := toY(data.Price), zeroY
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+		fillRect(img, left, top, right, bottom, barColor)
+	}
+
+	// axes
+	fillRect(img, plotLeft, zeroY, plotRight, zeroY+2, axisColor)
+	fillRect(img, plotLeft, plotTop, plotLeft+2, plotBottom, axisColor)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// priceRange returns the minimum, maximum and average price of given data
+func priceRange(data []models.Data) (minPrice, maxPrice, average float64) {
+	minPrice, maxPrice = math.Inf(1), math.Inf(-1)
+	var sum float64
+	for _, d := range data {
+		minPrice = math.Min(minPrice, d.Price)
+		maxPrice = math.Max(maxPrice, d.Price)
+		sum += d.Price
+	}
+	return minPrice, maxPrice, sum / float64(len(data))
+}
+
+// fillRect fills the rectangle (x0, y0) - (x1, y1) of the image with given color
+func fillRect(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
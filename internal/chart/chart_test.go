@@ -0,0 +1,99 @@
+// AnhCao 2024
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// series returns a price series with given prices
+func series(prices ...float64) models.PriceSeries {
+	series := models.PriceSeries{Name: "c/kWh"}
+	for _, price := range prices {
+		series.Data = append(series.Data, models.Data{Price: price})
+	}
+	return series
+}
+
+// barColors returns the colors of the bars which are drawn in the middle column of the bar of given entry
+func barColors(img image.Image, entries int, idx int) map[color.RGBA]bool {
+	slot := float64(width-2*padding) / float64(entries)
+	x := padding + int((float64(idx)+0.5)*slot)
+	colors := make(map[color.RGBA]bool)
+	for y := padding; y < height-padding; y++ {
+		r, g, b, a := img.At(x, y).RGBA()
+		c := color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+		if c == cheapColor || c == normalColor {
+			colors[c] = true
+		}
+	}
+	return colors
+}
+
+func TestRenderPNG(t *testing.T) {
+	tests := []struct {
+		name   string
+		series models.PriceSeries
+		// the entries which are drawn as cheap hours, the rest are drawn as normal hours
+		wantCheap []int
+		wantErr   bool
+	}{
+		{
+			name:      "cheap hours below the average",
+			series:    series(1, 10, 2, 11),
+			wantCheap: []int{0, 2},
+		},
+		{
+			name:      "negative prices",
+			series:    series(-2, 5, 6, 7),
+			wantCheap: []int{0},
+		},
+		{
+			name:   "equal prices",
+			series: series(3, 3, 3),
+		},
+		{
+			name:    "empty series",
+			series:  series(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := RenderPNG(tt.series)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderPNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			img, err := png.Decode(bytes.NewReader(encoded))
+			if err != nil {
+				t.Fatalf("RenderPNG() is not a PNG image: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != width || size.Y != height {
+				t.Fatalf("RenderPNG() size = %v, want %dx%d", size, width, height)
+			}
+
+			cheap := make(map[int]bool)
+			for _, idx := range tt.wantCheap {
+				cheap[idx] = true
+			}
+			for idx := range tt.series.Data {
+				want := normalColor
+				if cheap[idx] {
+					want = cheapColor
+				}
+				if colors := barColors(img, len(tt.series.Data), idx); len(colors) != 1 || !colors[want] {
+					t.Errorf("bar %d has colors %v, want %v", idx, colors, want)
+				}
+			}
+		})
+	}
+}
@@ -50,6 +50,9 @@ func ReadFile(cfg *models.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to decode config.yml: %s", err.Error())
 	}
+	if err = cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config.yml: %s", err.Error())
+	}
 	return nil
 }
 
@@ -12,4 +12,41 @@ const (
 	FirebaseKeyEncryptedFile string = "/internal/config/firebaseKey.enc.json"
 	FirebaseKeyDecryptedFile string = "/internal/config/firebaseKey.dec.json"
 	CryptoKeyFile            string = "/internal/config/key.txt"
+	PricesCollection         string = "prices"
+	RemindersCollection      string = "reminders"
+	JobsCollection           string = "jobs"
+	AppliancesCollection     string = "appliances"
+	ContractsCollection      string = "contracts"
+	PreferencesCollection    string = "preferences"
+	WebhooksCollection       string = "webhooks"
+	DeliveriesCollection     string = "deliveries"
+	InboxCollection          string = "inbox"
+	IdempotencyCollection    string = "idempotency_keys"
+	BroadcastsCollection     string = "broadcasts"
+	APIKeysCollection        string = "api_keys"
+	DateLayout               string = "2006-01-02"
+	PriceTimeLayout          string = "2006-01-02 15:04:05"
+	// time zone of the electricity market, the prices are published and stored by its dates
+	MarketTimeZone string = "Europe/Helsinki"
+)
+
+// Types of the notifications that the service sends by itself. The type is delivered to the app
+// in the data payload under the key `DataKeyType`.
+const (
+	DataKeyType                  string = "type"
+	NotificationTypeDailyPrices  string = "daily_prices"
+	NotificationTypeReminder     string = "reminder"
+	NotificationTypeChargingPlan string = "charging_plan"
+)
+
+// Roles which the endpoints may require from the caller, in addition to being authenticated
+const (
+	RoleAdmin   string = "admin"
+	RoleService string = "service"
+)
+
+// Keys of the data payload which tell the app where the notification is in the inbox and how many notifications are unread
+const (
+	DataKeyInboxId string = "inbox_id"
+	DataKeyBadge   string = "badge"
 )
@@ -3,5 +3,14 @@ package constants
 type contextKey string
 
 const (
-	UserIdKey contextKey = "USER_ID" // Key type for storing userID in context
+	UserIdKey     contextKey = "USER_ID"     // Key type for storing userID in context
+	DryRunKey     contextKey = "DRY_RUN"     // Key type for marking that notifications are only validated, not delivered
+	SourceKey     contextKey = "SOURCE"      // Key type for storing where notifications came from (ex: api)
+	RolesKey      contextKey = "ROLES"       // Key type for storing the roles of the authenticated caller
+	ServiceKey    contextKey = "SERVICE"     // Key type for storing the name of the service which authenticated with an API key or a client certificate
+	ScopesKey     contextKey = "SCOPES"      // Key type for storing the scopes of the API key of the service
+	ClientCertKey contextKey = "CLIENT_CERT" // Key type for storing the identity of the verified client certificate
+
+	NotificationIdKey contextKey = "NOTIFICATION_ID" // Key type for storing the ID of the notification being sent
+	SegmentKey        contextKey = "SEGMENT"         // Key type for storing the segment which a broadcast is sent to
 )
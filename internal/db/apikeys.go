@@ -0,0 +1,95 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// apiKeyUsageInterval is how often the last usage time of an API key is updated, so not every request writes to the database
+const apiKeyUsageInterval = time.Minute
+
+// createAPIKeysIndex creates a unique index on the "hash" field of the API keys, with which the keys are looked up
+func (db Mongo) createAPIKeysIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.M{"hash": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo API keys index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertAPIKey inserts a new API key and returns it with generated ID and creation time
+func (db Mongo) InsertAPIKey(key models.APIKey) (*models.APIKey, error) {
+	key.ID = bson.NewObjectID()
+	key.CreatedAt = time.Now().UTC()
+	if _, err := db.apiKeys.InsertOne(db.ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to insert API key: %s", err.Error())
+	}
+	return &key, nil
+}
+
+// GetAPIKeys retrieves all API keys, including the revoked ones, the latest first
+func (db Mongo) GetAPIKeys() ([]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := db.apiKeys.Find(db.ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API keys: %s", err.Error())
+	}
+	keys := []models.APIKey{}
+	if err = cursor.All(db.ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %s", err.Error())
+	}
+	return keys, nil
+}
+
+// GetActiveAPIKey retrieves the API key with given hash, unless it has been revoked.
+// It returns nil without an error if there is no such active key.
+func (db Mongo) GetActiveAPIKey(hash string) (*models.APIKey, error) {
+	filter := bson.D{{Key: "hash", Value: hash}, {Key: "revokedAt", Value: bson.M{"$exists": false}}}
+	var key models.APIKey
+	if err := db.apiKeys.FindOne(db.ctx, filter).Decode(&key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find API key: %s", err.Error())
+	}
+	return &key, nil
+}
+
+// TouchAPIKey records that the API key was used at given time. The time is only updated once per minute.
+func (db Mongo) TouchAPIKey(id bson.ObjectID, usedAt time.Time) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "$or", Value: bson.A{
+			bson.M{"lastUsedAt": bson.M{"$exists": false}},
+			bson.M{"lastUsedAt": bson.M{"$lt": usedAt.Add(-apiKeyUsageInterval)}},
+		}},
+	}
+	if _, err := db.apiKeys.UpdateOne(db.ctx, filter, bson.M{"$set": bson.M{"lastUsedAt": usedAt}}); err != nil {
+		return fmt.Errorf("failed to update usage of API key: %s", err.Error())
+	}
+	return nil
+}
+
+// RevokeAPIKey revokes the API key, so it is not accepted anymore.
+// It returns mongo.ErrNoDocuments if there is no active API key with given ID.
+func (db Mongo) RevokeAPIKey(id bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "revokedAt", Value: bson.M{"$exists": false}}}
+	result, err := db.apiKeys.UpdateOne(db.ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now().UTC()}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %s", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// AnhCao 2024
+package db
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+)
+
+func TestGetActiveAPIKey(t *testing.T) {
+	tests := []struct {
+		name string
+		// the active keys of the hash
+		stored   []any
+		wantName string
+	}{
+		{
+			name:     "active key",
+			stored:   []any{bson.D{{Key: "_id", Value: bson.NewObjectID()}, {Key: "name", Value: "billing"}, {Key: "hash", Value: "hash"}}},
+			wantName: "billing",
+		},
+		{
+			name: "unknown or revoked key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, server := newTestMongo(t, func(command bson.M) bson.D {
+				return dbtest.Cursor(tt.stored...)
+			})
+
+			key, err := db.GetActiveAPIKey("hash")
+			if err != nil {
+				t.Fatalf("GetActiveAPIKey() error = %v", err)
+			}
+			if (key == nil) != (tt.wantName == "") || (key != nil && key.Name != tt.wantName) {
+				t.Errorf("GetActiveAPIKey() = %+v, want key of %q", key, tt.wantName)
+			}
+
+			commands := server.Commands("find")
+			if len(commands) != 1 {
+				t.Fatalf("sent %d finds, want 1", len(commands))
+			}
+			filter := commands[0]["filter"].(bson.M)
+			if filter["hash"] != "hash" || filter["revokedAt"].(bson.M)["$exists"] != false {
+				t.Errorf("filter = %v, want the key of the hash which is not revoked", filter)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		matched int
+		wantErr error
+	}{
+		{
+			name:    "active key is revoked",
+			matched: 1,
+		},
+		{
+			name:    "unknown or already revoked key",
+			matched: 0,
+			wantErr: mongo.ErrNoDocuments,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, server := newTestMongo(t, func(command bson.M) bson.D {
+				return dbtest.Written(tt.matched)
+			})
+			id := bson.NewObjectID()
+
+			if err := db.RevokeAPIKey(id); err != tt.wantErr {
+				t.Fatalf("RevokeAPIKey() error = %v, want %v", err, tt.wantErr)
+			}
+
+			commands := server.Commands("update")
+			if len(commands) != 1 {
+				t.Fatalf("sent %d updates, want 1", len(commands))
+			}
+			update := commands[0]["updates"].(bson.A)[0].(bson.M)
+			if update["q"].(bson.M)["_id"] != id || update["u"].(bson.M)["$set"].(bson.M)["revokedAt"] == nil {
+				t.Errorf("update = %v, want the revocation time of the key set", update)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// createAppliancesIndex creates an index on the "userId" field of the appliances collection
+func (db Mongo) createAppliancesIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys: bson.M{"userId": 1},
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo appliances index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertAppliance inserts a new appliance and returns it with generated ID and creation time
+func (db Mongo) InsertAppliance(appliance models.Appliance) (*models.Appliance, error) {
+	appliance.ID = bson.NewObjectID()
+	appliance.CreatedAt = time.Now().UTC()
+	if _, err := db.appliances.InsertOne(db.ctx, appliance); err != nil {
+		return nil, fmt.Errorf("failed to insert appliance: %s", err.Error())
+	}
+	return &appliance, nil
+}
+
+// GetAppliances retrieves all appliances of a user
+func (db Mongo) GetAppliances(userId string) ([]models.Appliance, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	cursor, err := db.appliances.Find(db.ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find appliances: %s", err.Error())
+	}
+	appliances := make([]models.Appliance, 0)
+	if err = cursor.All(db.ctx, &appliances); err != nil {
+		return nil, fmt.Errorf("failed to decode appliances: %s", err.Error())
+	}
+	return appliances, nil
+}
+
+// DeleteAppliance deletes an appliance of a user.
+// It returns `mongo.ErrNoDocuments` if the user does not have an appliance with given ID.
+func (db Mongo) DeleteAppliance(userId string, id bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "userId", Value: userId}}
+	res, err := db.appliances.DeleteOne(db.ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete appliance: %s", err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
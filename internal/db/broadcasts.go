@@ -0,0 +1,151 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// createBroadcastsIndex creates an index on the "createdAt" field of the broadcasts collection
+func (db Mongo) createBroadcastsIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys: bson.M{"createdAt": -1},
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo broadcasts index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertBroadcast inserts a new, pending broadcast and returns it with generated ID and creation time
+func (db Mongo) InsertBroadcast(broadcast models.Broadcast) (*models.Broadcast, error) {
+	broadcast.ID = bson.NewObjectID()
+	broadcast.Status = models.BroadcastPending
+	broadcast.CreatedAt = time.Now().UTC()
+	if _, err := db.broadcasts.InsertOne(db.ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("failed to insert broadcast: %s", err.Error())
+	}
+	return &broadcast, nil
+}
+
+// GetBroadcast retrieves a broadcast. It returns nil without an error if the broadcast does not exist.
+func (db Mongo) GetBroadcast(id bson.ObjectID) (*models.Broadcast, error) {
+	var broadcast models.Broadcast
+	if err := db.broadcasts.FindOne(db.ctx, bson.D{{Key: "_id", Value: id}}).Decode(&broadcast); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find broadcast: %s", err.Error())
+	}
+	return &broadcast, nil
+}
+
+// StartBroadcast marks the broadcast as running with given number of target users.
+// The start time is kept if the broadcast is resumed after an interruption.
+func (db Mongo) StartBroadcast(id bson.ObjectID, total int) error {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":    models.BroadcastRunning,
+			"total":     total,
+			"startedAt": bson.M{"$ifNull": bson.A{"$startedAt", time.Now().UTC()}},
+		}}},
+	}
+	if _, err := db.broadcasts.UpdateByID(db.ctx, id, update); err != nil {
+		return fmt.Errorf("failed to start broadcast: %s", err.Error())
+	}
+	return nil
+}
+
+// UpdateBroadcastProgress adds the outcome of sending the broadcast to more users, up to the last given user
+func (db Mongo) UpdateBroadcastProgress(id bson.ObjectID, lastUserId string, processed, success, failure int) error {
+	update := bson.M{
+		"$set": bson.M{"lastUserId": lastUserId},
+		"$inc": bson.M{"processed": processed, "success": success, "failure": failure},
+	}
+	if _, err := db.broadcasts.UpdateByID(db.ctx, id, update); err != nil {
+		return fmt.Errorf("failed to update progress of broadcast: %s", err.Error())
+	}
+	return nil
+}
+
+// FinishBroadcast marks the broadcast as done, or as failed with the error that stopped it
+func (db Mongo) FinishBroadcast(id bson.ObjectID, status models.BroadcastStatus, lastError string) error {
+	set := bson.M{"status": status, "completedAt": time.Now().UTC()}
+	if lastError != "" {
+		set["error"] = lastError
+	}
+	if _, err := db.broadcasts.UpdateByID(db.ctx, id, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to finish broadcast: %s", err.Error())
+	}
+	return nil
+}
+
+// GetSegmentUserIDs retrieves the users who have a device matching the filters of the segment and, if the segment has
+// a category, who opted in to the category. The users are sorted, so a broadcast can continue after the last processed user.
+func (db Mongo) GetSegmentUserIDs(segment models.Segment) ([]string, error) {
+	match := bson.M{}
+	filters := map[string][]string{
+		"platform":   segment.Platforms,
+		"locale":     segment.Locales,
+		"appVersion": segment.AppVersions,
+		"area":       segment.Areas,
+	}
+	for field, values := range filters {
+		if len(values) > 0 {
+			match[field] = bson.M{"$in": values}
+		}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$userId"}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	userIDs, err := db.aggregateIDs(db.collection, pipeline)
+	if err != nil || segment.Category == "" {
+		return userIDs, err
+	}
+
+	optedIn, err := db.aggregateIDs(db.preferences, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"categories": segment.Category}}},
+		{{Key: "$group", Value: bson.M{"_id": "$userId"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	inCategory := make(map[string]bool, len(optedIn))
+	for _, userId := range optedIn {
+		inCategory[userId] = true
+	}
+	segmentUserIDs := make([]string, 0, len(optedIn))
+	for _, userId := range userIDs {
+		if inCategory[userId] {
+			segmentUserIDs = append(segmentUserIDs, userId)
+		}
+	}
+	return segmentUserIDs, nil
+}
+
+// aggregateIDs runs the pipeline, which groups the documents by a string ID, and returns the IDs
+func (db Mongo) aggregateIDs(collection *mongo.Collection, pipeline mongo.Pipeline) ([]string, error) {
+	cursor, err := collection.Aggregate(db.ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate user IDs: %s", err.Error())
+	}
+	var results []struct {
+		ID string `bson:"_id"`
+	}
+	if err = cursor.All(db.ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode user IDs: %s", err.Error())
+	}
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return ids, nil
+}
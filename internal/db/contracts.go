@@ -0,0 +1,64 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// createContractsIndex creates a unique index on the "userId" field of the contracts collection,
+// so that every user has at most one contract
+func (db Mongo) createContractsIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.M{"userId": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo contracts index error: %s", err.Error())
+	}
+	return nil
+}
+
+// UpsertContract stores the contract of a user, replacing the previous one
+func (db Mongo) UpsertContract(contract models.Contract) (*models.Contract, error) {
+	contract.UpdatedAt = time.Now().UTC()
+	filter := bson.D{{Key: "userId", Value: contract.UserId}}
+	_, err := db.contracts.ReplaceOne(db.ctx, filter, contract, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert contract: %s", err.Error())
+	}
+	return &contract, nil
+}
+
+// GetContract retrieves the contract of a user. It returns nil without an error if the user has not configured a contract.
+func (db Mongo) GetContract(userId string) (*models.Contract, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	var contract models.Contract
+	if err := db.contracts.FindOne(db.ctx, filter).Decode(&contract); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get contract: %s", err.Error())
+	}
+	return &contract, nil
+}
+
+// DeleteContract deletes the contract of a user.
+// It returns `mongo.ErrNoDocuments` if the user has not configured a contract.
+func (db Mongo) DeleteContract(userId string) error {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	res, err := db.contracts.DeleteOne(db.ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete contract: %s", err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
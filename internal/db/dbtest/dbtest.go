@@ -0,0 +1,222 @@
+// AnhCao 2024
+//
+// Package dbtest provides a fake MongoDB server for the tests of the packages which use the database.
+// The server records every command sent to it and answers them with a reply function of the test,
+// so the tests can check the queries and updates of the database without a running MongoDB.
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/address"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/description"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/drivertest"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/mnet"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/wiremessage"
+)
+
+// ReplyFunc answers a command sent to the server. A nil reply answers the command successfully without a result.
+type ReplyFunc func(command bson.M) bson.D
+
+// Server is a deployment of a single MongoDB server which records the commands sent to it
+// and answers them with the reply function
+type Server struct {
+	*drivertest.MockDeployment
+	mu       sync.Mutex
+	reply    ReplyFunc
+	commands []bson.M
+	replies  [][]byte
+}
+
+// NewServer creates a server which answers the commands with given reply function
+func NewServer(reply ReplyFunc) *Server {
+	return &Server{MockDeployment: drivertest.NewMockDeployment(), reply: reply}
+}
+
+// Client returns a client which sends its commands to the server
+func (s *Server) Client() (*mongo.Client, error) {
+	opts := options.Client()
+	opts.Deployment = s
+	return mongo.Connect(opts)
+}
+
+// SelectServer implements driver.Deployment
+func (s *Server) SelectServer(context.Context, description.ServerSelector) (driver.Server, error) {
+	return s, nil
+}
+
+// Connection implements driver.Server
+func (s *Server) Connection(context.Context) (*mnet.Connection, error) {
+	return mnet.NewConnection(&connection{server: s}), nil
+}
+
+// Commands returns the commands sent to the server by their name (ex: "insert"), in the order they were sent
+func (s *Server) Commands(name string) []bson.M {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	commands := make([]bson.M, 0)
+	for _, command := range s.commands {
+		if _, ok := command[name]; ok {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// Reset forgets the commands sent to the server so far
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = nil
+}
+
+func (s *Server) write(wm []byte) error {
+	document, err := drivertest.GetCommandFromMsgWireMessage(wm)
+	if err != nil {
+		return err
+	}
+	command, err := decode(document)
+	if err != nil {
+		return err
+	}
+	// documents of inserts and updates are sent in a separate section of the message
+	if documents, ok := readDocumentSequences(wm); ok {
+		for identifier, values := range documents {
+			command[identifier] = values
+		}
+	}
+	reply := s.reply(command)
+	if reply == nil {
+		reply = OK()
+	}
+	response, err := bson.Marshal(reply)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, command)
+	var index int32
+	var dst []byte
+	index, dst = wiremessage.AppendHeaderStart(dst, wiremessage.NextRequestID(), 0, wiremessage.OpMsg)
+	dst = wiremessage.AppendMsgFlags(dst, 0)
+	dst = wiremessage.AppendMsgSectionType(dst, wiremessage.SingleDocument)
+	dst = append(dst, response...)
+	s.replies = append(s.replies, bsoncore.UpdateLength(dst, index, int32(len(dst[index:]))))
+	return nil
+}
+
+func (s *Server) read() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.replies) == 0 {
+		return nil, errors.New("no reply to read")
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply, nil
+}
+
+// readDocumentSequences returns the documents in the document sequence sections of an OP_MSG wire message by their identifier
+func readDocumentSequences(wm []byte) (map[string]bson.A, bool) {
+	_, _, _, _, wm, ok := wiremessage.ReadHeader(wm)
+	if !ok {
+		return nil, false
+	}
+	if _, wm, ok = wiremessage.ReadMsgFlags(wm); !ok {
+		return nil, false
+	}
+	sequences := make(map[string]bson.A)
+	for len(wm) > 0 {
+		var sectionType wiremessage.SectionType
+		if sectionType, wm, ok = wiremessage.ReadMsgSectionType(wm); !ok {
+			return nil, false
+		}
+		if sectionType == wiremessage.SingleDocument {
+			if _, wm, ok = wiremessage.ReadMsgSectionSingleDocument(wm); !ok {
+				return nil, false
+			}
+			continue
+		}
+		var identifier string
+		var documents []bsoncore.Document
+		if identifier, documents, wm, ok = wiremessage.ReadMsgSectionDocumentSequence(wm); !ok {
+			return nil, false
+		}
+		for _, document := range documents {
+			value, err := decode(document)
+			if err != nil {
+				return nil, false
+			}
+			sequences[identifier] = append(sequences[identifier], value)
+		}
+	}
+	return sequences, len(sequences) > 0
+}
+
+// decode decodes a document of a command, including the embedded documents, into maps
+func decode(document []byte) (bson.M, error) {
+	decoder := bson.NewDecoder(bson.NewDocumentReader(bytes.NewReader(document)))
+	decoder.DefaultDocumentM()
+	var command bson.M
+	err := decoder.Decode(&command)
+	return command, err
+}
+
+// connection is a connection to the server
+type connection struct {
+	server *Server
+}
+
+func (c *connection) Write(_ context.Context, wm []byte) error { return c.server.write(wm) }
+func (c *connection) Read(context.Context) ([]byte, error)     { return c.server.read() }
+func (c *connection) Close() error                             { return nil }
+func (c *connection) Description() description.Server          { return drivertest.MockDescription }
+func (c *connection) ID() string                               { return "dbtest" }
+func (c *connection) ServerConnectionID() *int64               { return nil }
+func (c *connection) DriverConnectionID() int64                { return 0 }
+func (c *connection) Address() address.Address                 { return "dbtest:27017" }
+func (c *connection) Stale() bool                              { return false }
+func (c *connection) OIDCTokenGenID() uint64                   { return 0 }
+func (c *connection) SetOIDCTokenGenID(uint64)                 {}
+
+// OK answers a command successfully without a result
+func OK() bson.D {
+	return bson.D{{Key: "ok", Value: 1}}
+}
+
+// Cursor answers a find or an aggregate command with given documents
+func Cursor(documents ...any) bson.D {
+	batch := bson.A{}
+	for _, document := range documents {
+		batch = append(batch, document)
+	}
+	return bson.D{
+		{Key: "ok", Value: 1},
+		{Key: "cursor", Value: bson.D{{Key: "id", Value: int64(0)}, {Key: "ns", Value: "test.collection"}, {Key: "firstBatch", Value: batch}}},
+	}
+}
+
+// Written answers an update or a delete command which matched given number of documents
+func Written(matched int) bson.D {
+	return bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: matched}, {Key: "nModified", Value: matched}}
+}
+
+// FindAndModify answers a findAndModify command with given document, or without one if it is nil
+func FindAndModify(document any) bson.D {
+	return bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: document}}
+}
+
+// Error answers a command with given server error (ex: 11000 for a duplicate key)
+func Error(code int32, message string) bson.D {
+	return bson.D{{Key: "ok", Value: 0}, {Key: "code", Value: code}, {Key: "errmsg", Value: message}}
+}
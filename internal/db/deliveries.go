@@ -0,0 +1,80 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defaultDeliveryRetention is how long the delivery log is kept if the retention is not configured
+const defaultDeliveryRetention = 30 * 24 * time.Hour
+
+// maxDeliveries is the maximum number of delivery records returned at once
+const maxDeliveries = 500
+
+// createDeliveriesIndexes creates the indexes of the delivery log. The records expire after the configured retention,
+// and they are looked up by user and by notification.
+func (db Mongo) createDeliveriesIndexes(collection *mongo.Collection) error {
+	retention := db.config.DeliveryRetention
+	if retention <= 0 {
+		retention = defaultDeliveryRetention
+	}
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"sentAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds())),
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "sentAt", Value: -1}},
+		},
+		{
+			Keys: bson.M{"notificationId": 1},
+		},
+	}
+	_, err := collection.Indexes().CreateMany(db.ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("mongo deliveries index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertDeliveries records the deliveries of a notification
+func (db Mongo) InsertDeliveries(deliveries []models.DeliveryLog) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	for i := range deliveries {
+		deliveries[i].ID = bson.NewObjectID()
+	}
+	if _, err := db.deliveries.InsertMany(db.ctx, deliveries); err != nil {
+		return fmt.Errorf("failed to insert deliveries: %s", err.Error())
+	}
+	return nil
+}
+
+// GetDeliveries retrieves the deliveries of a user since given time, the latest first.
+// If a notification ID is given, only the deliveries of that notification are returned.
+func (db Mongo) GetDeliveries(userId string, notificationId string, since time.Time) ([]models.DeliveryLog, error) {
+	filter := bson.D{
+		{Key: "userId", Value: userId},
+		{Key: "sentAt", Value: bson.M{"$gte": since}},
+	}
+	if notificationId != "" {
+		filter = append(filter, bson.E{Key: "notificationId", Value: notificationId})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "sentAt", Value: -1}}).SetLimit(maxDeliveries)
+	cursor, err := db.deliveries.Find(db.ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deliveries: %s", err.Error())
+	}
+	deliveries := make([]models.DeliveryLog, 0)
+	if err = cursor.All(db.ctx, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to decode deliveries: %s", err.Error())
+	}
+	return deliveries, nil
+}
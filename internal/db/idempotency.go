@@ -0,0 +1,111 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// createIdempotencyIndexes creates the indexes of the idempotency keys, which are unique per user
+// and removed once they expire
+func (db Mongo) createIdempotencyIndexes(collection *mongo.Collection) error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err := collection.Indexes().CreateMany(db.ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("mongo idempotency index error: %s", err.Error())
+	}
+	return nil
+}
+
+// ClaimIdempotencyKey reserves the idempotency key of the user for a request which is being processed until given time.
+// If the key is already taken, the existing record is returned instead and the key is not reserved.
+// A record whose processing never finished is taken over once it has expired.
+func (db Mongo) ClaimIdempotencyKey(userId string, key string, requestHash string, lockedUntil time.Time) (*models.IdempotencyRecord, error) {
+	record := models.IdempotencyRecord{
+		ID:          bson.NewObjectID(),
+		UserId:      userId,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      models.IdempotencyProcessing,
+		CreatedAt:   time.Now().UTC(),
+		ExpiresAt:   lockedUntil,
+	}
+	_, err := db.idempotency.InsertOne(db.ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to claim idempotency key: %s", err.Error())
+	}
+
+	var existing models.IdempotencyRecord
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "key", Value: key}}
+	if err = db.idempotency.FindOne(db.ctx, filter).Decode(&existing); err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key: %s", err.Error())
+	}
+	if existing.Status != models.IdempotencyProcessing || existing.ExpiresAt.After(record.CreatedAt) {
+		return &existing, nil
+	}
+
+	// the processing of the existing record was abandoned, ex: the service restarted in the middle of the request
+	record.ID = existing.ID
+	takeover := bson.D{{Key: "_id", Value: existing.ID}, {Key: "status", Value: models.IdempotencyProcessing}, {Key: "expiresAt", Value: existing.ExpiresAt}}
+	result, err := db.idempotency.ReplaceOne(db.ctx, takeover, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take over idempotency key: %s", err.Error())
+	}
+	if result.ModifiedCount == 0 {
+		// another request took over the key first
+		return &existing, nil
+	}
+	return nil, nil
+}
+
+// ExtendIdempotencyKey keeps the idempotency key reserved until given time for the request which is still being processed
+func (db Mongo) ExtendIdempotencyKey(userId string, key string, lockedUntil time.Time) error {
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "key", Value: key}, {Key: "status", Value: models.IdempotencyProcessing}}
+	update := bson.M{"$set": bson.M{"expiresAt": lockedUntil}}
+	if _, err := db.idempotency.UpdateOne(db.ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to extend idempotency key: %s", err.Error())
+	}
+	return nil
+}
+
+// CompleteIdempotencyKey stores the response of the request which reserved the idempotency key, until given time
+func (db Mongo) CompleteIdempotencyKey(userId string, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error {
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "key", Value: key}}
+	update := bson.M{"$set": bson.M{
+		"status":      models.IdempotencyCompleted,
+		"statusCode":  statusCode,
+		"contentType": contentType,
+		"body":        body,
+		"expiresAt":   expiresAt,
+	}}
+	if _, err := db.idempotency.UpdateOne(db.ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %s", err.Error())
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes the reservation of the idempotency key, so the request can be retried
+func (db Mongo) ReleaseIdempotencyKey(userId string, key string) error {
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "key", Value: key}, {Key: "status", Value: models.IdempotencyProcessing}}
+	if _, err := db.idempotency.DeleteOne(db.ctx, filter); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// inboxNotificationIndex is the name of the unique index on the user and the notification of the inbox items
+const inboxNotificationIndex = "userId_notificationId_unique"
+
+// createInboxIndexes creates the indexes of the inbox, which is listed per user the latest first,
+// counted per user by the read state and stored at most once per user and notification
+func (db Mongo) createInboxIndexes(collection *mongo.Collection) error {
+	// the index on the user and the notification used to be non-unique; it is replaced by the unique one,
+	// and a missing index is not an error
+	_ = collection.Indexes().DropOne(db.ctx, "userId_1_notificationId_1")
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "read", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "notificationId", Value: 1}},
+			Options: options.Index().
+				SetName(inboxNotificationIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"notificationId": bson.M{"$exists": true}}),
+		},
+	}
+	_, err := collection.Indexes().CreateMany(db.ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("mongo inbox index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertInboxItem inserts a new, unread notification into the inbox of the user and returns it with generated ID and creation time.
+// A notification is only stored once per user, so sending the same notification again (ex: a retried job) returns the stored one.
+func (db Mongo) InsertInboxItem(item models.InboxItem) (*models.InboxItem, error) {
+	item.ID = bson.NewObjectID()
+	item.Read = false
+	item.CreatedAt = time.Now().UTC()
+	if item.NotificationId == "" {
+		if _, err := db.inbox.InsertOne(db.ctx, item); err != nil {
+			return nil, fmt.Errorf("failed to insert inbox item: %s", err.Error())
+		}
+		return &item, nil
+	}
+
+	filter := bson.D{{Key: "userId", Value: item.UserId}, {Key: "notificationId", Value: item.NotificationId}}
+	update := bson.M{"$setOnInsert": item}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var stored models.InboxItem
+	err := db.inbox.FindOneAndUpdate(db.ctx, filter, update, opts).Decode(&stored)
+	if mongo.IsDuplicateKeyError(err) {
+		// the same notification was stored concurrently (ex: by another scheduler), so the stored one is returned
+		err = db.inbox.FindOne(db.ctx, filter).Decode(&stored)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert inbox item: %s", err.Error())
+	}
+	return &stored, nil
+}
+
+// GetInbox retrieves at most limit notifications of the user older than the notification with given ID, the latest first.
+// A zero ID starts from the latest notification, and an empty category returns the notifications of every category.
+func (db Mongo) GetInbox(userId string, category string, before bson.ObjectID, limit int64) ([]models.InboxItem, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	if category != "" {
+		filter = append(filter, bson.E{Key: "category", Value: category})
+	}
+	if !before.IsZero() {
+		filter = append(filter, bson.E{Key: "_id", Value: bson.M{"$lt": before}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+	cursor, err := db.inbox.Find(db.ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find inbox items: %s", err.Error())
+	}
+	items := make([]models.InboxItem, 0)
+	if err = cursor.All(db.ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode inbox items: %s", err.Error())
+	}
+	return items, nil
+}
+
+// CountUnread returns how many notifications in the inbox of the user are unread
+func (db Mongo) CountUnread(userId string) (int64, error) {
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "read", Value: false}}
+	count, err := db.inbox.CountDocuments(db.ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread inbox items: %s", err.Error())
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification in the inbox of the user as read.
+// It returns mongo.ErrNoDocuments if the user does not have the notification.
+func (db Mongo) MarkRead(userId string, id bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "userId", Value: userId}}
+	result, err := db.inbox.UpdateOne(db.ctx, filter, readUpdate())
+	if err != nil {
+		return fmt.Errorf("failed to mark inbox item as read: %s", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification in the inbox of the user as read and returns how many were marked
+func (db Mongo) MarkAllRead(userId string) (int64, error) {
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "read", Value: false}}
+	result, err := db.inbox.UpdateMany(db.ctx, filter, readUpdate())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark inbox items as read: %s", err.Error())
+	}
+	return result.ModifiedCount, nil
+}
+
+// readUpdate marks a notification as read, keeping the time when it was read first
+func readUpdate() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"read":   true,
+			"readAt": bson.M{"$ifNull": bson.A{"$readAt", time.Now().UTC()}},
+		}}},
+	}
+}
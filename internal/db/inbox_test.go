@@ -0,0 +1,130 @@
+// AnhCao 2024
+package db
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestInsertInboxItem(t *testing.T) {
+	storedId := bson.NewObjectID()
+	stored := bson.D{
+		{Key: "_id", Value: storedId},
+		{Key: "userId", Value: "user-1"},
+		{Key: "notificationId", Value: "job-1"},
+		{Key: "title", Value: "Cheap hours"},
+	}
+	tests := []struct {
+		name           string
+		notificationId string
+		// reply of the server to the upsert of the notification
+		upsertReply bson.D
+		wantStored  bool
+		wantUpsert  bool
+		wantFind    bool
+		wantErr     bool
+	}{
+		{
+			name:       "notification without ID is inserted",
+			wantUpsert: false,
+		},
+		{
+			name:           "notification is stored once",
+			notificationId: "job-1",
+			upsertReply:    dbtest.FindAndModify(stored),
+			wantStored:     true,
+			wantUpsert:     true,
+		},
+		{
+			name:           "notification stored concurrently is returned",
+			notificationId: "job-1",
+			upsertReply:    dbtest.Error(11000, "E11000 duplicate key error"),
+			wantStored:     true,
+			wantUpsert:     true,
+			wantFind:       true,
+		},
+		{
+			name:           "failure of the server",
+			notificationId: "job-1",
+			upsertReply:    dbtest.Error(2, "bad value"),
+			wantUpsert:     true,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, server := newTestMongo(t, func(command bson.M) bson.D {
+				switch {
+				case command["findAndModify"] != nil:
+					return tt.upsertReply
+				case command["find"] != nil:
+					return dbtest.Cursor(stored)
+				}
+				return dbtest.Written(1)
+			})
+
+			item, err := db.InsertInboxItem(models.InboxItem{UserId: "user-1", NotificationId: tt.notificationId, Title: "Cheap hours"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InsertInboxItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantStored && item.ID != storedId {
+				t.Errorf("InsertInboxItem() ID = %s, want the stored %s", item.ID.Hex(), storedId.Hex())
+			}
+			if !tt.wantStored && (item.ID.IsZero() || item.Read) {
+				t.Errorf("InsertInboxItem() = %+v, want a new unread item", item)
+			}
+
+			upserts := server.Commands("findAndModify")
+			if (len(upserts) == 1) != tt.wantUpsert {
+				t.Fatalf("sent %d upserts, want upsert %v", len(upserts), tt.wantUpsert)
+			}
+			if tt.wantUpsert {
+				query := upserts[0]["query"].(bson.M)
+				if query["userId"] != "user-1" || query["notificationId"] != tt.notificationId || upserts[0]["upsert"] != true {
+					t.Errorf("upsert = %v, want an upsert by the user and the notification", upserts[0])
+				}
+			} else if len(server.Commands("insert")) != 1 {
+				t.Errorf("sent %d inserts, want 1", len(server.Commands("insert")))
+			}
+			if finds := server.Commands("find"); (len(finds) == 1) != tt.wantFind {
+				t.Errorf("sent %d finds, want find %v", len(finds), tt.wantFind)
+			}
+		})
+	}
+}
+
+func TestCreateInboxIndexes(t *testing.T) {
+	db, server := newTestMongo(t, func(command bson.M) bson.D {
+		if command["dropIndexes"] != nil {
+			return dbtest.Error(27, "index not found")
+		}
+		return nil
+	})
+
+	if err := db.createInboxIndexes(db.inbox); err != nil {
+		t.Fatalf("createInboxIndexes() error = %v", err)
+	}
+
+	commands := server.Commands("createIndexes")
+	if len(commands) != 1 {
+		t.Fatalf("sent %d createIndexes commands, want 1", len(commands))
+	}
+	for _, index := range commands[0]["indexes"].(bson.A) {
+		index := index.(bson.M)
+		if index["name"] != inboxNotificationIndex {
+			continue
+		}
+		if index["unique"] != true || index["partialFilterExpression"] == nil {
+			t.Errorf("index %v, want a unique index of the notifications with an ID", index)
+		}
+		return
+	}
+	t.Errorf("indexes %v, want %s", commands[0]["indexes"], inboxNotificationIndex)
+}
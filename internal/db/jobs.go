@@ -0,0 +1,219 @@
+// AnhCao 2024
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// finishedJobRetention is how long the finished jobs are kept in the database
+const finishedJobRetention = 7 * 24 * time.Hour
+
+// ErrJobLost is returned when a scheduler updates a job which it no longer holds,
+// because its lock expired and another scheduler claimed the job
+var ErrJobLost = errors.New("job was claimed by another scheduler")
+
+// createJobsIndexes creates the indexes of the jobs collection:
+//   - "status" and "runAt" for looking up due jobs
+//   - unique "dedupKey" for the jobs that must not be scheduled twice
+//   - "expiresAt" for removing finished jobs
+func (db Mongo) createJobsIndexes(collection *mongo.Collection) error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "runAt", Value: 1}},
+		},
+		{
+			Keys: bson.M{"dedupKey": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+				bson.M{"dedupKey": bson.M{"$type": "string"}},
+			),
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	_, err := collection.Indexes().CreateMany(db.ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("mongo jobs index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertJob schedules a new job. If the job has a dedup key and a job with the same key
+// already exists, the existing job is kept untouched. The job gets a new ID unless it already has one.
+func (db Mongo) InsertJob(job models.Job) error {
+	if job.ID.IsZero() {
+		job.ID = bson.NewObjectID()
+	}
+	job.Status = models.JobPending
+	job.CreatedAt = time.Now().UTC()
+
+	if job.DedupKey == "" {
+		if _, err := db.jobs.InsertOne(db.ctx, job); err != nil {
+			return fmt.Errorf("failed to insert job: %s", err.Error())
+		}
+		return nil
+	}
+
+	filter := bson.D{{Key: "dedupKey", Value: job.DedupKey}}
+	update := bson.M{"$setOnInsert": job}
+	if _, err := db.jobs.UpdateOne(db.ctx, filter, update, options.UpdateOne().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to upsert job: %s", err.Error())
+	}
+	return nil
+}
+
+// ClaimDueJob atomically reserves the earliest job which run time has passed, so that only one scheduler
+// (also across replicas) executes it. Jobs which were reserved by a scheduler that never finished them
+// are claimed again once their lock has expired. Every claim gets a new claim token, which the scheduler passes
+// back when it updates the job. It returns `mongo.ErrNoDocuments` if there is no due job.
+func (db Mongo) ClaimDueJob(now time.Time, lockTimeout time.Duration) (*models.Job, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": models.JobPending, "runAt": bson.M{"$lte": now}},
+			bson.M{"status": models.JobRunning, "lockedUntil": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "lockedUntil": now.Add(lockTimeout), "claimToken": bson.NewObjectID().Hex()},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"runAt": 1}).
+		SetReturnDocument(options.After)
+
+	var job models.Job
+	if err := db.jobs.FindOneAndUpdate(db.ctx, filter, update, opts).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to claim due job: %s", err.Error())
+	}
+	return &job, nil
+}
+
+// CompleteJob marks a claimed job as done.
+// It returns ErrJobLost if the job was claimed by another scheduler in the meantime.
+func (db Mongo) CompleteJob(id bson.ObjectID, claimToken string) error {
+	expiresAt := time.Now().UTC().Add(finishedJobRetention)
+	update := bson.M{"$set": bson.M{"status": models.JobDone, "expiresAt": expiresAt}}
+	err := db.updateClaimedJob(id, claimToken, update)
+	if err != nil && err != ErrJobLost {
+		return fmt.Errorf("failed to complete job: %s", err.Error())
+	}
+	return err
+}
+
+// RescheduleJob releases a claimed job after a failed attempt so it is retried at given time.
+// It returns ErrJobLost if the job was claimed by another scheduler in the meantime.
+func (db Mongo) RescheduleJob(id bson.ObjectID, claimToken string, runAt time.Time, lastError string) error {
+	update := bson.M{"$set": bson.M{"status": models.JobPending, "runAt": runAt, "lastError": lastError}}
+	err := db.updateClaimedJob(id, claimToken, update)
+	if err != nil && err != ErrJobLost {
+		return fmt.Errorf("failed to reschedule job: %s", err.Error())
+	}
+	return err
+}
+
+// FailJob marks a claimed job as failed, so it will not be retried anymore.
+// It returns ErrJobLost if the job was claimed by another scheduler in the meantime.
+func (db Mongo) FailJob(id bson.ObjectID, claimToken string, lastError string) error {
+	expiresAt := time.Now().UTC().Add(finishedJobRetention)
+	update := bson.M{"$set": bson.M{"status": models.JobFailed, "lastError": lastError, "expiresAt": expiresAt}}
+	err := db.updateClaimedJob(id, claimToken, update)
+	if err != nil && err != ErrJobLost {
+		return fmt.Errorf("failed to mark job as failed: %s", err.Error())
+	}
+	return err
+}
+
+// updateClaimedJob updates a running job only as long as it is held by the claim with given token
+func (db Mongo) updateClaimedJob(id bson.ObjectID, claimToken string, update any) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: models.JobRunning}, {Key: "claimToken", Value: claimToken}}
+	result, err := db.jobs.UpdateOne(db.ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrJobLost
+	}
+	return nil
+}
+
+// DeletePendingJobs deletes the jobs created by given resource that have not been executed yet
+func (db Mongo) DeletePendingJobs(referenceId string) error {
+	filter := bson.D{{Key: "referenceId", Value: referenceId}, {Key: "status", Value: models.JobPending}}
+	if _, err := db.jobs.DeleteMany(db.ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete pending jobs: %s", err.Error())
+	}
+	return nil
+}
+
+// GetScheduledNotification retrieves a notification which the user scheduled through the API.
+// It returns nil without an error if the user does not have the scheduled notification.
+func (db Mongo) GetScheduledNotification(userId string, id bson.ObjectID) (*models.Job, error) {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "kind", Value: models.JobKindNotification},
+		{Key: "message.userId", Value: userId},
+	}
+	var job models.Job
+	if err := db.jobs.FindOne(db.ctx, filter).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find scheduled notification: %s", err.Error())
+	}
+	return &job, nil
+}
+
+// CancelScheduledNotification deletes a notification which the user scheduled through the API, as long as it has not been sent yet.
+// It returns mongo.ErrNoDocuments if the user does not have a pending scheduled notification with given ID.
+func (db Mongo) CancelScheduledNotification(userId string, id bson.ObjectID) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "kind", Value: models.JobKindNotification},
+		{Key: "message.userId", Value: userId},
+		{Key: "status", Value: models.JobPending},
+	}
+	result, err := db.jobs.DeleteOne(db.ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled notification: %s", err.Error())
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ExtendJobLock keeps a long running job reserved for the scheduler which claimed it until given time.
+// It returns ErrJobLost if the job was claimed by another scheduler in the meantime.
+func (db Mongo) ExtendJobLock(id bson.ObjectID, claimToken string, lockedUntil time.Time) error {
+	update := bson.M{"$set": bson.M{"lockedUntil": lockedUntil}}
+	err := db.updateClaimedJob(id, claimToken, update)
+	if err != nil && err != ErrJobLost {
+		return fmt.Errorf("failed to extend lock of job: %s", err.Error())
+	}
+	return err
+}
+
+// ReleaseJob releases a claimed job which was interrupted (ex: by a shutdown) so it is continued right away,
+// without counting the interrupted attempt. It returns ErrJobLost if the job was claimed by another scheduler in the meantime.
+func (db Mongo) ReleaseJob(id bson.ObjectID, claimToken string) error {
+	update := bson.M{
+		"$set": bson.M{"status": models.JobPending, "runAt": time.Now().UTC()},
+		"$inc": bson.M{"attempts": -1},
+	}
+	err := db.updateClaimedJob(id, claimToken, update)
+	if err != nil && err != ErrJobLost {
+		return fmt.Errorf("failed to release job: %s", err.Error())
+	}
+	return err
+}
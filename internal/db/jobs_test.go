@@ -0,0 +1,82 @@
+// AnhCao 2024
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestClaimDueJob(t *testing.T) {
+	db, server := newTestMongo(t, func(command bson.M) bson.D {
+		return dbtest.FindAndModify(bson.D{{Key: "_id", Value: bson.NewObjectID()}, {Key: "status", Value: models.JobRunning}, {Key: "claimToken", Value: "token"}})
+	})
+
+	if _, err := db.ClaimDueJob(time.Now().UTC(), time.Minute); err != nil {
+		t.Fatalf("ClaimDueJob() error = %v", err)
+	}
+	if _, err := db.ClaimDueJob(time.Now().UTC(), time.Minute); err != nil {
+		t.Fatalf("ClaimDueJob() error = %v", err)
+	}
+
+	commands := server.Commands("findAndModify")
+	if len(commands) != 2 {
+		t.Fatalf("sent %d claims, want 2", len(commands))
+	}
+	tokens := make([]any, 0, len(commands))
+	for _, command := range commands {
+		token := command["update"].(bson.M)["$set"].(bson.M)["claimToken"]
+		if token == nil || token == "" {
+			t.Fatalf("claim %v, want a claim token", command)
+		}
+		tokens = append(tokens, token)
+	}
+	if tokens[0] == tokens[1] {
+		t.Errorf("claim tokens %v, want a new token for every claim", tokens)
+	}
+}
+
+func TestUpdateClaimedJob(t *testing.T) {
+	id := bson.NewObjectID()
+	runAt := time.Now().UTC()
+	updates := map[string]func(db *Mongo) error{
+		"complete":   func(db *Mongo) error { return db.CompleteJob(id, "token") },
+		"reschedule": func(db *Mongo) error { return db.RescheduleJob(id, "token", runAt, "channel down") },
+		"fail":       func(db *Mongo) error { return db.FailJob(id, "token", "channel down") },
+		"extend":     func(db *Mongo) error { return db.ExtendJobLock(id, "token", runAt) },
+		"release":    func(db *Mongo) error { return db.ReleaseJob(id, "token") },
+	}
+	tests := []struct {
+		name    string
+		reply   bson.D
+		wantErr error
+	}{
+		{name: "held by the claim", reply: dbtest.Written(1)},
+		{name: "claimed by another scheduler", reply: dbtest.Written(0), wantErr: ErrJobLost},
+	}
+	for _, tt := range tests {
+		for name, update := range updates {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				db, server := newTestMongo(t, func(command bson.M) bson.D { return tt.reply })
+
+				if err := update(db); !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+
+				commands := server.Commands("update")
+				if len(commands) != 1 {
+					t.Fatalf("sent %d updates, want 1", len(commands))
+				}
+				filter := commands[0]["updates"].(bson.A)[0].(bson.M)["q"].(bson.M)
+				if filter["_id"] != id || filter["claimToken"] != "token" || filter["status"] != string(models.JobRunning) {
+					t.Errorf("filter = %v, want the running job held by the claim", filter)
+				}
+			})
+		}
+	}
+}
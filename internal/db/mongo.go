@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -20,6 +21,23 @@ type Mongo struct {
 	ctx        context.Context
 	Client     *mongo.Client
 	collection *mongo.Collection
+	prices     *mongo.Collection
+	reminders  *mongo.Collection
+	jobs       *mongo.Collection
+	appliances *mongo.Collection
+	contracts  *mongo.Collection
+	// user preferences, ex: delivery channels and email address
+	preferences *mongo.Collection
+	webhooks    *mongo.Collection
+	// delivery log of the notifications
+	deliveries *mongo.Collection
+	// in-app inbox of the notifications
+	inbox *mongo.Collection
+	// responses of the requests with an idempotency key
+	idempotency *mongo.Collection
+	broadcasts  *mongo.Collection
+	// API keys of the services which call the API
+	apiKeys *mongo.Collection
 }
 
 // NewMongo initializes a new Mongo instance with the provided context, database configuration, and logger.
@@ -45,11 +63,77 @@ func (db *Mongo) EstablishConnection() (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to ping database. Error: %s", err.Error())
 	}
+	return db.Open(db.Client)
+}
 
+// Open prepares the collections and their indexes on a connected client.
+// EstablishConnection calls it with the client of the configured server, tests with the client of a test deployment (see dbtest).
+func (db *Mongo) Open(client *mongo.Client) (err error) {
+	db.Client = client
 	db.collection = db.Client.Database(db.config.Name).Collection(db.config.Collection)
 	if err = db.createIndex(db.collection); err != nil {
 		return err
 	}
+
+	db.prices = db.Client.Database(db.config.Name).Collection(constants.PricesCollection)
+	if err = db.createPricesIndex(db.prices); err != nil {
+		return err
+	}
+
+	db.reminders = db.Client.Database(db.config.Name).Collection(constants.RemindersCollection)
+	if err = db.createRemindersIndex(db.reminders); err != nil {
+		return err
+	}
+
+	db.jobs = db.Client.Database(db.config.Name).Collection(constants.JobsCollection)
+	if err = db.createJobsIndexes(db.jobs); err != nil {
+		return err
+	}
+
+	db.appliances = db.Client.Database(db.config.Name).Collection(constants.AppliancesCollection)
+	if err = db.createAppliancesIndex(db.appliances); err != nil {
+		return err
+	}
+
+	db.contracts = db.Client.Database(db.config.Name).Collection(constants.ContractsCollection)
+	if err = db.createContractsIndex(db.contracts); err != nil {
+		return err
+	}
+
+	db.preferences = db.Client.Database(db.config.Name).Collection(constants.PreferencesCollection)
+	if err = db.createPreferencesIndexes(db.preferences); err != nil {
+		return err
+	}
+
+	db.webhooks = db.Client.Database(db.config.Name).Collection(constants.WebhooksCollection)
+	if err = db.createWebhooksIndex(db.webhooks); err != nil {
+		return err
+	}
+
+	db.deliveries = db.Client.Database(db.config.Name).Collection(constants.DeliveriesCollection)
+	if err = db.createDeliveriesIndexes(db.deliveries); err != nil {
+		return err
+	}
+
+	db.inbox = db.Client.Database(db.config.Name).Collection(constants.InboxCollection)
+	if err = db.createInboxIndexes(db.inbox); err != nil {
+		return err
+	}
+
+	db.idempotency = db.Client.Database(db.config.Name).Collection(constants.IdempotencyCollection)
+	if err = db.createIdempotencyIndexes(db.idempotency); err != nil {
+		return err
+	}
+
+	db.broadcasts = db.Client.Database(db.config.Name).Collection(constants.BroadcastsCollection)
+	if err = db.createBroadcastsIndex(db.broadcasts); err != nil {
+		return err
+	}
+
+	db.apiKeys = db.Client.Database(db.config.Name).Collection(constants.APIKeysCollection)
+	if err = db.createAPIKeysIndex(db.apiKeys); err != nil {
+		return err
+	}
 	db.logger.Info("Successfully connected to database")
 	return nil
 }
@@ -90,42 +174,69 @@ func (db Mongo) InsertToken(token models.NotificationToken) error {
 		if res.Err() == mongo.ErrNoDocuments {
 			// If token does not exist then insert it
 			token.ID = bson.NewObjectID()
-			_, err := db.collection.InsertOne(db.ctx, token)
-			return fmt.Errorf("failed to insert token: %s", err.Error())
+			if _, err := db.collection.InsertOne(db.ctx, token); err != nil {
+				return fmt.Errorf("failed to insert token: %s", err.Error())
+			}
+			return nil
 		}
 		return res.Err()
 	}
 
-	// If token exists update the timestamp to now
-	_, err := db.collection.UpdateOne(db.ctx, filter, bson.M{"$set": bson.M{"timestamp": time.Now().UTC()}})
+	// If token exists update the timestamp to now, together with the platform, the attributes of the device
+	// and the Web Push keys if they are given
+	update := bson.M{"timestamp": time.Now().UTC()}
+	fields := map[string]string{
+		"platform":   token.Platform,
+		"locale":     token.Locale,
+		"appVersion": token.AppVersion,
+		"area":       token.Area,
+	}
+	for field, value := range fields {
+		if value != "" {
+			update[field] = value
+		}
+	}
+	if token.Keys != nil {
+		update["keys"] = token.Keys
+	}
+	_, err := db.collection.UpdateOne(db.ctx, filter, bson.M{"$set": update})
 	if err != nil {
 		return fmt.Errorf("failed to update existing token: %s", err.Error())
 	}
 	return nil
 }
 
-// Get all the tokens registered for a user
-func (db Mongo) GetTokens(userId string) ([]string, error) {
+// Get all the tokens registered for a user, together with the platform of each device
+func (db Mongo) GetTokens(userId string) ([]models.NotificationToken, error) {
 	filter := bson.D{{Key: "userId", Value: userId}}
 	tokenCursor, err := db.collection.Find(db.ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find tokens for user: %s", err.Error())
 	}
 
-	tokens := make([]string, 0)
-	for tokenCursor.Next(db.ctx) {
-		var token models.NotificationToken
-		err = tokenCursor.Decode(&token)
-		tokens = append(tokens, token.DeviceId)
+	tokens := make([]models.NotificationToken, 0)
+	if err = tokenCursor.All(db.ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode notification token: %s", err.Error())
 	}
+	return tokens, nil
+}
 
+// DeleteToken deletes a device token of a user.
+// It returns `mongo.ErrNoDocuments` if the user does not have given token.
+func (db Mongo) DeleteToken(userId, deviceId string) error {
+	filter := bson.D{{Key: "deviceId", Value: deviceId}, {Key: "userId", Value: userId}}
+	res, err := db.collection.DeleteOne(db.ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode notification token: %s", err.Error())
+		return fmt.Errorf("failed to delete token: %s", err.Error())
 	}
-	return tokens, nil
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
 }
 
 // GetAllUserIDs retrieves all user IDs from the MongoDB collection.
+// A user with several devices is returned only once.
 // It returns a slice of user IDs and an error if any occurs during the process.
 func (db Mongo) GetAllUserIDs() ([]string, error) {
 	var userIDs []string
@@ -139,7 +250,12 @@ func (db Mongo) GetAllUserIDs() ([]string, error) {
 	if err = cursor.All(db.ctx, &results); err != nil {
 		return userIDs, fmt.Errorf("failed to cursor all notification tokens: %s", err.Error())
 	}
+	seen := make(map[string]bool, len(results))
 	for _, result := range results {
+		if seen[result.UserId] {
+			continue
+		}
+		seen[result.UserId] = true
 		userIDs = append(userIDs, result.UserId)
 	}
 	db.logger.Info("get all user IDs successfully", zap.Any("userIDs", userIDs))
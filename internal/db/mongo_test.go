@@ -1,2 +1,29 @@
 // AnhCao 2024
 package db
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// newTestMongo returns a database stored on a fake server which answers with given reply function
+func newTestMongo(t *testing.T, reply dbtest.ReplyFunc) (*Mongo, *dbtest.Server) {
+	t.Helper()
+	server := dbtest.NewServer(reply)
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to connect to fake server: %v", err)
+	}
+	db := NewMongo(context.Background(), &models.Database{Name: "test", Collection: "tokens"}, zap.NewNop())
+	if err = db.Open(client); err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// only the commands of the test are of interest, not the ones creating the indexes
+	server.Reset()
+	return db, server
+}
@@ -0,0 +1,123 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// createPreferencesIndexes creates a unique index on the "userId" field of the preferences collection,
+// so that every user has at most one preferences document, and an index to look up pending email confirmations
+func (db Mongo) createPreferencesIndexes(collection *mongo.Collection) error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"userId": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"verificationHash": 1},
+			Options: options.Index().SetPartialFilterExpression(
+				bson.M{"verificationHash": bson.M{"$exists": true}},
+			),
+		},
+	}
+	_, err := collection.Indexes().CreateMany(db.ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("mongo preferences index error: %s", err.Error())
+	}
+	return nil
+}
+
+// GetPreferences retrieves the preferences of a user. It returns nil without an error if the user has not configured any.
+func (db Mongo) GetPreferences(userId string) (*models.Preferences, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	var preferences models.Preferences
+	if err := db.preferences.FindOne(db.ctx, filter).Decode(&preferences); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get preferences: %s", err.Error())
+	}
+	return &preferences, nil
+}
+
+// UpdateChannels stores the delivery channels that the user selected
+func (db Mongo) UpdateChannels(userId string, channels []string) (*models.Preferences, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	update := bson.M{
+		"$set": bson.M{
+			"channels":  channels,
+			"updatedAt": time.Now().UTC(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var preferences models.Preferences
+	if err := db.preferences.FindOneAndUpdate(db.ctx, filter, update, opts).Decode(&preferences); err != nil {
+		return nil, fmt.Errorf("failed to update channels: %s", err.Error())
+	}
+	return &preferences, nil
+}
+
+// SetEmail stores a new, unconfirmed email address of the user together with the hash of its confirmation token.
+// The previous address stops receiving notifications until the new one is confirmed.
+func (db Mongo) SetEmail(userId, email, verificationHash string, expiresAt time.Time) error {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	update := bson.M{
+		"$set": bson.M{
+			"email":                 email,
+			"emailVerified":         false,
+			"verificationHash":      verificationHash,
+			"verificationExpiresAt": expiresAt,
+			"updatedAt":             time.Now().UTC(),
+		},
+	}
+	_, err := db.preferences.UpdateOne(db.ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to set email: %s", err.Error())
+	}
+	return nil
+}
+
+// VerifyEmail confirms the email address whose pending confirmation token has given hash and has not expired.
+// It returns `mongo.ErrNoDocuments` if there is no such pending confirmation.
+func (db Mongo) VerifyEmail(verificationHash string, now time.Time) (*models.Preferences, error) {
+	filter := bson.D{
+		{Key: "verificationHash", Value: verificationHash},
+		{Key: "verificationExpiresAt", Value: bson.M{"$gt": now}},
+	}
+	update := bson.M{
+		"$set":   bson.M{"emailVerified": true, "updatedAt": now},
+		"$unset": bson.M{"verificationHash": "", "verificationExpiresAt": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var preferences models.Preferences
+	if err := db.preferences.FindOneAndUpdate(db.ctx, filter, update, opts).Decode(&preferences); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to verify email: %s", err.Error())
+	}
+	return &preferences, nil
+}
+
+// UpdateCategories stores the categories of broadcasts which the user opted in to, and returns the updated preferences
+func (db Mongo) UpdateCategories(userId string, categories []string) (*models.Preferences, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	update := bson.M{
+		"$set": bson.M{
+			"categories": categories,
+			"updatedAt":  time.Now().UTC(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var preferences models.Preferences
+	if err := db.preferences.FindOneAndUpdate(db.ctx, filter, update, opts).Decode(&preferences); err != nil {
+		return nil, fmt.Errorf("failed to update categories: %s", err.Error())
+	}
+	return &preferences, nil
+}
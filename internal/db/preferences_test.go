@@ -0,0 +1,90 @@
+// AnhCao 2024
+package db
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/AnhCaooo/electric-notifications/internal/db/dbtest"
+)
+
+func TestSetEmail(t *testing.T) {
+	db, server := newTestMongo(t, func(command bson.M) bson.D {
+		return dbtest.Written(1)
+	})
+	expiresAt := time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	// registering an address again replaces a confirmed address by an unconfirmed one
+	if err := db.SetEmail("user-1", "new@example.com", "hash", expiresAt); err != nil {
+		t.Fatalf("SetEmail() error = %v", err)
+	}
+
+	commands := server.Commands("update")
+	if len(commands) != 1 {
+		t.Fatalf("sent %d updates, want 1", len(commands))
+	}
+	update := commands[0]["updates"].(bson.A)[0].(bson.M)
+	if update["q"].(bson.M)["userId"] != "user-1" || update["upsert"] != true {
+		t.Errorf("update = %v, want an upsert of the preferences of the user", update)
+	}
+	set := update["u"].(bson.M)["$set"].(bson.M)
+	if set["email"] != "new@example.com" || set["emailVerified"] != false || set["verificationHash"] != "hash" {
+		t.Errorf("$set = %v, want the unconfirmed address and its hash", set)
+	}
+	if set["verificationExpiresAt"].(bson.DateTime).Time().UTC() != expiresAt {
+		t.Errorf("verificationExpiresAt = %v, want %v", set["verificationExpiresAt"], expiresAt)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// the preferences which have a pending confirmation of given hash, nil if there is none
+		pending   bson.D
+		wantEmail string
+		wantErr   error
+	}{
+		{
+			name:      "pending confirmation",
+			pending:   bson.D{{Key: "userId", Value: "user-1"}, {Key: "email", Value: "user@example.com"}, {Key: "emailVerified", Value: true}},
+			wantEmail: "user@example.com",
+		},
+		{
+			name:    "unknown, used or expired confirmation",
+			wantErr: mongo.ErrNoDocuments,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, server := newTestMongo(t, func(command bson.M) bson.D {
+				return dbtest.FindAndModify(tt.pending)
+			})
+
+			preferences, err := db.VerifyEmail("hash", now)
+			if err != tt.wantErr {
+				t.Fatalf("VerifyEmail() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (preferences.Email != tt.wantEmail || !preferences.EmailVerified) {
+				t.Errorf("VerifyEmail() = %+v, want the confirmed %s", preferences, tt.wantEmail)
+			}
+
+			commands := server.Commands("findAndModify")
+			if len(commands) != 1 {
+				t.Fatalf("sent %d findAndModify commands, want 1", len(commands))
+			}
+			query := commands[0]["query"].(bson.M)
+			expiresAt, _ := query["verificationExpiresAt"].(bson.M)["$gt"].(bson.DateTime)
+			if query["verificationHash"] != "hash" || expiresAt.Time().UTC() != now {
+				t.Errorf("query = %v, want the hash of a confirmation which expires after now", query)
+			}
+			update := commands[0]["update"].(bson.M)
+			if update["$set"].(bson.M)["emailVerified"] != true || update["$unset"].(bson.M)["verificationHash"] == nil {
+				t.Errorf("update = %v, want the address confirmed and the token removed", update)
+			}
+		})
+	}
+}
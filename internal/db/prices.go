@@ -0,0 +1,56 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// createPricesIndex creates a unique index on the "date" field of the prices collection,
+// so that there is only one price series stored per day.
+func (db Mongo) createPricesIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.M{"date": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo prices index error: %s", err.Error())
+	}
+	return nil
+}
+
+// UpsertDailyPrices stores the price series of given date.
+// If the price series of the date already exists, it will be replaced.
+func (db Mongo) UpsertDailyPrices(date string, prices models.PriceSeries) error {
+	filter := bson.D{{Key: "date", Value: date}}
+	document := models.DailyPrices{
+		Date:      date,
+		Prices:    prices,
+		UpdatedAt: time.Now().UTC(),
+	}
+	_, err := db.prices.ReplaceOne(db.ctx, filter, document, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to upsert prices of %s: %s", date, err.Error())
+	}
+	return nil
+}
+
+// GetDailyPrices retrieves the price series of given date.
+// It returns `mongo.ErrNoDocuments` if there is no price series stored for the date.
+func (db Mongo) GetDailyPrices(date string) (*models.DailyPrices, error) {
+	filter := bson.D{{Key: "date", Value: date}}
+	var prices models.DailyPrices
+	if err := db.prices.FindOne(db.ctx, filter).Decode(&prices); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get prices of %s: %s", date, err.Error())
+	}
+	return &prices, nil
+}
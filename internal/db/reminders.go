@@ -0,0 +1,70 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// createRemindersIndex creates an index on the "userId" field of the reminders collection
+func (db Mongo) createRemindersIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys: bson.M{"userId": 1},
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo reminders index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertReminder inserts a new reminder and returns it with generated ID and creation time
+func (db Mongo) InsertReminder(reminder models.Reminder) (*models.Reminder, error) {
+	reminder.ID = bson.NewObjectID()
+	reminder.CreatedAt = time.Now().UTC()
+	if _, err := db.reminders.InsertOne(db.ctx, reminder); err != nil {
+		return nil, fmt.Errorf("failed to insert reminder: %s", err.Error())
+	}
+	return &reminder, nil
+}
+
+// GetReminders retrieves all reminders of a user
+func (db Mongo) GetReminders(userId string) ([]models.Reminder, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	return db.findReminders(filter)
+}
+
+// GetAllReminders retrieves the reminders of all users
+func (db Mongo) GetAllReminders() ([]models.Reminder, error) {
+	return db.findReminders(bson.D{})
+}
+
+func (db Mongo) findReminders(filter bson.D) ([]models.Reminder, error) {
+	cursor, err := db.reminders.Find(db.ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reminders: %s", err.Error())
+	}
+	reminders := make([]models.Reminder, 0)
+	if err = cursor.All(db.ctx, &reminders); err != nil {
+		return nil, fmt.Errorf("failed to decode reminders: %s", err.Error())
+	}
+	return reminders, nil
+}
+
+// DeleteReminder deletes a reminder of a user together with its pending jobs.
+// It returns `mongo.ErrNoDocuments` if the user does not have a reminder with given ID.
+func (db Mongo) DeleteReminder(userId string, id bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "userId", Value: userId}}
+	res, err := db.reminders.DeleteOne(db.ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %s", err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return db.DeletePendingJobs(id.Hex())
+}
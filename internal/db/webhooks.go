@@ -0,0 +1,128 @@
+// AnhCao 2024
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// createWebhooksIndex creates an index on the "userId" field of the webhooks collection
+func (db Mongo) createWebhooksIndex(collection *mongo.Collection) error {
+	indexModel := mongo.IndexModel{
+		Keys: bson.M{"userId": 1},
+	}
+	_, err := collection.Indexes().CreateOne(db.ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("mongo webhooks index error: %s", err.Error())
+	}
+	return nil
+}
+
+// InsertWebhook inserts a new, enabled webhook and returns it with generated ID and creation time
+func (db Mongo) InsertWebhook(webhook models.Webhook) (*models.Webhook, error) {
+	webhook.ID = bson.NewObjectID()
+	webhook.Enabled = true
+	webhook.CreatedAt = time.Now().UTC()
+	if _, err := db.webhooks.InsertOne(db.ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %s", err.Error())
+	}
+	return &webhook, nil
+}
+
+// GetWebhooks retrieves all webhooks of a user
+func (db Mongo) GetWebhooks(userId string) ([]models.Webhook, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	return db.findWebhooks(filter)
+}
+
+// GetEnabledWebhooks retrieves the webhooks of a user which receive notifications
+func (db Mongo) GetEnabledWebhooks(userId string) ([]models.Webhook, error) {
+	filter := bson.D{{Key: "userId", Value: userId}, {Key: "enabled", Value: true}}
+	return db.findWebhooks(filter)
+}
+
+// CountWebhooks returns how many webhooks the user has registered
+func (db Mongo) CountWebhooks(userId string) (int64, error) {
+	filter := bson.D{{Key: "userId", Value: userId}}
+	count, err := db.webhooks.CountDocuments(db.ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %s", err.Error())
+	}
+	return count, nil
+}
+
+func (db Mongo) findWebhooks(filter bson.D) ([]models.Webhook, error) {
+	cursor, err := db.webhooks.Find(db.ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhooks: %s", err.Error())
+	}
+	webhooks := make([]models.Webhook, 0)
+	if err = cursor.All(db.ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %s", err.Error())
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook of a user.
+// It returns `mongo.ErrNoDocuments` if the user does not have a webhook with given ID.
+func (db Mongo) DeleteWebhook(userId string, id bson.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "userId", Value: userId}}
+	res, err := db.webhooks.DeleteOne(db.ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %s", err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RecordWebhookSuccess records a successful delivery to the webhook and resets its consecutive failures
+func (db Mongo) RecordWebhookSuccess(id bson.ObjectID, statusCode int) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.M{
+		"$set": bson.M{
+			"consecutiveFailures": 0,
+			"lastStatusCode":      statusCode,
+			"lastDeliveryAt":      time.Now().UTC(),
+		},
+		"$unset": bson.M{"lastError": ""},
+	}
+	if _, err := db.webhooks.UpdateOne(db.ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to record webhook success: %s", err.Error())
+	}
+	return nil
+}
+
+// RecordWebhookFailure records a failed delivery to the webhook. The webhook is disabled atomically when it reaches
+// given number of consecutive failures, so concurrent deliveries cannot keep it enabled.
+// It returns the updated webhook.
+func (db Mongo) RecordWebhookFailure(id bson.ObjectID, statusCode int, deliveryErr string, maxConsecutiveFailures int) (*models.Webhook, error) {
+	now := time.Now().UTC()
+	filter := bson.D{{Key: "_id", Value: id}}
+	limitReached := bson.M{"$gte": bson.A{"$consecutiveFailures", maxConsecutiveFailures}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"consecutiveFailures": bson.M{"$add": bson.A{"$consecutiveFailures", 1}},
+			"lastStatusCode":      statusCode,
+			"lastError":           deliveryErr,
+			"lastDeliveryAt":      now,
+		}}},
+		// fields of the same stage are computed from the input document, so `disabledAt` sees the previous `enabled`
+		{{Key: "$set", Value: bson.M{
+			"disabledAt": bson.M{"$cond": bson.A{bson.M{"$and": bson.A{limitReached, "$enabled"}}, now, "$disabledAt"}},
+			"enabled":    bson.M{"$cond": bson.A{limitReached, false, "$enabled"}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var webhook models.Webhook
+	if err := db.webhooks.FindOneAndUpdate(db.ctx, filter, update, opts).Decode(&webhook); err != nil {
+		return nil, fmt.Errorf("failed to record webhook failure: %s", err.Error())
+	}
+	return &webhook, nil
+}
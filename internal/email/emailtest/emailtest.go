@@ -0,0 +1,126 @@
+// AnhCao 2024
+//
+// Package emailtest provides a fake SMTP server for the tests of the packages which send emails.
+// The server accepts plain SMTP connections without authentication and records every email sent to it,
+// so a Mailer with the TLS mode "none" can deliver to it.
+package emailtest
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// Message represents an email received by the server
+type Message struct {
+	From string
+	To   []string
+	// Data is the MIME message as written by the client, with CRLF line endings
+	Data string
+}
+
+// Server is a local SMTP server which records the emails sent to it
+type Server struct {
+	listener net.Listener
+	// RejectRecipients makes the server reject every recipient of the emails, ex: an unknown mailbox
+	RejectRecipients bool
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewServer starts a server listening on a random local port
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %s", err.Error())
+	}
+	s := &Server{listener: listener}
+	go s.serve()
+	return s, nil
+}
+
+// Config returns the configuration of a Mailer which sends its emails to the server from given sender
+func (s *Server) Config(from string) *models.Email {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return &models.Email{Host: host, Port: port, From: from, TLS: "none"}
+}
+
+// Messages returns the emails received so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(textproto.NewConn(conn))
+	}
+}
+
+// handle runs the SMTP conversation of a single connection
+func (s *Server) handle(conn *textproto.Conn) {
+	defer conn.Close()
+	var message Message
+	conn.PrintfLine("220 emailtest ESMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			conn.PrintfLine("250 emailtest")
+		case "MAIL":
+			message = Message{From: address(argument)}
+			conn.PrintfLine("250 OK")
+		case "RCPT":
+			if s.RejectRecipients {
+				conn.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			message.To = append(message.To, address(argument))
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			lines, err := conn.ReadDotLines()
+			if err != nil {
+				return
+			}
+			message.Data = strings.Join(lines, "\r\n")
+			s.mu.Lock()
+			s.messages = append(s.messages, message)
+			s.mu.Unlock()
+			conn.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 bye")
+			return
+		default:
+			conn.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// address returns the address of a MAIL FROM or RCPT TO argument, ex: "FROM:<user@example.com>"
+func address(argument string) string {
+	_, address, _ := strings.Cut(argument, ":")
+	address, _, _ = strings.Cut(address, " ")
+	return strings.Trim(address, "<>")
+}
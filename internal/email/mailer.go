@@ -0,0 +1,215 @@
+// AnhCao 2024
+//
+// Package email delivers emails through an SMTP server and renders the emails of the service from templates.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/ratelimit"
+)
+
+// Channel is the name of the delivery channel that emails are reported under
+const Channel string = models.ChannelEmail
+
+// TLS modes of the SMTP connection
+const (
+	TLSStartTLS string = "starttls"
+	TLSImplicit string = "tls"
+	TLSNone     string = "none"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Message represents an email with a plain-text and an HTML alternative of the same content.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends emails through the configured SMTP server
+type Mailer struct {
+	config *models.Email
+	// Budget of the sent emails, nil if not limited
+	limiter *ratelimit.Limiter
+}
+
+// NewMailer creates a new Mailer for given SMTP configuration
+func NewMailer(config *models.Email, limiter *ratelimit.Limiter) *Mailer {
+	return &Mailer{config: config, limiter: limiter}
+}
+
+// Send delivers the email and returns the Message-ID that was assigned to it
+func (m *Mailer) Send(ctx context.Context, message Message) (string, error) {
+	from, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		return "", fmt.Errorf("invalid sender address: %s", err.Error())
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient address: %s", err.Error())
+	}
+	messageId, err := newMessageId(from.Address)
+	if err != nil {
+		return "", err
+	}
+	body, err := m.build(from, to, messageId, message)
+	if err != nil {
+		return "", err
+	}
+
+	if err = m.limiter.Wait(ctx, 1); err != nil {
+		return "", err
+	}
+	client, err := m.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if err = client.Mail(from.Address); err != nil {
+		return "", fmt.Errorf("smtp MAIL FROM failed: %s", err.Error())
+	}
+	if err = client.Rcpt(to.Address); err != nil {
+		return "", fmt.Errorf("smtp RCPT TO failed: %s", err.Error())
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("smtp DATA failed: %s", err.Error())
+	}
+	if _, err = writer.Write(body); err != nil {
+		return "", fmt.Errorf("failed to write email: %s", err.Error())
+	}
+	if err = writer.Close(); err != nil {
+		return "", fmt.Errorf("smtp server rejected email: %s", err.Error())
+	}
+	if err = client.Quit(); err != nil {
+		return "", fmt.Errorf("smtp QUIT failed: %s", err.Error())
+	}
+	return messageId, nil
+}
+
+// dial connects and authenticates to the SMTP server according to the configured TLS mode
+func (m *Mailer) dial(ctx context.Context) (*smtp.Client, error) {
+	timeout := m.config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	address := net.JoinHostPort(m.config.Host, m.config.Port)
+	tlsConfig := &tls.Config{ServerName: m.config.Host}
+	var conn net.Conn
+	var err error
+	if m.config.TLS == TLSImplicit {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %s", err.Error())
+	}
+	// the deadline covers the whole conversation with the server, not only the dial
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create smtp client: %s", err.Error())
+	}
+	switch m.config.TLS {
+	case TLSImplicit, TLSNone:
+	case "", TLSStartTLS:
+		if err = client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS failed: %s", err.Error())
+		}
+	default:
+		client.Close()
+		return nil, fmt.Errorf("unsupported smtp tls mode '%s'", m.config.TLS)
+	}
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err = client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp authentication failed: %s", err.Error())
+		}
+	}
+	return client, nil
+}
+
+// build encodes the email as a multipart/alternative MIME message
+func (m *Mailer) build(from, to *mail.Address, messageId string, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageId,
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", parts.Boundary()),
+	}
+	header := strings.Join(headers, "\r\n") + "\r\n\r\n"
+
+	// the last alternative is the preferred one
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email part: %s", err.Error())
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err = encoder.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to encode email part: %s", err.Error())
+		}
+		if err = encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode email part: %s", err.Error())
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close email: %s", err.Error())
+	}
+	return append([]byte(header), buf.Bytes()...), nil
+}
+
+// newMessageId generates a unique Message-ID in the domain of the sender
+func newMessageId(sender string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %s", err.Error())
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		domain = sender[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain), nil
+}
@@ -0,0 +1,105 @@
+// AnhCao 2024
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/email/emailtest"
+)
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		message Message
+		reject  bool
+		wantErr bool
+	}{
+		{
+			name:    "email is delivered",
+			from:    "Electric <noreply@example.com>",
+			message: Message{To: "user@example.com", Subject: "Cheap hours", Text: "Prices are low", HTML: "<p>Prices are low</p>"},
+		},
+		{
+			name:    "invalid recipient",
+			from:    "noreply@example.com",
+			message: Message{To: "not an address", Subject: "Cheap hours"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid sender",
+			from:    "noreply",
+			message: Message{To: "user@example.com", Subject: "Cheap hours"},
+			wantErr: true,
+		},
+		{
+			name:    "recipient rejected by the server",
+			from:    "noreply@example.com",
+			message: Message{To: "user@example.com", Subject: "Cheap hours"},
+			reject:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := emailtest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+			server.RejectRecipients = tt.reject
+
+			messageId, err := NewMailer(server.Config(tt.from), nil).Send(context.Background(), tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			messages := server.Messages()
+			if tt.wantErr {
+				if len(messages) != 0 {
+					t.Errorf("server received %d emails, want none", len(messages))
+				}
+				return
+			}
+			if len(messages) != 1 {
+				t.Fatalf("server received %d emails, want 1", len(messages))
+			}
+			received := messages[0]
+			if received.From != "noreply@example.com" || len(received.To) != 1 || received.To[0] != tt.message.To {
+				t.Errorf("envelope from %s to %v, want from noreply@example.com to %s", received.From, received.To, tt.message.To)
+			}
+			for _, want := range []string{
+				"Message-ID: " + messageId,
+				"Subject: " + tt.message.Subject,
+				"Content-Type: multipart/alternative",
+				tt.message.Text,
+				tt.message.HTML,
+			} {
+				if !strings.Contains(received.Data, want) {
+					t.Errorf("email does not contain %q:\n%s", want, received.Data)
+				}
+			}
+			if !strings.HasSuffix(messageId, "@example.com>") {
+				t.Errorf("Send() message ID = %s, want one in the domain of the sender", messageId)
+			}
+		})
+	}
+}
+
+func TestSendUnsupportedTLS(t *testing.T) {
+	server, err := emailtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	config := server.Config("noreply@example.com")
+	config.TLS = "ssl"
+
+	if _, err = NewMailer(config, nil).Send(context.Background(), Message{To: "user@example.com"}); err == nil {
+		t.Fatal("Send() error = nil, want an error of the unsupported TLS mode")
+	}
+	if len(server.Messages()) != 0 {
+		t.Errorf("server received %d emails, want none", len(server.Messages()))
+	}
+}
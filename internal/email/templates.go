@@ -0,0 +1,70 @@
+// AnhCao 2024
+package email
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt.tmpl"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html.tmpl"))
+)
+
+const defaultSubject = "Electricity notification"
+
+// notificationData is the data of the notification templates
+type notificationData struct {
+	Title    string
+	Lines    []string
+	ImageURL string
+}
+
+// verificationData is the data of the verification templates
+type verificationData struct {
+	Link string
+}
+
+// RenderNotification renders the email of a notification which is sent to given address
+func RenderNotification(to string, message models.NotificationMessage) (Message, error) {
+	subject := message.Title
+	if subject == "" {
+		subject = defaultSubject
+	}
+	data := notificationData{
+		Title:    subject,
+		Lines:    strings.Split(message.GetBody(), "\n"),
+		ImageURL: message.ImageURL,
+	}
+	return render(to, subject, "notification", data)
+}
+
+// RenderVerification renders the email which asks the user to confirm the address by opening given link
+func RenderVerification(to, link string) (Message, error) {
+	return render(to, "Confirm your email address", "verification", verificationData{Link: link})
+}
+
+// render executes the plain-text and HTML templates of given name
+func render(to, subject, name string, data any) (Message, error) {
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt.tmpl", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html.tmpl", data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      to,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,80 @@
+// AnhCao 2024
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestRenderVerification(t *testing.T) {
+	link := "https://example.com/v1/preferences/email/verify?token=abc&x=1"
+	message, err := RenderVerification("user@example.com", link)
+	if err != nil {
+		t.Fatalf("RenderVerification() error = %v", err)
+	}
+	if message.To != "user@example.com" || message.Subject == "" {
+		t.Errorf("RenderVerification() = %+v, want an email to the user with a subject", message)
+	}
+	if !strings.Contains(message.Text, link) {
+		t.Errorf("text %q does not contain the link", message.Text)
+	}
+	// the link is escaped in the attribute of the HTML alternative
+	if !strings.Contains(message.HTML, `href="https://example.com/v1/preferences/email/verify?token=abc&amp;x=1"`) {
+		t.Errorf("HTML %q does not contain the link", message.HTML)
+	}
+}
+
+func TestRenderNotification(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     models.NotificationMessage
+		wantSubject string
+		wantText    []string
+		wantHTML    []string
+	}{
+		{
+			name:        "title is the subject",
+			message:     models.NotificationMessage{Title: "Cheap hours", Body: "03:00 - 06:00\n2.1 c/kWh"},
+			wantSubject: "Cheap hours",
+			wantText:    []string{"03:00 - 06:00", "2.1 c/kWh"},
+			wantHTML:    []string{"03:00 - 06:00", "2.1 c/kWh"},
+		},
+		{
+			name:        "notification without title",
+			message:     models.NotificationMessage{Body: "Prices are low"},
+			wantSubject: defaultSubject,
+			wantText:    []string{"Prices are low"},
+			wantHTML:    []string{"Prices are low"},
+		},
+		{
+			name:        "HTML of the body is escaped",
+			message:     models.NotificationMessage{Title: "Prices", Body: "<b>low</b>"},
+			wantSubject: "Prices",
+			wantText:    []string{"<b>low</b>"},
+			wantHTML:    []string{"&lt;b&gt;low&lt;/b&gt;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := RenderNotification("user@example.com", tt.message)
+			if err != nil {
+				t.Fatalf("RenderNotification() error = %v", err)
+			}
+			if message.Subject != tt.wantSubject {
+				t.Errorf("RenderNotification() subject = %q, want %q", message.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(message.Text, want) {
+					t.Errorf("text %q does not contain %q", message.Text, want)
+				}
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(message.HTML, want) {
+					t.Errorf("HTML %q does not contain %q", message.HTML, want)
+				}
+			}
+		})
+	}
+}
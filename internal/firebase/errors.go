@@ -0,0 +1,29 @@
+// AnhCao 2024
+package firebase
+
+import "firebase.google.com/go/v4/messaging"
+
+// ErrorCode returns the FCM error code of an error returned by the messaging client, ex: UNREGISTERED.
+// It returns an empty string if the error is nil.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case messaging.IsUnregistered(err):
+		return "UNREGISTERED"
+	case messaging.IsInvalidArgument(err):
+		return "INVALID_ARGUMENT"
+	case messaging.IsSenderIDMismatch(err):
+		return "SENDER_ID_MISMATCH"
+	case messaging.IsQuotaExceeded(err):
+		return "QUOTA_EXCEEDED"
+	case messaging.IsUnavailable(err):
+		return "UNAVAILABLE"
+	case messaging.IsInternal(err):
+		return "INTERNAL"
+	case messaging.IsThirdPartyAuthError(err):
+		return "THIRD_PARTY_AUTH_ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
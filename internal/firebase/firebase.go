@@ -3,27 +3,56 @@ package firebase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
 	"github.com/AnhCaooo/electric-notifications/internal/config"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/ratelimit"
 	"go.uber.org/zap"
 	"google.golang.org/api/option"
 )
 
+// Channel is the name of the delivery channel that FCM push notifications are reported under
+const Channel string = models.ChannelPush
+
+// Keys of the data payload which carry the notification fields to the app,
+// so the app can handle the notification also when it is delivered as a data message
+const (
+	DataKeyTitle       string = "title"
+	DataKeyBody        string = "body"
+	DataKeyImage       string = "image"
+	DataKeyClickAction string = "click_action"
+)
+
+// messagingClient is the part of the FCM messaging client which is used to send messages
+type messagingClient interface {
+	Send(ctx context.Context, message *messaging.Message) (string, error)
+	SendDryRun(ctx context.Context, message *messaging.Message) (string, error)
+	SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+	SendEachForMulticastDryRun(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+}
+
 type Firebase struct {
 	logger       *zap.Logger
-	cloudMessage *messaging.Client
+	cloudMessage messagingClient
 	ctx          context.Context
+	// Platform-specific configuration of push notifications
+	config *models.Push
+	// Budget of the messages sent to FCM, nil if not limited
+	limiter *ratelimit.Limiter
 }
 
 // Initialize new a new Firebase instance
-func NewFirebase(logger *zap.Logger, ctx context.Context) *Firebase {
+func NewFirebase(logger *zap.Logger, ctx context.Context, config *models.Push, limiter *ratelimit.Limiter) *Firebase {
 	return &Firebase{
 		logger:       logger,
 		cloudMessage: nil,
 		ctx:          ctx,
+		config:       config,
+		limiter:      limiter,
 	}
 }
 
@@ -40,27 +69,32 @@ func (fb *Firebase) EstablishConnection() error {
 		return fmt.Errorf("failed to initialize connection with Firebase app: %s", err.Error())
 	}
 	// Get the FCM object
-	fb.cloudMessage, err = app.Messaging(fb.ctx)
+	cloudMessage, err := app.Messaging(fb.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get Messaging client: %s", err.Error())
 	}
+	fb.cloudMessage = cloudMessage
 
 	fb.logger.Info("Successfully connected to Firebase Cloud Message platform")
 	return nil
 }
 
 // Send notification based on a device token
-func (fb Firebase) SendToSingleToken(
-	token, userId, message string,
-) error {
-	// define message payload
+func (fb Firebase) SendToSingleToken(device models.NotificationToken, message models.NotificationMessage) error {
+	notification, data := buildPayload(message)
+	android, apns, webpush := fb.buildPlatformConfigs(device.Platform, message)
 	payload := &messaging.Message{
-		Token: token,
-		Data: map[string]string{
-			message: message,
-		},
+		Token:        device.DeviceId,
+		Notification: notification,
+		Data:         data,
+		Android:      android,
+		APNS:         apns,
+		Webpush:      webpush,
 	}
 	// send a message to the device based on given token
+	if err := fb.limiter.Wait(fb.ctx, 1); err != nil {
+		return fmt.Errorf("error sending notification to single device: %s", err.Error())
+	}
 	_, err := fb.cloudMessage.Send(fb.ctx, payload)
 	if err != nil {
 		return fmt.Errorf("error sending notification to single device: %s", err.Error())
@@ -68,33 +102,120 @@ func (fb Firebase) SendToSingleToken(
 	return nil
 }
 
-// Send notification based on multi device tokens
-func (fb Firebase) SendToMultiTokens(
-	tokens []string,
-	userId, message string,
-) error {
-	payload := &messaging.MulticastMessage{
-		Data: map[string]string{
-			message: message,
-		},
-		Tokens: tokens,
-	}
-	//Send to Multiple Tokens
-	batchResponse, err := fb.cloudMessage.SendEachForMulticast(fb.ctx, payload)
-	if err != nil {
-		return fmt.Errorf("error sending notifications to multi devices: %s", err.Error())
+// Send notification based on multi device tokens and return the result of every token.
+// The devices are grouped by their platform, so each device only receives the configuration of its own platform.
+// Any number of devices is accepted, as the tokens are sent in chunks which FCM accepts, and tokens which fail
+// temporarily are retried. The result of every token is the final outcome after the retries.
+// In dry-run mode FCM only validates the message for every token, which reveals the tokens that would fail.
+func (fb Firebase) SendToMultiTokens(devices []models.NotificationToken, message models.NotificationMessage, dryRun bool) ([]models.DeliveryResult, error) {
+	notification, data := buildPayload(message)
+	results := make([]models.DeliveryResult, 0, len(devices))
+	// devices of a bulk send may belong to different users
+	owners := make(map[string]string, len(devices))
+	for _, device := range devices {
+		owners[device.DeviceId] = device.UserId
 	}
+	var errs []error
+	for platform, tokens := range groupByPlatform(devices) {
+		android, apns, webpush := fb.buildPlatformConfigs(platform, message)
+		payload := &messaging.MulticastMessage{
+			Tokens:       tokens,
+			Notification: notification,
+			Data:         data,
+			Android:      android,
+			APNS:         apns,
+			Webpush:      webpush,
+		}
+		//Send to Multiple Tokens
+		batchResponse, err := fb.SendMulticast(payload, dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error sending notifications to multi devices: %s", err.Error()))
+		}
 
-	// check which tokens resulted in errors
-	if batchResponse.FailureCount > 0 {
+		// check which tokens resulted in errors
 		var failedTokens []string
 		for idx, resp := range batchResponse.Responses {
+			// The order of responses corresponds to the order of the registration tokens.
+			result := models.DeliveryResult{
+				Channel:   Channel,
+				UserId:    owners[tokens[idx]],
+				Recipient: tokens[idx],
+				Success:   resp.Success,
+				MessageId: resp.MessageID,
+				Attempts:  batchResponse.Attempts[idx],
+			}
 			if !resp.Success {
-				// The order of responses corresponds to the order of the registration tokens.
 				failedTokens = append(failedTokens, tokens[idx])
+				result.ErrorCode = ErrorCode(resp.Error)
+				if resp.Error != nil {
+					result.Error = resp.Error.Error()
+				}
 			}
+			results = append(results, result)
+		}
+		if len(failedTokens) > 0 {
+			fb.logger.Error("List of tokens that cause failures", zap.String("platform", platform), zap.Any("tokens", failedTokens))
 		}
-		fb.logger.Error("List of tokens that cause failures", zap.Any("tokens", failedTokens))
 	}
-	return nil
+	return results, errors.Join(errs...)
+}
+
+// Send notification to all devices subscribed to a topic. In dry-run mode FCM only validates the message.
+func (fb Firebase) SendToTopic(topic string, message models.NotificationMessage, dryRun bool) (models.DeliveryResult, error) {
+	notification, data := buildPayload(message)
+	// devices of all platforms may be subscribed to the topic
+	android, apns, webpush := fb.buildPlatformConfigs("", message)
+	payload := &messaging.Message{
+		Topic:        topic,
+		Notification: notification,
+		Data:         data,
+		Android:      android,
+		APNS:         apns,
+		Webpush:      webpush,
+	}
+	result := models.DeliveryResult{Channel: Channel, Recipient: topic}
+	if err := fb.limiter.Wait(fb.ctx, 1); err != nil {
+		result.Error = err.Error()
+		return result, fmt.Errorf("error sending notification to topic: %s", err.Error())
+	}
+	send := fb.cloudMessage.Send
+	if dryRun {
+		send = fb.cloudMessage.SendDryRun
+	}
+	messageID, err := send(fb.ctx, payload)
+	if err != nil {
+		result.ErrorCode = ErrorCode(err)
+		result.Error = err.Error()
+		return result, fmt.Errorf("error sending notification to topic: %s", err.Error())
+	}
+	result.Success = true
+	result.MessageId = messageID
+	return result, nil
+}
+
+// buildPayload builds the notification block that the operating system displays, and the data payload for the app.
+// The data payload contains the custom data of the message together with the notification fields.
+func buildPayload(message models.NotificationMessage) (*messaging.Notification, map[string]string) {
+	notification := &messaging.Notification{
+		Title:    message.Title,
+		Body:     message.GetBody(),
+		ImageURL: message.ImageURL,
+	}
+
+	data := make(map[string]string, len(message.Data)+4)
+	for key, value := range message.Data {
+		data[key] = value
+	}
+	fields := map[string]string{
+		DataKeyTitle:       notification.Title,
+		DataKeyBody:        notification.Body,
+		DataKeyImage:       notification.ImageURL,
+		DataKeyClickAction: message.ClickAction,
+	}
+	for key, value := range fields {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	return notification, data
 }
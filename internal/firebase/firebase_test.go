@@ -1,2 +1,62 @@
 // AnhCao 2024
 package firebase
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestBuildPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   models.NotificationMessage
+		wantTitle string
+		wantBody  string
+		wantData  map[string]string
+	}{
+		{
+			name: "notification fields are shown and given to the app",
+			message: models.NotificationMessage{
+				Title:       "Electricity prices for tomorrow",
+				Body:        "Cheapest at 03:00",
+				ImageURL:    "https://example.com/chart.png",
+				ClickAction: "OPEN_PRICES",
+				Data:        map[string]string{"date": "2024-12-09"},
+			},
+			wantTitle: "Electricity prices for tomorrow",
+			wantBody:  "Cheapest at 03:00",
+			wantData: map[string]string{
+				"date":             "2024-12-09",
+				DataKeyTitle:       "Electricity prices for tomorrow",
+				DataKeyBody:        "Cheapest at 03:00",
+				DataKeyImage:       "https://example.com/chart.png",
+				DataKeyClickAction: "OPEN_PRICES",
+			},
+		},
+		{
+			name:     "deprecated message is the body",
+			message:  models.NotificationMessage{Message: "Hello, World!"},
+			wantBody: "Hello, World!",
+			wantData: map[string]string{DataKeyBody: "Hello, World!"},
+		},
+		{
+			name:      "notification fields replace the data of the same key",
+			message:   models.NotificationMessage{Title: "Prices", Data: map[string]string{DataKeyTitle: "custom", "type": "prices"}},
+			wantTitle: "Prices",
+			wantData:  map[string]string{DataKeyTitle: "Prices", "type": "prices"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification, data := buildPayload(tt.message)
+			if notification.Title != tt.wantTitle || notification.Body != tt.wantBody || notification.ImageURL != tt.message.ImageURL {
+				t.Errorf("buildPayload() notification = %+v, want title %q and body %q", notification, tt.wantTitle, tt.wantBody)
+			}
+			if !maps.Equal(data, tt.wantData) {
+				t.Errorf("buildPayload() data = %v, want %v", data, tt.wantData)
+			}
+		})
+	}
+}
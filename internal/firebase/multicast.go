@@ -0,0 +1,152 @@
+// AnhCao 2024
+package firebase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"go.uber.org/zap"
+)
+
+const (
+	// the most tokens FCM accepts in a single multicast message
+	maxMulticastTokens = 500
+	// how many chunks of a multicast are sent at the same time, if not configured
+	defaultMaxConcurrentBatches = 4
+)
+
+// MulticastResponse is the aggregated response of a multicast message together with the attempts of every token
+type MulticastResponse struct {
+	*messaging.BatchResponse
+	// How many times each token was sent, in the order of the tokens of the message.
+	Attempts []int
+}
+
+// SendMulticast sends the message to any number of tokens. The tokens are split into chunks of at most 500 tokens,
+// which is the limit of FCM, and the chunks are sent concurrently with a bounded number of chunks in flight.
+// Tokens which fail with a transient error (UNAVAILABLE, INTERNAL or QUOTA_EXCEEDED) are sent again according
+// to the retry policy, until they succeed, fail permanently or run out of attempts.
+// The final responses of all tokens are aggregated in the order of the tokens of the message. A chunk which fails
+// as a whole is reported as a failed response for each of its tokens, and its error is also returned.
+// In dry-run mode FCM only validates the message for every token without delivering it.
+func (fb Firebase) SendMulticast(message *messaging.MulticastMessage, dryRun bool) (*MulticastResponse, error) {
+	policy := fb.retryPolicy()
+	responses := make([]*messaging.SendResponse, len(message.Tokens))
+	attempts := make([]int, len(message.Tokens))
+	// error of the whole chunk, if the last attempt of the token failed as a part of such a chunk
+	chunkErrs := make([]error, len(message.Tokens))
+
+	pending := make([]int, len(message.Tokens))
+	for idx := range pending {
+		pending[idx] = idx
+	}
+	for attempt := 1; len(pending) > 0; attempt++ {
+		tokens := make([]string, len(pending))
+		for i, idx := range pending {
+			tokens[i] = message.Tokens[idx]
+		}
+		pendingResponses, pendingErrs := fb.sendChunks(message, tokens, dryRun)
+
+		var retry []int
+		var failed []*messaging.SendResponse
+		for i, idx := range pending {
+			responses[idx], chunkErrs[idx] = pendingResponses[i], pendingErrs[i]
+			attempts[idx]++
+			if !responses[idx].Success && isTransient(responses[idx].Error) {
+				retry = append(retry, idx)
+				failed = append(failed, responses[idx])
+			}
+		}
+		if len(retry) == 0 || attempt >= policy.maxAttempts {
+			break
+		}
+		delay, ok := policy.delay(attempt, failed)
+		if !ok {
+			fb.logger.Warn("FCM asked to retry later than the maximum backoff, giving up", zap.Int("tokens", len(retry)), zap.Duration("retry_after", delay))
+			break
+		}
+		fb.logger.Info("retrying tokens which failed temporarily", zap.Int("tokens", len(retry)), zap.Int("attempt", attempt+1), zap.Duration("delay", delay))
+		select {
+		case <-fb.ctx.Done():
+			retry = nil
+		case <-time.After(delay):
+		}
+		pending = retry
+	}
+
+	aggregated := &MulticastResponse{
+		BatchResponse: &messaging.BatchResponse{Responses: responses},
+		Attempts:      attempts,
+	}
+	var errs []error
+	seen := make(map[error]bool)
+	for idx, resp := range responses {
+		if resp.Success {
+			aggregated.SuccessCount++
+			continue
+		}
+		aggregated.FailureCount++
+		if err := chunkErrs[idx]; err != nil && !seen[err] {
+			seen[err] = true
+			errs = append(errs, err)
+		}
+	}
+	return aggregated, errors.Join(errs...)
+}
+
+// sendChunks sends the message to given tokens in concurrent chunks of at most 500 tokens.
+// It returns the response of every token, together with the error of the whole chunk for the tokens of a failed chunk.
+func (fb Firebase) sendChunks(message *messaging.MulticastMessage, tokens []string, dryRun bool) ([]*messaging.SendResponse, []error) {
+	send := fb.cloudMessage.SendEachForMulticast
+	if dryRun {
+		send = fb.cloudMessage.SendEachForMulticastDryRun
+	}
+	responses := make([]*messaging.SendResponse, len(tokens))
+	chunkErrs := make([]error, len(tokens))
+
+	// semaphore which bounds the number of chunks in flight
+	inFlight := make(chan struct{}, fb.maxConcurrentBatches())
+	var wg sync.WaitGroup
+	for start := 0; start < len(tokens); start += maxMulticastTokens {
+		end := min(start+maxMulticastTokens, len(tokens))
+		chunk := *message
+		chunk.Tokens = tokens[start:end]
+
+		wg.Add(1)
+		inFlight <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			// every token of the chunk is a separate message for FCM
+			err := fb.limiter.Wait(fb.ctx, len(chunk.Tokens))
+			var batchResponse *messaging.BatchResponse
+			if err == nil {
+				batchResponse, err = send(fb.ctx, &chunk)
+			}
+			if err != nil {
+				chunkErr := fmt.Errorf("chunk of %d tokens failed: %s", end-start, err.Error())
+				for idx := start; idx < end; idx++ {
+					responses[idx] = &messaging.SendResponse{Success: false, Error: err}
+					chunkErrs[idx] = chunkErr
+				}
+				return
+			}
+			// the order of the responses corresponds to the order of the tokens of the chunk
+			copy(responses[start:end], batchResponse.Responses)
+		}()
+	}
+	wg.Wait()
+	return responses, chunkErrs
+}
+
+// maxConcurrentBatches returns how many chunks of a multicast may be sent at the same time
+func (fb Firebase) maxConcurrentBatches() int {
+	if fb.config != nil && fb.config.MaxConcurrentBatches > 0 {
+		return fb.config.MaxConcurrentBatches
+	}
+	return defaultMaxConcurrentBatches
+}
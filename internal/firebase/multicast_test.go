@@ -0,0 +1,192 @@
+// AnhCao 2024
+package firebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// stubMessaging replaces FCM: every token gets the response of respond, or succeeds with the message ID "id-<token>"
+type stubMessaging struct {
+	// respond returns the response of given attempt (1 for the first) of the token, nil to succeed
+	respond func(token string, attempt int) *messaging.SendResponse
+	// failChunk returns the error of the whole chunk, nil to send it
+	failChunk func(tokens []string) error
+	// delay of every chunk, so that chunks overlap
+	delay time.Duration
+
+	mu          sync.Mutex
+	chunks      [][]string
+	messages    []*messaging.MulticastMessage
+	dryRuns     int
+	attempts    map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *stubMessaging) Send(context.Context, *messaging.Message) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (s *stubMessaging) SendDryRun(context.Context, *messaging.Message) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (s *stubMessaging) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	return s.send(message, false)
+}
+
+func (s *stubMessaging) SendEachForMulticastDryRun(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	return s.send(message, true)
+}
+
+func (s *stubMessaging) send(message *messaging.MulticastMessage, dryRun bool) (*messaging.BatchResponse, error) {
+	s.mu.Lock()
+	s.chunks = append(s.chunks, append([]string(nil), message.Tokens...))
+	s.messages = append(s.messages, message)
+	if dryRun {
+		s.dryRuns++
+	}
+	if s.attempts == nil {
+		s.attempts = make(map[string]int)
+	}
+	attempts := make([]int, len(message.Tokens))
+	for i, token := range message.Tokens {
+		s.attempts[token]++
+		attempts[i] = s.attempts[token]
+	}
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+
+	if s.failChunk != nil {
+		if err := s.failChunk(message.Tokens); err != nil {
+			return nil, err
+		}
+	}
+	batch := &messaging.BatchResponse{}
+	for i, token := range message.Tokens {
+		var resp *messaging.SendResponse
+		if s.respond != nil {
+			resp = s.respond(token, attempts[i])
+		}
+		if resp == nil {
+			resp = &messaging.SendResponse{Success: true, MessageID: "id-" + token}
+		}
+		if resp.Success {
+			batch.SuccessCount++
+		} else {
+			batch.FailureCount++
+		}
+		batch.Responses = append(batch.Responses, resp)
+	}
+	return batch, nil
+}
+
+func newTestFirebase(stub *stubMessaging, config *models.Push) *Firebase {
+	return &Firebase{
+		logger:       zap.NewNop(),
+		cloudMessage: stub,
+		ctx:          context.Background(),
+		config:       config,
+	}
+}
+
+func testTokens(n int) []string {
+	tokens := make([]string, n)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("token-%d", i)
+	}
+	return tokens
+}
+
+func TestSendChunks(t *testing.T) {
+	chunkErr := errors.New("connection reset")
+	tests := []struct {
+		name       string
+		tokens     int
+		failChunk  func(tokens []string) error
+		wantChunks []int
+		// index of the first and after the last token of the failed chunk
+		failedFrom, failedTo int
+	}{
+		{name: "no tokens", tokens: 0, wantChunks: nil},
+		{name: "one full chunk", tokens: 500, wantChunks: []int{500}},
+		{name: "one token more than a chunk", tokens: 501, wantChunks: []int{500, 1}},
+		{name: "three chunks", tokens: 1001, wantChunks: []int{500, 500, 1}},
+		{
+			name:   "failed chunk",
+			tokens: 1001,
+			failChunk: func(tokens []string) error {
+				if tokens[0] == "token-500" {
+					return chunkErr
+				}
+				return nil
+			},
+			wantChunks: []int{500, 500, 1},
+			failedFrom: 500,
+			failedTo:   1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubMessaging{failChunk: tt.failChunk, delay: 5 * time.Millisecond}
+			fb := newTestFirebase(stub, &models.Push{MaxConcurrentBatches: 2})
+			tokens := testTokens(tt.tokens)
+
+			responses, errs := fb.sendChunks(&messaging.MulticastMessage{Tokens: tokens}, tokens, false)
+
+			if len(responses) != tt.tokens || len(errs) != tt.tokens {
+				t.Fatalf("got %d responses and %d errors, want %d of each", len(responses), len(errs), tt.tokens)
+			}
+			sizes := make(map[int]int)
+			for _, chunk := range stub.chunks {
+				sizes[len(chunk)]++
+			}
+			wantSizes := make(map[int]int)
+			for _, size := range tt.wantChunks {
+				wantSizes[size]++
+			}
+			if len(stub.chunks) != len(tt.wantChunks) || fmt.Sprint(sizes) != fmt.Sprint(wantSizes) {
+				t.Errorf("sent chunks of sizes %v, want %v", sizes, wantSizes)
+			}
+			if stub.maxInFlight > 2 {
+				t.Errorf("%d chunks were in flight at the same time, want at most 2", stub.maxInFlight)
+			}
+			for i, token := range tokens {
+				failed := i >= tt.failedFrom && i < tt.failedTo
+				switch {
+				case failed && (responses[i].Success || !errors.Is(responses[i].Error, chunkErr) || errs[i] == nil):
+					t.Errorf("response of %s = %+v with chunk error %v, want the error of the failed chunk", token, responses[i], errs[i])
+				case !failed && (!responses[i].Success || responses[i].MessageID != "id-"+token || errs[i] != nil):
+					t.Errorf("response of %s = %+v with chunk error %v, want its own success", token, responses[i], errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSendChunksDryRun(t *testing.T) {
+	stub := &stubMessaging{}
+	fb := newTestFirebase(stub, nil)
+	tokens := testTokens(501)
+
+	fb.sendChunks(&messaging.MulticastMessage{Tokens: tokens}, tokens, true)
+	if stub.dryRuns != 2 {
+		t.Errorf("%d of %d chunks were sent in dry-run mode, want all", stub.dryRuns, len(stub.chunks))
+	}
+}
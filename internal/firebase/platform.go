@@ -0,0 +1,95 @@
+// AnhCao 2024
+package firebase
+
+import (
+	"strings"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// groupByPlatform groups the device tokens by the platform of the device.
+// Tokens registered without a platform are grouped under an empty platform.
+func groupByPlatform(devices []models.NotificationToken) map[string][]string {
+	groups := make(map[string][]string)
+	for _, device := range devices {
+		platform := strings.ToLower(device.Platform)
+		groups[platform] = append(groups[platform], device.DeviceId)
+	}
+	return groups
+}
+
+// buildPlatformConfigs builds the platform-specific configuration for the devices of given platform.
+// Devices without a known platform receive the configuration of every platform, since each platform
+// ignores the configuration of the others.
+func (fb Firebase) buildPlatformConfigs(
+	platform string,
+	message models.NotificationMessage,
+) (*messaging.AndroidConfig, *messaging.APNSConfig, *messaging.WebpushConfig) {
+	config := fb.config.Resolve(message.Category, message.PlatformOverrides)
+	if message.Badge != nil {
+		// the unread count of the inbox replaces the configured badge
+		config.APNS.Badge = message.Badge
+		config.Android.NotificationCount = message.Badge
+	}
+	switch platform {
+	case models.PlatformAndroid:
+		return buildAndroidConfig(config.Android, message), nil, nil
+	case models.PlatformIOS:
+		return nil, buildAPNSConfig(config.APNS, message), nil
+	case models.PlatformWeb:
+		return nil, nil, buildWebpushConfig(config.Webpush, message)
+	default:
+		return buildAndroidConfig(config.Android, message), buildAPNSConfig(config.APNS, message), buildWebpushConfig(config.Webpush, message)
+	}
+}
+
+// buildAndroidConfig sets the priority, channel, sound, color, count and click action of the Android notification
+func buildAndroidConfig(config models.AndroidPush, message models.NotificationMessage) *messaging.AndroidConfig {
+	return &messaging.AndroidConfig{
+		Priority: config.Priority,
+		Notification: &messaging.AndroidNotification{
+			ChannelID:         config.ChannelID,
+			Sound:             config.Sound,
+			Color:             config.Color,
+			ClickAction:       message.ClickAction,
+			NotificationCount: config.NotificationCount,
+		},
+	}
+}
+
+// buildAPNSConfig sets the sound, badge and thread of the iOS notification, together with the click action as notification category.
+// When the message has an image, the notification is marked as mutable so the notification service extension can attach it.
+func buildAPNSConfig(config models.APNSPush, message models.NotificationMessage) *messaging.APNSConfig {
+	apnsConfig := &messaging.APNSConfig{
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				Sound:          config.Sound,
+				Badge:          config.Badge,
+				ThreadID:       config.ThreadID,
+				Category:       message.ClickAction,
+				MutableContent: message.ImageURL != "",
+			},
+		},
+	}
+	if message.ImageURL != "" {
+		apnsConfig.FCMOptions = &messaging.APNSFCMOptions{ImageURL: message.ImageURL}
+	}
+	return apnsConfig
+}
+
+// buildWebpushConfig sets the icon and badge of the web notification. The click action is used as the link
+// which is opened when the user clicks on the notification, as long as it is an HTTPS URL.
+func buildWebpushConfig(config models.WebPush, message models.NotificationMessage) *messaging.WebpushConfig {
+	webpushConfig := &messaging.WebpushConfig{
+		Notification: &messaging.WebpushNotification{
+			Icon:  config.Icon,
+			Badge: config.Badge,
+			Image: message.ImageURL,
+		},
+	}
+	if strings.HasPrefix(message.ClickAction, "https://") {
+		webpushConfig.FCMOptions = &messaging.WebpushFCMOptions{Link: message.ClickAction}
+	}
+	return webpushConfig
+}
@@ -0,0 +1,96 @@
+// AnhCao 2024
+package firebase
+
+import (
+	"testing"
+
+	"firebase.google.com/go/v4/messaging"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestSendToMultiTokensPlatformConfig(t *testing.T) {
+	config := &models.Push{
+		Defaults: models.PlatformConfig{
+			Android: models.AndroidPush{Priority: "normal", ChannelID: "general"},
+			APNS:    models.APNSPush{Sound: "default"},
+			Webpush: models.WebPush{Icon: "https://example.com/icon.png"},
+		},
+		Categories: map[string]models.PlatformConfig{
+			"reminder": {
+				Android: models.AndroidPush{Priority: "high", ChannelID: "reminders"},
+				APNS:    models.APNSPush{ThreadID: "reminders"},
+			},
+		},
+	}
+	devices := []models.NotificationToken{
+		{DeviceId: "android-token", Platform: models.PlatformAndroid},
+		{DeviceId: "ios-token", Platform: "iOS"},
+		{DeviceId: "web-token", Platform: models.PlatformWeb},
+		{DeviceId: "legacy-token"},
+	}
+	message := models.NotificationMessage{
+		Title:       "Reminder",
+		ClickAction: "https://example.com/prices",
+		Category:    "reminder",
+		// the message overrides the sound of its category on iOS
+		PlatformOverrides: &models.PlatformConfig{APNS: models.APNSPush{Sound: "chime.caf"}},
+	}
+
+	stub := &stubMessaging{}
+	results, err := newTestFirebase(stub, config).SendToMultiTokens(devices, message, false)
+	if err != nil {
+		t.Fatalf("SendToMultiTokens() error = %v", err)
+	}
+	if len(results) != len(devices) {
+		t.Fatalf("SendToMultiTokens() results = %+v, want one per device", results)
+	}
+
+	sent := make(map[string]*messaging.MulticastMessage)
+	for _, message := range stub.messages {
+		if len(message.Tokens) != 1 {
+			t.Fatalf("message sent to %v, want every platform sent separately", message.Tokens)
+		}
+		sent[message.Tokens[0]] = message
+	}
+	if len(sent) != len(devices) {
+		t.Fatalf("messages sent to %d devices, want %d", len(sent), len(devices))
+	}
+	checkAndroid := func(token string, config *messaging.AndroidConfig) {
+		if config == nil || config.Priority != "high" || config.Notification.ChannelID != "reminders" {
+			t.Errorf("%s: Android config = %+v, want the high priority reminders channel", token, config)
+		}
+	}
+	checkAPNS := func(token string, config *messaging.APNSConfig) {
+		if config == nil || config.Payload.Aps.Sound != "chime.caf" || config.Payload.Aps.ThreadID != "reminders" {
+			t.Errorf("%s: APNs config = %+v, want the sound of the message in the reminders thread", token, config)
+		}
+	}
+	checkWebpush := func(token string, config *messaging.WebpushConfig) {
+		if config == nil || config.Notification.Icon != "https://example.com/icon.png" || config.FCMOptions.Link != message.ClickAction {
+			t.Errorf("%s: Webpush config = %+v, want the default icon linked to the click action", token, config)
+		}
+	}
+
+	// every device only receives the configuration of its platform
+	if android := sent["android-token"]; android.APNS != nil || android.Webpush != nil {
+		t.Errorf("Android message has the configuration of other platforms: %+v", android)
+	} else {
+		checkAndroid("android-token", android.Android)
+	}
+	if ios := sent["ios-token"]; ios.Android != nil || ios.Webpush != nil {
+		t.Errorf("iOS message has the configuration of other platforms: %+v", ios)
+	} else {
+		checkAPNS("ios-token", ios.APNS)
+	}
+	if web := sent["web-token"]; web.Android != nil || web.APNS != nil {
+		t.Errorf("web message has the configuration of other platforms: %+v", web)
+	} else {
+		checkWebpush("web-token", web.Webpush)
+	}
+	// devices registered without a platform receive the configuration of every platform
+	legacy := sent["legacy-token"]
+	checkAndroid("legacy-token", legacy.Android)
+	checkAPNS("legacy-token", legacy.APNS)
+	checkWebpush("legacy-token", legacy.Webpush)
+}
@@ -0,0 +1,97 @@
+// AnhCao 2024
+package firebase
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"firebase.google.com/go/v4/errorutils"
+	"firebase.google.com/go/v4/messaging"
+)
+
+// Defaults of the retry policy of transient FCM failures
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMaxBackoff     = 30 * time.Second
+)
+
+// isTransient reports whether sending to a token failed temporarily, so sending again later may succeed
+func isTransient(err error) bool {
+	return messaging.IsUnavailable(err) || messaging.IsInternal(err) || messaging.IsQuotaExceeded(err)
+}
+
+// retryAfter returns the delay that FCM asked for in the Retry-After header of the failed response,
+// either in seconds or as an HTTP date. It returns zero if there is no such header.
+func retryAfter(err error) time.Duration {
+	resp := errorutils.HTTPResponse(err)
+	if resp == nil {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// retryPolicy decides how transient failures are retried
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// retryPolicy returns the configured retry policy, falling back to defaults for zero values
+func (fb Firebase) retryPolicy() retryPolicy {
+	policy := retryPolicy{
+		maxAttempts:    defaultRetryMaxAttempts,
+		initialBackoff: defaultRetryInitialBackoff,
+		maxBackoff:     defaultRetryMaxBackoff,
+	}
+	if fb.config == nil {
+		return policy
+	}
+	if fb.config.Retry.MaxAttempts > 0 {
+		policy.maxAttempts = fb.config.Retry.MaxAttempts
+	}
+	if fb.config.Retry.InitialBackoff > 0 {
+		policy.initialBackoff = fb.config.Retry.InitialBackoff
+	}
+	if fb.config.Retry.MaxBackoff > 0 {
+		policy.maxBackoff = fb.config.Retry.MaxBackoff
+	}
+	return policy
+}
+
+// backoff returns the delay before given retry (1 for the first retry): exponential backoff with jitter,
+// so that retries of many senders do not hit FCM at the same time
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.initialBackoff << (retry - 1)
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	// equal jitter: between a half and the full delay
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// delay returns how long to wait before given retry of the failed responses, honouring the longest Retry-After
+// of their errors. It returns false if FCM asked to wait longer than the maximum backoff, in which case the
+// failures are final.
+func (p retryPolicy) delay(retry int, failed []*messaging.SendResponse) (time.Duration, bool) {
+	delay := p.backoff(retry)
+	for _, resp := range failed {
+		if after := retryAfter(resp.Error); after > delay {
+			delay = after
+		}
+	}
+	return delay, delay <= p.maxBackoff
+}
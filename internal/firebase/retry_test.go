@@ -0,0 +1,195 @@
+// AnhCao 2024
+package firebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// fcmError returns the error which the messaging client returns when FCM responds with given status code,
+// FCM error code and Retry-After header, so that the errors are classified exactly as in production
+func fcmError(t *testing.T, statusCode int, code, retryAfter string) error {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		fmt.Fprintf(w, `{"error":{"code":%d,"message":"%s","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"%s"}]}}`, statusCode, code, code)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: "test"}, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("failed to create Firebase app: %v", err)
+	}
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		t.Fatalf("failed to create messaging client: %v", err)
+	}
+	_, err = client.Send(ctx, &messaging.Message{Token: "token"})
+	if err == nil {
+		t.Fatalf("FCM responded with %d %s but sending succeeded", statusCode, code)
+	}
+	return err
+}
+
+func TestSendMulticastRetry(t *testing.T) {
+	errInternal := fcmError(t, http.StatusInternalServerError, "INTERNAL", "")
+	errUnregistered := fcmError(t, http.StatusNotFound, "UNREGISTERED", "")
+	errQuotaExceeded := fcmError(t, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "1")
+	// the messaging client only gives up on UNAVAILABLE itself if FCM asks to wait longer than 2 minutes
+	errUnavailable := fcmError(t, http.StatusServiceUnavailable, "UNAVAILABLE", "3600")
+	for err, transient := range map[error]bool{errInternal: true, errUnregistered: false, errQuotaExceeded: true, errUnavailable: true} {
+		if isTransient(err) != transient {
+			t.Fatalf("isTransient(%v) = %v, want %v", err, !transient, transient)
+		}
+	}
+	fail := func(err error) *messaging.SendResponse { return &messaging.SendResponse{Success: false, Error: err} }
+	fastRetry := models.Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	tests := []struct {
+		name    string
+		retry   models.Retry
+		respond func(token string, attempt int) *messaging.SendResponse
+		// chunk error of the first attempt
+		chunkErr     error
+		wantAttempts []int
+		wantSuccess  []bool
+		wantErr      bool
+		minElapsed   time.Duration
+	}{
+		{
+			name:         "success is not retried",
+			retry:        fastRetry,
+			wantAttempts: []int{1, 1},
+			wantSuccess:  []bool{true, true},
+		},
+		{
+			name:  "transient failure is retried until it succeeds",
+			retry: fastRetry,
+			respond: func(token string, attempt int) *messaging.SendResponse {
+				if token == "token-0" && attempt < 3 {
+					return fail(errInternal)
+				}
+				return nil
+			},
+			wantAttempts: []int{3, 1},
+			wantSuccess:  []bool{true, true},
+		},
+		{
+			name:  "permanent failure is not retried",
+			retry: fastRetry,
+			respond: func(token string, attempt int) *messaging.SendResponse {
+				if token == "token-1" {
+					return fail(errUnregistered)
+				}
+				return nil
+			},
+			wantAttempts: []int{1, 1},
+			wantSuccess:  []bool{true, false},
+		},
+		{
+			name:  "transient failure gives up after the maximum attempts",
+			retry: fastRetry,
+			respond: func(token string, attempt int) *messaging.SendResponse {
+				return fail(errInternal)
+			},
+			wantAttempts: []int{3, 3},
+			wantSuccess:  []bool{false, false},
+		},
+		{
+			name:  "single attempt",
+			retry: models.Retry{MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+			respond: func(token string, attempt int) *messaging.SendResponse {
+				return fail(errInternal)
+			},
+			wantAttempts: []int{1, 1},
+			wantSuccess:  []bool{false, false},
+		},
+		{
+			name:  "retry waits as long as Retry-After",
+			retry: models.Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Second},
+			respond: func(token string, attempt int) *messaging.SendResponse {
+				if token == "token-0" && attempt == 1 {
+					return fail(errQuotaExceeded)
+				}
+				return nil
+			},
+			wantAttempts: []int{2, 1},
+			wantSuccess:  []bool{true, true},
+			minElapsed:   time.Second,
+		},
+		{
+			name:  "Retry-After longer than the maximum backoff is final",
+			retry: fastRetry,
+			respond: func(token string, attempt int) *messaging.SendResponse {
+				if token == "token-0" {
+					return fail(errUnavailable)
+				}
+				return nil
+			},
+			wantAttempts: []int{1, 1},
+			wantSuccess:  []bool{false, true},
+		},
+		{
+			name:         "failed chunk is not retried",
+			retry:        fastRetry,
+			chunkErr:     errors.New("connection reset"),
+			wantAttempts: []int{1, 1},
+			wantSuccess:  []bool{false, false},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubMessaging{respond: tt.respond}
+			if tt.chunkErr != nil {
+				stub.failChunk = func([]string) error { return tt.chunkErr }
+			}
+			fb := newTestFirebase(stub, &models.Push{Retry: tt.retry})
+			tokens := testTokens(len(tt.wantAttempts))
+
+			start := time.Now()
+			resp, err := fb.SendMulticast(&messaging.MulticastMessage{Tokens: tokens}, false)
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SendMulticast() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("SendMulticast() took %s, want at least %s", elapsed, tt.minElapsed)
+			}
+			for i, token := range tokens {
+				if resp.Attempts[i] != tt.wantAttempts[i] || stub.attempts[token] != tt.wantAttempts[i] {
+					t.Errorf("%s was attempted %d times (FCM saw %d), want %d", token, resp.Attempts[i], stub.attempts[token], tt.wantAttempts[i])
+				}
+				if resp.Responses[i].Success != tt.wantSuccess[i] {
+					t.Errorf("%s succeeded = %v, want %v", token, resp.Responses[i].Success, tt.wantSuccess[i])
+				}
+			}
+			wantSuccessCount := 0
+			for _, success := range tt.wantSuccess {
+				if success {
+					wantSuccessCount++
+				}
+			}
+			if resp.SuccessCount != wantSuccessCount || resp.FailureCount != len(tokens)-wantSuccessCount {
+				t.Errorf("SuccessCount = %d, FailureCount = %d, want %d and %d", resp.SuccessCount, resp.FailureCount, wantSuccessCount, len(tokens)-wantSuccessCount)
+			}
+		})
+	}
+}
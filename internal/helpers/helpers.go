@@ -3,7 +3,13 @@ package helpers
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	// the time zone of the market is available also when the system has no time zone database
+	_ "time/tzdata"
 
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
 )
 
@@ -14,3 +20,91 @@ import (
 func GenerateNotificationMessageForSpotPrice(data *models.PricesMessage) string {
 	return fmt.Sprintf("Tomorrow price is %f", data.Data.Tomorrow.Prices.Data[0].Price)
 }
+
+// GetPriceSeriesDate returns the date (in format YYYY-MM-DD) which the given price series belongs to.
+// The date is taken from the local time of the first entry of the series.
+func GetPriceSeriesDate(series models.PriceSeries) (string, error) {
+	if len(series.Data) == 0 {
+		return "", fmt.Errorf("price series is empty")
+	}
+	t, err := time.Parse(constants.PriceTimeLayout, series.Data[0].Time)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse price time '%s': %s", series.Data[0].Time, err.Error())
+	}
+	return t.Format(constants.DateLayout), nil
+}
+
+// marketLocation is the time zone of the electricity market
+var marketLocation = mustLoadLocation(constants.MarketTimeZone)
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load time zone %s: %s", name, err.Error()))
+	}
+	return location
+}
+
+// MarketDates returns today's and tomorrow's date (in format YYYY-MM-DD) of the electricity market at given time.
+// The price series are stored by the local dates of the market (see GetPriceSeriesDate), which differ from
+// the UTC dates around midnight.
+func MarketDates(now time.Time) (today string, tomorrow string) {
+	local := now.In(marketLocation)
+	return local.Format(constants.DateLayout), local.AddDate(0, 0, 1).Format(constants.DateLayout)
+}
+
+// BuildPriceChartURL returns the public URL of the price chart image of given date.
+// It returns an empty string if the public URL of the service is not configured.
+func BuildPriceChartURL(publicURL, date string) string {
+	if publicURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/v1/prices/%s/chart.png", strings.TrimSuffix(publicURL, "/"), date)
+}
+
+// BuildEmailVerificationURL returns the public URL which confirms the email address of a user with given token.
+// It returns an empty string if the public URL of the service is not configured.
+func BuildEmailVerificationURL(publicURL, token string) string {
+	if publicURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/v1/preferences/email/verify?token=%s", strings.TrimSuffix(publicURL, "/"), url.QueryEscape(token))
+}
+
+// GenerateRecommendationsMessage generates the part of the daily notification which tells when to run the appliances of a user,
+// for example: "Dishwasher: start at 02:00 (~3.12 c)". It returns an empty string if there are no recommendations.
+func GenerateRecommendationsMessage(recommendations []models.Recommendation) string {
+	lines := make([]string, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		startTime := recommendation.StartTime
+		if t, err := time.Parse(constants.PriceTimeLayout, recommendation.StartTime); err == nil {
+			startTime = t.Format("15:04")
+		}
+		lines = append(lines, fmt.Sprintf("%s: start at %s (~%.2f %s)", recommendation.Name, startTime, recommendation.EstimatedCost, recommendation.Unit))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GenerateChargingPlanMessage generates a notification message for an electric car charging plan, for example:
+// "Charge 30.0 kWh for ~52.40 c: 01:00 (11.0 kWh), 02:00 (11.0 kWh), 04:00 (8.0 kWh)"
+func GenerateChargingPlanMessage(plan *models.ChargingPlan) string {
+	slots := make([]string, 0, len(plan.Schedule))
+	for _, slot := range plan.Schedule {
+		startTime := slot.StartTime
+		if t, err := time.Parse(constants.PriceTimeLayout, slot.StartTime); err == nil {
+			startTime = t.Format("15:04")
+		}
+		slots = append(slots, fmt.Sprintf("%s (%.1f kWh)", startTime, slot.EnergyKWh))
+	}
+	message := fmt.Sprintf("Charge %.1f kWh for ~%.2f %s: %s", plan.TotalEnergyKWh, plan.TotalCost, plan.Unit, strings.Join(slots, ", "))
+	if !plan.Fulfilled {
+		message += ". Not everything fits before the deadline"
+	}
+	return message
+}
+
+// GenerateFixedContractHint generates a hint which tells that the fixed-price contract would be cheaper than the spot contract, for example:
+// "Your fixed price of 8.50 c/kWh would be cheaper tomorrow than spot (avg 9.12 c/kWh)"
+func GenerateFixedContractHint(fixedPrice, spotAverage float64, unit string) string {
+	return fmt.Sprintf("Your fixed price of %.2f %s would be cheaper tomorrow than spot (avg %.2f %s)", fixedPrice, unit, spotAverage, unit)
+}
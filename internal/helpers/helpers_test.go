@@ -1,2 +1,55 @@
 // AnhCao 2024
 package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarketDates(t *testing.T) {
+	tests := []struct {
+		name         string
+		now          time.Time
+		wantToday    string
+		wantTomorrow string
+	}{
+		{
+			name:         "afternoon",
+			now:          time.Date(2024, 12, 9, 12, 0, 0, 0, time.UTC),
+			wantToday:    "2024-12-09",
+			wantTomorrow: "2024-12-10",
+		},
+		{
+			name:         "after local midnight, before UTC midnight",
+			now:          time.Date(2024, 12, 9, 22, 30, 0, 0, time.UTC),
+			wantToday:    "2024-12-10",
+			wantTomorrow: "2024-12-11",
+		},
+		{
+			name:         "one minute before local midnight in summer time",
+			now:          time.Date(2025, 6, 30, 20, 59, 0, 0, time.UTC),
+			wantToday:    "2025-06-30",
+			wantTomorrow: "2025-07-01",
+		},
+		{
+			name:         "local midnight in summer time",
+			now:          time.Date(2025, 6, 30, 21, 0, 0, 0, time.UTC),
+			wantToday:    "2025-07-01",
+			wantTomorrow: "2025-07-02",
+		},
+		{
+			name:         "last day of the month",
+			now:          time.Date(2024, 12, 31, 22, 0, 0, 0, time.UTC),
+			wantToday:    "2025-01-01",
+			wantTomorrow: "2025-01-02",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			today, tomorrow := MarketDates(tt.now)
+			if today != tt.wantToday || tomorrow != tt.wantTomorrow {
+				t.Errorf("MarketDates() = %s, %s, want %s, %s", today, tomorrow, tt.wantToday, tt.wantTomorrow)
+			}
+		})
+	}
+}
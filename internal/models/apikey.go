@@ -0,0 +1,73 @@
+// AnhCao 2024
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Scopes of the API keys, which decide the endpoints that a service may use
+const (
+	// ScopeNotificationsSend allows sending and scheduling notifications to any user
+	ScopeNotificationsSend string = "notifications:send"
+	// ScopeDeliveriesRead allows reading the delivery log of any user
+	ScopeDeliveriesRead string = "deliveries:read"
+)
+
+// APIKeyScopes are all the scopes which an API key may have
+var APIKeyScopes = []string{ScopeNotificationsSend, ScopeDeliveriesRead}
+
+// APIKey represents the key of another backend service, with which the service calls the API on behalf of any user.
+// Only the hash of the key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID bson.ObjectID `bson:"_id" json:"id" example:"6790b1c2d3e4f5a6b7c8d9e0"`
+	// Name of the service which uses the key. It is recorded in the delivery log of the notifications that the service sends.
+	Name string `bson:"name" json:"name" example:"billing"`
+	// Beginning of the key, so the key can be recognized without revealing it.
+	Prefix string `bson:"prefix" json:"prefix" example:"en_Xk3v9QaB"`
+	// SHA-256 hash of the key.
+	Hash string `bson:"hash" json:"-"`
+	// What the service may do with the key (ex: notifications:send).
+	Scopes []string `bson:"scopes" json:"scopes" example:"notifications:send"`
+	// Identifier of the admin who created the key, empty if it was created with the command line.
+	CreatedBy string `bson:"createdBy,omitempty" json:"createdBy,omitempty" example:"1234567890"`
+	// When the key was created, last used and revoked. A revoked key is not accepted anymore.
+	CreatedAt  time.Time  `bson:"createdAt" json:"createdAt" example:"2025-01-22T10:00:00Z"`
+	LastUsedAt *time.Time `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty" example:"2025-01-22T12:00:00Z"`
+	RevokedAt  *time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty" example:"2025-02-01T08:00:00Z"`
+}
+
+// APIKeyRequest represents the request to create an API key for a service.
+type APIKeyRequest struct {
+	// Name of the service which uses the key.
+	Name string `json:"name" example:"billing"`
+	// What the service may do with the key, any of: notifications:send, deliveries:read.
+	Scopes []string `json:"scopes" example:"notifications:send"`
+}
+
+// Validate checks that the request has a name and only known scopes
+func (r APIKeyRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("`name` is required")
+	}
+	if len(r.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range r.Scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			return fmt.Errorf("unknown scope '%s', expected any of: %s", scope, strings.Join(APIKeyScopes, ", "))
+		}
+	}
+	return nil
+}
+
+// CreatedAPIKey represents a new API key together with the key itself, which is not available later on.
+type CreatedAPIKey struct {
+	APIKey
+	// The key, which the service sends in the `X-API-Key` header.
+	Key string `json:"key" example:"en_Xk3v9QaB7tLmN2pR5sUvW8yZ1cEfHjK4"`
+}
@@ -0,0 +1,44 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Appliance represents a household appliance which usage the user wants to schedule to the cheapest hours.
+type Appliance struct {
+	// Unique identifier for the appliance.
+	ID bson.ObjectID `bson:"_id" json:"id" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// Identifier of the user who owns the appliance.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// Name of the appliance.
+	Name string `bson:"name" json:"name" example:"Dishwasher"`
+	// How many hours a single run of the appliance takes, in whole hours between 1 and 24 (ex: a run of 1.5 hours is given as 2).
+	DurationHours int `bson:"durationHours" json:"durationHours" example:"2"`
+	// How much energy a single run of the appliance consumes in kWh.
+	EnergyKWh float64 `bson:"energyKWh" json:"energyKWh" example:"1.2"`
+	// The earliest local time (HH:MM) when the appliance may start.
+	AllowedFrom string `bson:"allowedFrom" json:"allowedFrom" example:"22:00"`
+	// The local time (HH:MM) by which the run must be finished. It may be on the next day, ex: from 22:00 to 07:00.
+	AllowedTo string `bson:"allowedTo" json:"allowedTo" example:"07:00"`
+	// The time when the appliance was created.
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt" example:"2025-01-02 14:00:00 +0200 EET"`
+}
+
+// Recommendation represents the cheapest time to run an appliance.
+type Recommendation struct {
+	// Identifier of the appliance.
+	ApplianceId string `json:"applianceId" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// Name of the appliance.
+	Name string `json:"name" example:"Dishwasher"`
+	// The local time when the appliance should be started.
+	StartTime string `json:"startTime" example:"2024-12-09 02:00:00"`
+	// The local time when the run is finished.
+	EndTime string `json:"endTime" example:"2024-12-09 04:00:00"`
+	// Estimated cost of the run, in the currency unit of the price series (ex: c).
+	EstimatedCost float64 `json:"estimatedCost" example:"3.12"`
+	// Currency unit of the estimated cost.
+	Unit string `json:"unit" example:"c"`
+}
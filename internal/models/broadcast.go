@@ -0,0 +1,90 @@
+// AnhCao 2024
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// BroadcastStatus represents the state of the fan-out of a broadcast.
+type BroadcastStatus string
+
+const (
+	BroadcastPending BroadcastStatus = "pending" // the broadcast waits for the scheduler
+	BroadcastRunning BroadcastStatus = "running" // the broadcast is being sent to the users
+	BroadcastDone    BroadcastStatus = "done"    // the broadcast has been sent to every user
+	BroadcastFailed  BroadcastStatus = "failed"  // the broadcast was stopped because of an error
+)
+
+// Segment represents the users who receive a broadcast. Every filter which is set must match,
+// and a filter matches if any of its values matches. An empty segment targets every user.
+// If any device filter (platform, locale, app version or area) is set, the broadcast is only sent
+// to the matching devices of the users, and not through the channels without devices (ex: email).
+type Segment struct {
+	// Platforms of the devices (ex: android).
+	Platforms []string `bson:"platforms,omitempty" json:"platforms,omitempty" example:"android,ios"`
+	// Languages of the app on the devices (ex: fi-FI).
+	Locales []string `bson:"locales,omitempty" json:"locales,omitempty" example:"fi-FI"`
+	// Versions of the app on the devices (ex: 1.4.0).
+	AppVersions []string `bson:"appVersions,omitempty" json:"appVersions,omitempty" example:"1.4.0"`
+	// Electricity price areas of the devices (ex: FI).
+	Areas []string `bson:"areas,omitempty" json:"areas,omitempty" example:"FI"`
+	// Category of broadcasts which the users opted in to (ex: tips).
+	Category string `bson:"category,omitempty" json:"category,omitempty" example:"tips"`
+}
+
+// FiltersDevices reports whether the segment has any filter on the devices
+func (s Segment) FiltersDevices() bool {
+	return len(s.Platforms) > 0 || len(s.Locales) > 0 || len(s.AppVersions) > 0 || len(s.Areas) > 0
+}
+
+// MatchesDevice reports whether the device matches every device filter of the segment
+func (s Segment) MatchesDevice(device NotificationToken) bool {
+	matches := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+	return matches(s.Platforms, device.Platform) && matches(s.Locales, device.Locale) &&
+		matches(s.AppVersions, device.AppVersion) && matches(s.Areas, device.Area)
+}
+
+// BroadcastRequest represents a notification to be sent to every user of a segment.
+type BroadcastRequest struct {
+	Segment Segment `json:"segment"`
+	// The notification. The user ID of the message is ignored, as every user receives the notification.
+	Message NotificationMessage `json:"message"`
+}
+
+// BroadcastPreview represents how many users a segment targets.
+type BroadcastPreview struct {
+	Recipients int `json:"recipients" example:"1250"`
+}
+
+// Broadcast represents a notification which is sent to every user of a segment in the background, together with its progress.
+type Broadcast struct {
+	ID bson.ObjectID `bson:"_id" json:"id" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// The users who receive the broadcast.
+	Segment Segment `bson:"segment" json:"segment"`
+	// The notification which is sent.
+	Message NotificationMessage `bson:"message" json:"message"`
+	// The current state of the broadcast.
+	Status BroadcastStatus `bson:"status" json:"status" example:"running"`
+	// How many users the segment targets, known once the sending has started.
+	Total int `bson:"total" json:"total" example:"1250"`
+	// How many users the broadcast has been sent to.
+	Processed int `bson:"processed" json:"processed" example:"600"`
+	// How many deliveries succeeded and failed so far.
+	Success int `bson:"success" json:"success" example:"1100"`
+	Failure int `bson:"failure" json:"failure" example:"12"`
+	// The last user which the broadcast was sent to, so the sending continues after it if it is interrupted.
+	LastUserId string `bson:"lastUserId,omitempty" json:"-"`
+	// The error which stopped the broadcast.
+	Error string `bson:"error,omitempty" json:"error,omitempty"`
+	// Identifier of the admin who created the broadcast.
+	CreatedBy string `bson:"createdBy" json:"createdBy" example:"1234567890"`
+	// The time when the broadcast was created, started and completed.
+	CreatedAt   time.Time  `bson:"createdAt" json:"createdAt" example:"2025-01-02 14:00:00 +0200 EET"`
+	StartedAt   *time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty" example:"2025-01-02 14:00:05 +0200 EET"`
+	CompletedAt *time.Time `bson:"completedAt,omitempty" json:"completedAt,omitempty" example:"2025-01-02 14:03:00 +0200 EET"`
+}
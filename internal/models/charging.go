@@ -0,0 +1,47 @@
+// AnhCao 2024
+package models
+
+import "time"
+
+// ChargingRequest represents the request of an electric car owner to plan the charging before a deadline,
+// ex: "charge 30 kWh before 07:00 at max 11 kW".
+type ChargingRequest struct {
+	// How much energy has to be charged in kWh.
+	EnergyKWh float64 `json:"energyKWh" example:"30"`
+	// The maximum charging power in kW.
+	MaxPowerKW float64 `json:"maxPowerKW" example:"11"`
+	// The time by which the charging must be finished.
+	Deadline time.Time `json:"deadline" example:"2024-12-09T07:00:00+02:00"`
+	// Whether the plan is also sent to the devices of the user as a push notification.
+	Notify bool `json:"notify" example:"false"`
+}
+
+// ChargingSlot represents charging during a single price entry. The slot may be only partially used.
+type ChargingSlot struct {
+	// The time when the charging starts in UTC.
+	Start time.Time `json:"start" example:"2024-12-09T01:00:00Z"`
+	// The time when the charging ends in UTC.
+	End time.Time `json:"end" example:"2024-12-09T01:40:00Z"`
+	// The local time when the charging starts, same format as `Data.Time`.
+	StartTime string `json:"startTime" example:"2024-12-09 03:00:00"`
+	// The energy charged during the slot in kWh.
+	EnergyKWh float64 `json:"energyKWh" example:"7.33"`
+	// The price of the entry.
+	Price float64 `json:"price" example:"1.25"`
+	// The cost of the energy charged during the slot, in the currency unit of the prices (ex: c).
+	Cost float64 `json:"cost" example:"9.16"`
+}
+
+// ChargingPlan represents the cheapest way to charge the requested energy before the deadline.
+type ChargingPlan struct {
+	// The charging slots in chronological order.
+	Schedule []ChargingSlot `json:"schedule"`
+	// The total energy that is charged by the plan in kWh.
+	TotalEnergyKWh float64 `json:"totalEnergyKWh" example:"30"`
+	// The total cost of the plan.
+	TotalCost float64 `json:"totalCost" example:"52.4"`
+	// Currency unit of the costs.
+	Unit string `json:"unit" example:"c"`
+	// Whether the requested energy fits before the deadline. If not, the plan charges as much as possible.
+	Fulfilled bool `json:"fulfilled" example:"true"`
+}
@@ -1,13 +1,27 @@
 // AnhCao 2024
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 // Config represents the configuration structure for the application.
 // It includes settings for the server, database, Supabase, and message broker.
 type Config struct {
-	Server        Server   `yaml:"server"`
-	Database      Database `yaml:"database"`
-	Supabase      Supabase `yaml:"supabase"`
-	MessageBroker Broker   `yaml:"message_broker"`
+	Server        Server    `yaml:"server"`
+	Database      Database  `yaml:"database"`
+	Supabase      Supabase  `yaml:"supabase"`
+	MessageBroker Broker    `yaml:"message_broker"`
+	Scheduler     Scheduler `yaml:"scheduler"`
+	Push          Push      `yaml:"push"`
+	Email         Email     `yaml:"email"`
+	Webhooks      Webhooks  `yaml:"webhooks"`
+	VAPID         VAPID     `yaml:"vapid"`
+	// Budgets of outbound requests per delivery channel (ex: push, email, webhook, webpush).
+	RateLimits map[string]RateLimit `yaml:"rate_limits"`
+	// If enabled, no notification is delivered: every channel only validates and renders the notifications.
+	DryRun bool `yaml:"dry_run"`
 }
 
 // Server represents the configuration settings for the server.
@@ -16,6 +30,37 @@ type Config struct {
 type Server struct {
 	Port string `yaml:"port"`
 	Host string `yaml:"host"`
+	// The public base URL of the service (ex: https://notifications.example.com).
+	// It is used to build links that are embedded into notifications, like price chart images.
+	PublicURL string `yaml:"public_url"`
+	// How long the response of a request with an `Idempotency-Key` header is stored and replayed (ex: "24h"). Defaults to 24 hours.
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+	// How long an `Idempotency-Key` is reserved for the request which is being processed (ex: "1m"). The reservation
+	// is renewed while the request runs, so this only decides how soon the key is free again after a crash. Defaults to 1 minute.
+	IdempotencyLockTimeout time.Duration `yaml:"idempotency_lock_timeout"`
+	// Serves the API over HTTPS when the certificate is configured, plain HTTP otherwise.
+	TLS TLS `yaml:"tls"`
+}
+
+// TLS represents the HTTPS configuration of the API server. The files are checked for changes
+// while the server runs, so a renewed certificate is used without restarting the service.
+type TLS struct {
+	// PEM files of the certificate chain and the private key of the server.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// Lowest accepted TLS version, "1.2" or "1.3". Defaults to "1.2".
+	MinVersion string `yaml:"min_version"`
+	// PEM file of the certificate authorities which issue the client certificates of the internal callers (mutual TLS).
+	// Without it, client certificates are not requested.
+	ClientCAFile string `yaml:"client_ca_file"`
+	// Whether every client must present a certificate ("require"), or only the internal callers ("optional").
+	// Defaults to "optional", so the apps keep connecting with their access tokens.
+	ClientAuth string `yaml:"client_auth"`
+	// Scopes of the internal callers, by the identity of their client certificate (the common name, or the first DNS name).
+	// A listed caller without an access token or API key is authenticated as a service with the scopes (ex: notifications:send).
+	Clients map[string][]string `yaml:"clients"`
+	// How often the files are checked for changes (ex: "1m"). Defaults to 30 seconds.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
 }
 
 // Broker represents the configuration settings for connecting to a broker.
@@ -47,6 +92,62 @@ type Database struct {
 	Name string `yaml:"name"`
 	// The name of the collection within the database.
 	Collection string `yaml:"collection"`
+	// How long the delivery log of the notifications is kept (ex: "720h"). Defaults to 30 days.
+	DeliveryRetention time.Duration `yaml:"delivery_retention"`
+}
+
+// Scheduler represents the configuration settings for the job scheduler which sends persisted notifications on time.
+// Zero values fall back to the defaults of the scheduler.
+type Scheduler struct {
+	// How often the scheduler looks for due jobs (ex: "10s").
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// How long a claimed job is reserved for the scheduler before another replica may take it over (ex: "1m").
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+	// How many times a job is attempted before it is marked as failed.
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+// Email represents the configuration settings of the SMTP server which delivers the notifications of the email channel.
+// The email channel is disabled if the host is not set.
+type Email struct {
+	// The hostname or IP address of the SMTP server.
+	Host string `yaml:"host"`
+	// The port number on which the SMTP server is listening (ex: 587).
+	Port string `yaml:"port"`
+	// The username for authentication with the SMTP server. Authentication is skipped if it is empty.
+	Username string `yaml:"user"`
+	// The password for authentication with the SMTP server.
+	Password string `yaml:"pass"`
+	// The sender address of the emails (ex: "Electric <noreply@example.com>").
+	From string `yaml:"from"`
+	// How the connection is secured: "starttls" (default), "tls" for implicit TLS, or "none" for local SMTP servers.
+	TLS string `yaml:"tls"`
+	// Timeout of a single email delivery (ex: "10s").
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Webhooks represents the configuration settings of the delivery to the webhooks of users.
+// Zero values fall back to the defaults of the webhook sender.
+type Webhooks struct {
+	// Timeout of a single delivery attempt (ex: "5s").
+	Timeout time.Duration `yaml:"timeout"`
+	// How many times a delivery is attempted before it fails.
+	MaxAttempts int `yaml:"max_attempts"`
+	// Delay before the first retry, doubled after every failed attempt (ex: "1s").
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// After how many consecutive failed deliveries a webhook is disabled.
+	MaxConsecutiveFailures int `yaml:"max_consecutive_failures"`
+	// How many webhooks a user may register. Defaults to 10.
+	MaxPerUser int `yaml:"max_per_user"`
+}
+
+// RateLimit represents the token bucket which bounds the outbound requests of a delivery channel.
+// A channel without a rate is not limited.
+type RateLimit struct {
+	// How many requests per second the channel sends on average (ex: 100 FCM messages per second).
+	Rate float64 `yaml:"rate"`
+	// How many requests the channel may send at once after being idle. Defaults to the rate.
+	Burst int `yaml:"burst"`
 }
 
 // Supabase represents the configuration settings for connecting to Supabase.
@@ -55,10 +156,35 @@ type Supabase struct {
 }
 
 type auth struct {
+	// Shared secret of the HS256 tokens. Leave empty to only accept the asymmetric tokens of the JWKS.
 	JwtSecret string `yaml:"jwt_secret"`
+	// URL of the JWKS document with the public keys of the RS256 and ES256 tokens
+	// (ex: https://<project>.supabase.co/auth/v1/.well-known/jwks.json).
+	JWKSURL string `yaml:"jwks_url"`
+	// Local file of the JWKS document, used instead of the URL (ex: in an air-gapped deployment).
+	JWKSFile string `yaml:"jwks_file"`
+	// How often the JWKS document is refreshed (ex: "1h"). A token with an unknown key ID refreshes it right away. Defaults to 1 hour.
+	JWKSRefresh time.Duration `yaml:"jwks_refresh"`
+	// Required `iss` claim of the tokens (ex: https://<project>.supabase.co/auth/v1). It must be set if a JWKS is configured,
+	// as the keys of a public JWKS may also sign the tokens of other projects. Empty accepts any issuer of HS256 tokens.
+	Issuer string `yaml:"issuer"`
+	// Required `aud` claim of the tokens (ex: authenticated). It must be set if a JWKS is configured.
+	// Empty accepts any audience of HS256 tokens.
+	Audience string `yaml:"audience"`
+	// Tolerated difference between the clocks of the issuer and the service when validating `exp` and `nbf` (ex: "30s"). Defaults to 30 seconds.
+	ClockSkew time.Duration `yaml:"clock_skew"`
+	// Identifiers of the users (`sub` of the token) who have the admin role, in addition to the roles of their token.
+	Admins []string `yaml:"admins"`
+	// Paths of the claims which contain the roles of the user, separated by dots (ex: "app_metadata.roles").
+	// A claim may contain a single role or a list of roles. Defaults to "app_metadata.roles" and "app_metadata.role".
+	RoleClaims []string `yaml:"role_claims"`
 }
 
-// todo: validate configuration
+// Validate checks the settings which would otherwise make the service insecure
 func (c *Config) Validate() error {
+	authConfig := c.Supabase.Auth
+	if (authConfig.JWKSURL != "" || authConfig.JWKSFile != "") && (authConfig.Issuer == "" || authConfig.Audience == "") {
+		return fmt.Errorf("supabase.auth.issuer and supabase.auth.audience are required when a JWKS is configured")
+	}
 	return nil
 }
@@ -0,0 +1,27 @@
+// AnhCao 2024
+package models
+
+import "testing"
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    auth
+		wantErr bool
+	}{
+		{name: "shared secret only", auth: auth{JwtSecret: "secret"}},
+		{name: "JWKS with issuer and audience", auth: auth{JWKSURL: "https://project.supabase.co/auth/v1/.well-known/jwks.json", Issuer: "https://project.supabase.co/auth/v1", Audience: "authenticated"}},
+		{name: "JWKS URL without issuer", auth: auth{JWKSURL: "https://project.supabase.co/auth/v1/.well-known/jwks.json", Audience: "authenticated"}, wantErr: true},
+		{name: "JWKS URL without audience", auth: auth{JWKSURL: "https://project.supabase.co/auth/v1/.well-known/jwks.json", Issuer: "https://project.supabase.co/auth/v1"}, wantErr: true},
+		{name: "JWKS file without issuer and audience", auth: auth{JWKSFile: "jwks.json"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			config.Supabase.Auth = tt.auth
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
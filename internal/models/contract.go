@@ -0,0 +1,37 @@
+// AnhCao 2024
+package models
+
+import "time"
+
+// Contract represents the electricity contract of a user. All prices are in the unit of the price series (ex: c/kWh)
+// and are expected to include VAT, like the spot prices do.
+type Contract struct {
+	// Identifier of the user who owns the contract.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// Margin that the retailer adds on top of the spot price.
+	Margin float64 `bson:"margin" json:"margin" example:"0.49"`
+	// Price of a fixed-price contract to compare the spot contract with. Zero disables the comparison.
+	FixedPrice float64 `bson:"fixedPrice,omitempty" json:"fixedPrice,omitempty" example:"8.5"`
+	// Transfer tariff of the network operator.
+	Transfer TransferTariff `bson:"transfer" json:"transfer"`
+	// Monthly basic fees of the retailer and the network operator together, in the currency unit of the prices (ex: c).
+	MonthlyFees float64 `bson:"monthlyFees,omitempty" json:"monthlyFees,omitempty" example:"1000"`
+	// Estimated monthly consumption in kWh, used to spread the monthly fees over every consumed kWh.
+	// Zero leaves the monthly fees out of the hourly price.
+	MonthlyConsumptionKWh float64 `bson:"monthlyConsumptionKWh,omitempty" json:"monthlyConsumptionKWh,omitempty" example:"500"`
+	// The time when the contract was last updated.
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt" example:"2025-01-02 14:00:00 +0200 EET"`
+}
+
+// TransferTariff represents the time-of-use transfer fees of the network operator.
+// If the night time range is not set, the day price applies to every hour.
+type TransferTariff struct {
+	// Transfer fee during the day.
+	DayPrice float64 `bson:"dayPrice" json:"dayPrice" example:"4.28"`
+	// Transfer fee during the night.
+	NightPrice float64 `bson:"nightPrice,omitempty" json:"nightPrice,omitempty" example:"2.63"`
+	// The local time (HH:MM) when the night tariff begins.
+	NightFrom string `bson:"nightFrom,omitempty" json:"nightFrom,omitempty" example:"22:00"`
+	// The local time (HH:MM) when the night tariff ends.
+	NightTo string `bson:"nightTo,omitempty" json:"nightTo,omitempty" example:"07:00"`
+}
@@ -0,0 +1,95 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DeliveryResult represents the outcome of delivering a notification to a single recipient (ex: a device token).
+type DeliveryResult struct {
+	// Delivery channel which was used (ex: push).
+	Channel string `json:"channel" example:"push"`
+	// Identifier of the user who owns the recipient, if known.
+	UserId string `json:"userId,omitempty" example:"1234567890"`
+	// The recipient within the channel, ex: device token or topic.
+	Recipient string `json:"recipient" example:"fcm-device-token"`
+	// Whether the notification was accepted by the channel.
+	Success bool `json:"success" example:"true"`
+	// Identifier of the message given by the channel (ex: FCM message ID).
+	MessageId string `json:"messageId,omitempty" example:"projects/app/messages/0:1234"`
+	// Machine readable reason of the failure (ex: UNREGISTERED).
+	ErrorCode string `json:"errorCode,omitempty" example:"UNREGISTERED"`
+	// Human readable reason of the failure.
+	Error string `json:"error,omitempty" example:"Requested entity was not found."`
+	// How many times the delivery was attempted, if the channel retries failed deliveries.
+	Attempts int `json:"attempts,omitempty" example:"1"`
+	// What would have been sent to the recipient in dry-run mode, ex: the rendered email or the JSON payload of a webhook.
+	Preview string `json:"preview,omitempty" example:"{\"title\":\"Electricity prices for tomorrow\"}"`
+}
+
+// NotificationResult represents the outcome of sending a notification through every delivery channel.
+type NotificationResult struct {
+	// Identifier of the notification, under which its deliveries are recorded in the delivery log.
+	NotificationId string `json:"notificationId" example:"6760a7d5e13f1c2a9c8b4566"`
+	// How many deliveries succeeded and failed.
+	Success int `json:"success" example:"2"`
+	Failure int `json:"failure" example:"1"`
+	// Whether the notification was only validated and rendered, without being delivered.
+	DryRun bool `json:"dryRun" example:"true"`
+	// The notification which was sent.
+	Message NotificationMessage `json:"message"`
+	// The outcome for every recipient of every channel, ex: the status and the reason of the failure of every device.
+	Results []DeliveryResult `json:"results"`
+	// Errors of the channels which failed as a whole, if any.
+	Error string `json:"error,omitempty" example:"email: failed to connect to SMTP server"`
+}
+
+// Sources of the notifications, which are recorded in the delivery log
+const (
+	SourceAPI       string = "api"
+	SourceRabbitMQ  string = "rabbitmq"
+	SourceScheduler string = "scheduler"
+	SourceBroadcast string = "broadcast"
+)
+
+// Statuses of the deliveries in the delivery log
+const (
+	DeliveryStatusDelivered string = "delivered"
+	DeliveryStatusFailed    string = "failed"
+)
+
+// DeliveryLog represents the record of delivering a notification to a single recipient (ex: a device token).
+// Every notification gets an ID which is shared by the records of all its recipients.
+type DeliveryLog struct {
+	ID bson.ObjectID `bson:"_id" json:"id" example:"6760a7d5e13f1c2a9c8b4567"`
+	// Identifier of the notification which the delivery belongs to.
+	NotificationId string `bson:"notificationId" json:"notificationId" example:"6760a7d5e13f1c2a9c8b4566"`
+	// Where the notification came from (api, rabbitmq or scheduler).
+	Source string `bson:"source" json:"source" example:"rabbitmq"`
+	// Name of the service which sent the notification with an API key, if any.
+	Service string `bson:"service,omitempty" json:"service,omitempty" example:"billing"`
+	// Identifier of the user who received the notification.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// Delivery channel which was used (ex: push).
+	Channel string `bson:"channel" json:"channel" example:"push"`
+	// The recipient within the channel, ex: device token or topic.
+	Recipient string `bson:"recipient" json:"recipient" example:"fcm-device-token"`
+	// Title and category of the notification, so the notification can be recognized later on.
+	Title    string `bson:"title,omitempty" json:"title,omitempty" example:"Electricity prices for tomorrow"`
+	Category string `bson:"category,omitempty" json:"category,omitempty" example:"daily_prices"`
+	// Outcome of the delivery (delivered or failed).
+	Status string `bson:"status" json:"status" example:"delivered"`
+	// Identifier of the message given by the channel (ex: FCM message ID).
+	MessageId string `bson:"messageId,omitempty" json:"messageId,omitempty" example:"projects/app/messages/0:1234"`
+	// Machine readable reason of the failure (ex: UNREGISTERED).
+	ErrorCode string `bson:"errorCode,omitempty" json:"errorCode,omitempty" example:"UNREGISTERED"`
+	// Human readable reason of the failure.
+	Error string `bson:"error,omitempty" json:"error,omitempty" example:"Requested entity was not found."`
+	// How many times the delivery was attempted.
+	Attempts int `bson:"attempts,omitempty" json:"attempts,omitempty" example:"1"`
+	// When the sending of the notification started and when the channel reported the outcome.
+	SentAt      time.Time `bson:"sentAt" json:"sentAt" example:"2024-12-16T19:00:00Z"`
+	CompletedAt time.Time `bson:"completedAt" json:"completedAt" example:"2024-12-16T19:00:01Z"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Represents a struct of data that will received from RabbitMQ producer.
 type PricesMessage struct {
 	Data      TodayTomorrowPrice `json:"data"`      // Data represents the price of today and tomorrow
@@ -34,3 +36,11 @@ type Data struct {
 	IsToday      bool    `json:"isToday" example:"false"`                 // IsToday indicates whether the current time is today or not
 	IncludeVat   string  `json:"includeVat" example:"1" enums:"0,1"`      // IncludeVat is legacy property that return string value and value "0" means no VAT included and string "1" is included
 }
+
+// DailyPrices represents the price series of a single day that is stored in the database.
+// It is used to serve price related resources (ex: chart image) after the message from RabbitMQ has been processed.
+type DailyPrices struct {
+	Date      string      `bson:"date" json:"date" example:"2024-12-09"`                              // date of the price series in format YYYY-MM-DD
+	Prices    PriceSeries `bson:"prices" json:"prices"`                                               // price series of the date
+	UpdatedAt time.Time   `bson:"updatedAt" json:"updatedAt" example:"2024-12-08 14:00:00 +0200 EET"` // the time when the price series was stored
+}
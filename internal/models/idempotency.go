@@ -0,0 +1,37 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Statuses of the requests with an idempotency key
+const (
+	IdempotencyProcessing string = "processing"
+	IdempotencyCompleted  string = "completed"
+)
+
+// IdempotencyRecord represents a request which was made with an `Idempotency-Key` header, together with its response,
+// so a retry of the request returns the same response without executing the request again.
+type IdempotencyRecord struct {
+	ID bson.ObjectID `bson:"_id"`
+	// Identifier of the user who made the request. Keys are unique per user.
+	UserId string `bson:"userId"`
+	// The idempotency key given by the client.
+	Key string `bson:"key"`
+	// Hash of the request, which tells apart a retry from a different request with the same key.
+	RequestHash string `bson:"requestHash"`
+	// Whether the request is still being processed or its response is stored.
+	Status string `bson:"status"`
+	// The stored response.
+	StatusCode  int    `bson:"statusCode,omitempty"`
+	ContentType string `bson:"contentType,omitempty"`
+	Body        []byte `bson:"body,omitempty"`
+	// The time when the request was made.
+	CreatedAt time.Time `bson:"createdAt"`
+	// The time after which the record is removed. While the request is processed, the time after which the key is released
+	// in case the processing never finished.
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
@@ -0,0 +1,49 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// InboxItem represents a notification in the in-app inbox of a user, so the notification
+// can still be read after the push notification was dismissed.
+type InboxItem struct {
+	ID bson.ObjectID `bson:"_id" json:"id" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// Identifier of the user who received the notification.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// Identifier of the notification in the delivery log.
+	NotificationId string `bson:"notificationId,omitempty" json:"notificationId,omitempty" example:"6760a7d5e13f1c2a9c8b4566"`
+	// Title of the notification.
+	Title string `bson:"title,omitempty" json:"title,omitempty" example:"Electricity prices for tomorrow"`
+	// Body text of the notification.
+	Body string `bson:"body,omitempty" json:"body,omitempty" example:"Tomorrow price is 2.47 c/kWh"`
+	// URL of the image of the notification.
+	ImageURL string `bson:"imageUrl,omitempty" json:"imageUrl,omitempty" example:"https://example.com/v1/prices/2024-12-09/chart.png"`
+	// The action which is triggered when the user taps on the notification.
+	ClickAction string `bson:"clickAction,omitempty" json:"clickAction,omitempty" example:"OPEN_PRICES"`
+	// Category of the notification (ex: reminder).
+	Category string `bson:"category,omitempty" json:"category,omitempty" example:"reminder"`
+	// Custom key-value pairs of the notification.
+	Data map[string]string `bson:"data,omitempty" json:"data,omitempty"`
+	// Whether the user has read the notification, and when.
+	Read   bool       `bson:"read" json:"read" example:"false"`
+	ReadAt *time.Time `bson:"readAt,omitempty" json:"readAt,omitempty" example:"2024-12-16T19:05:00Z"`
+	// The time when the notification was sent.
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt" example:"2024-12-16T19:00:00Z"`
+}
+
+// InboxPage represents a page of the inbox of a user, the latest notifications first.
+type InboxPage struct {
+	Items []InboxItem `json:"items"`
+	// How many notifications of the user are unread.
+	Unread int64 `json:"unread" example:"3"`
+	// Cursor of the next page, given as `before` to get the older notifications. Empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty" example:"677e5c2b8f1b2c0a4d3e2f10"`
+}
+
+// InboxCount represents the number of unread notifications of a user.
+type InboxCount struct {
+	Unread int64 `json:"unread" example:"3"`
+}
@@ -0,0 +1,61 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// JobStatus represents the state of a scheduled job.
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending" // the job waits until its run time
+	JobRunning JobStatus = "running" // the job has been claimed by a scheduler
+	JobDone    JobStatus = "done"    // the job has been executed successfully
+	JobFailed  JobStatus = "failed"  // the job has failed and will not be retried
+)
+
+const (
+	// JobKindReminder is a job that sends a reminder before a cheap price period begins.
+	JobKindReminder string = "reminder"
+	// JobKindNotification is a job that sends a notification which was scheduled through the API.
+	JobKindNotification string = "notification"
+	// JobKindBroadcast is a job that sends a broadcast to every user of its segment.
+	JobKindBroadcast string = "broadcast"
+)
+
+// Job represents a notification that is persisted and sent at a given time by the scheduler.
+type Job struct {
+	// Unique identifier for the job.
+	ID bson.ObjectID `bson:"_id" json:"id" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// The kind of the job (ex: reminder).
+	Kind string `bson:"kind" json:"kind" example:"reminder"`
+	// Identifier of the resource which created the job (ex: reminder ID).
+	ReferenceId string `bson:"referenceId,omitempty" json:"referenceId,omitempty" example:"677e5c2b8f1b2c0a4d3e2f11"`
+	// Unique key that prevents the same job from being scheduled twice.
+	DedupKey string `bson:"dedupKey,omitempty" json:"-"`
+	// The time when the job should be executed.
+	RunAt time.Time `bson:"runAt" json:"runAt" example:"2025-01-03 02:45:00 +0200 EET"`
+	// The time zone which the run time was given in, if any.
+	TimeZone string `bson:"timeZone,omitempty" json:"timeZone,omitempty" example:"Europe/Helsinki"`
+	// The current state of the job.
+	Status JobStatus `bson:"status" json:"status" example:"pending"`
+	// Name of the service which scheduled the notification with an API key, if any.
+	Service string `bson:"service,omitempty" json:"service,omitempty" example:"billing"`
+	// The notification which is sent when the job is executed.
+	Message NotificationMessage `bson:"message" json:"message"`
+	// Number of times the job has been attempted.
+	Attempts int `bson:"attempts" json:"attempts" example:"0"`
+	// Until this time the job is reserved by the scheduler that claimed it.
+	LockedUntil time.Time `bson:"lockedUntil,omitempty" json:"-"`
+	// Random token of the latest claim, only the scheduler which holds it may finish the job.
+	ClaimToken string `bson:"claimToken,omitempty" json:"-"`
+	// The error of the last failed attempt.
+	LastError string `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	// The time when the job was created.
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt" example:"2025-01-02 14:00:00 +0200 EET"`
+	// The time after which a finished job is removed from the database.
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty" json:"-"`
+}
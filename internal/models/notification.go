@@ -2,11 +2,15 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// LocalTimeLayout is the layout of times which are given without an offset, in a separate time zone
+const LocalTimeLayout string = "2006-01-02T15:04:05"
+
 // NotificationToken represents a token used for sending notifications to a specific device.
 type NotificationToken struct {
 	// Unique identifier for the notification token.
@@ -16,12 +20,80 @@ type NotificationToken struct {
 	// Identifier of the device associated with the notification token.
 	// todo: maybe this could be a slice instead of single deviceID. This way we can send notifications to multiple devices that user has.
 	DeviceId string `bson:"deviceId" json:"deviceId" example:"1234567890"`
+	// Platform of the device: "android", "ios" or "web". It decides which platform-specific configuration is sent to the device.
+	Platform string `bson:"platform,omitempty" json:"platform,omitempty" example:"android" enums:"android,ios,web"`
+	// Encryption keys of a standard Web Push subscription. Only set on tokens of platform "webpush".
+	Keys *WebPushKeys `bson:"keys,omitempty" json:"-"`
+	// Language of the app on the device as BCP 47 tag (ex: "fi-FI"). Used to target broadcasts.
+	Locale string `bson:"locale,omitempty" json:"locale,omitempty" example:"fi-FI"`
+	// Version of the app on the device. Used to target broadcasts.
+	AppVersion string `bson:"appVersion,omitempty" json:"appVersion,omitempty" example:"1.4.0"`
+	// Electricity price area of the user on the device (ex: "FI"). Used to target broadcasts.
+	Area string `bson:"area,omitempty" json:"area,omitempty" example:"FI"`
 	// The time when the notification token was created.
 	Timestamp time.Time `bson:"timestamp" json:"timestamp" example:"2025-01-02 14:00:00 +0200 EET"`
 }
 
 // NotificationMessage represents a message to be sent to a user.
 type NotificationMessage struct {
-	UserId  string `json:"userId" example:"1234567890"`
-	Message string `json:"message" example:"Hello, World!"`
+	// Identifier of the user who receives the notification.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// Title of the notification.
+	Title string `bson:"title,omitempty" json:"title,omitempty" example:"Electricity prices for tomorrow"`
+	// Body text of the notification.
+	Body string `bson:"body,omitempty" json:"body,omitempty" example:"Tomorrow price is 2.47 c/kWh"`
+	// Deprecated: use `body` instead. It is used as the body when the body is empty.
+	Message string `bson:"message,omitempty" json:"message,omitempty" example:"Hello, World!"`
+	// URL of an image that is shown in the expanded notification.
+	ImageURL string `bson:"imageUrl,omitempty" json:"imageUrl,omitempty" example:"https://example.com/v1/prices/2024-12-09/chart.png"`
+	// Custom key-value pairs which are delivered to the app together with the notification.
+	Data map[string]string `bson:"data,omitempty" json:"data,omitempty"`
+	// The action (ex: Android intent filter, iOS category or web URL) which is triggered when the user taps on the notification.
+	ClickAction string `bson:"clickAction,omitempty" json:"clickAction,omitempty" example:"OPEN_PRICES"`
+	// Category of the notification (ex: reminder). It selects the platform-specific configuration of the category.
+	Category string `bson:"category,omitempty" json:"category,omitempty" example:"reminder"`
+	// Platform-specific configuration of this message, applied on top of the defaults and the category configuration.
+	PlatformOverrides *PlatformConfig `bson:"platformOverrides,omitempty" json:"platformOverrides,omitempty"`
+	// Number of unread notifications of the user, shown as the badge of the app. Set when the notification is sent.
+	Badge *int `bson:"-" json:"-"`
+}
+
+// GetBody returns the body text of the notification, falling back to the deprecated `message` field
+func (m NotificationMessage) GetBody() string {
+	if m.Body != "" {
+		return m.Body
+	}
+	return m.Message
+}
+
+// NotificationRequest represents a notification to be sent through the API, either right away or at a given time.
+type NotificationRequest struct {
+	NotificationMessage
+	// The time when the notification is sent, in RFC 3339 format (ex: "2025-01-03T07:00:00+02:00"),
+	// or as a local time without offset (ex: "2025-01-03T07:00:00") in the time zone of `timeZone`. Empty sends right away.
+	SendAt string `json:"sendAt,omitempty" example:"2025-01-03T07:00:00"`
+	// IANA time zone of `sendAt` when it has no offset (ex: "Europe/Helsinki"). Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty" example:"Europe/Helsinki"`
+}
+
+// ScheduledTime returns the time when the notification is sent, or a zero time if it is sent right away
+func (r NotificationRequest) ScheduledTime() (time.Time, error) {
+	if r.SendAt == "" {
+		return time.Time{}, nil
+	}
+	location := time.UTC
+	if r.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(r.TimeZone); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timeZone '%s'", r.TimeZone)
+		}
+	}
+	if sendAt, err := time.Parse(time.RFC3339, r.SendAt); err == nil {
+		return sendAt, nil
+	}
+	sendAt, err := time.ParseInLocation(LocalTimeLayout, r.SendAt, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid sendAt '%s', expected RFC 3339 time or local time in format %s", r.SendAt, LocalTimeLayout)
+	}
+	return sendAt, nil
 }
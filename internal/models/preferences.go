@@ -0,0 +1,69 @@
+// AnhCao 2024
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Delivery channels which users can select for their notifications
+const (
+	ChannelPush    string = "push"
+	ChannelEmail   string = "email"
+	ChannelWebhook string = "webhook"
+	ChannelWebPush string = "webpush"
+)
+
+// SelectableChannels are the delivery channels which users can select
+var SelectableChannels = []string{ChannelPush, ChannelEmail, ChannelWebhook, ChannelWebPush}
+
+// DefaultChannels are the delivery channels of users who have not selected any.
+// A channel only delivers if the user has a recipient in it, ex: a verified email address or a webhook.
+var DefaultChannels = []string{ChannelPush, ChannelEmail, ChannelWebhook, ChannelWebPush}
+
+// Preferences represents how a user wants to receive notifications.
+type Preferences struct {
+	// Identifier of the user who owns the preferences.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// Delivery channels which the user receives notifications from. If not set, the default channels are used.
+	Channels []string `bson:"channels,omitempty" json:"channels,omitempty" example:"push,email" enums:"push,email,webhook,webpush"`
+	// Categories of broadcasts which the user opted in to (ex: "tips").
+	Categories []string `bson:"categories,omitempty" json:"categories,omitempty" example:"tips,news"`
+	// Email address which receives the notifications of the email channel.
+	Email string `bson:"email,omitempty" json:"email,omitempty" example:"user@example.com"`
+	// Whether the user has confirmed the email address. Unconfirmed addresses never receive notifications.
+	EmailVerified bool `bson:"emailVerified" json:"emailVerified" example:"true"`
+	// SHA-256 hash of the pending email confirmation token.
+	VerificationHash string `bson:"verificationHash,omitempty" json:"-"`
+	// The time when the pending email confirmation token expires.
+	VerificationExpiresAt *time.Time `bson:"verificationExpiresAt,omitempty" json:"-"`
+	// The time when the preferences were last updated.
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt" example:"2025-01-02 14:00:00 +0200 EET"`
+}
+
+// CategorySelection represents the categories of broadcasts that a user opts in to.
+type CategorySelection struct {
+	// Categories of broadcasts which the user receives. Empty opts out of every category.
+	Categories []string `json:"categories" example:"tips,news"`
+}
+
+// ChannelSelection represents the delivery channels that a user selects.
+type ChannelSelection struct {
+	// Delivery channels which the user receives notifications from. At least one channel is required.
+	Channels []string `json:"channels" example:"push,email" enums:"push,email,webhook,webpush"`
+}
+
+// EmailAddress represents the email address that a user registers for the email channel.
+type EmailAddress struct {
+	// Email address which receives the notifications of the email channel.
+	Email string `json:"email" example:"user@example.com"`
+}
+
+// Allows reports whether the user receives notifications from given channel.
+// Nil preferences allow the default channels.
+func (p *Preferences) Allows(channel string) bool {
+	if p == nil || len(p.Channels) == 0 {
+		return slices.Contains(DefaultChannels, channel)
+	}
+	return slices.Contains(p.Channels, channel)
+}
@@ -0,0 +1,119 @@
+// AnhCao 2024
+package models
+
+import "time"
+
+// Platforms of the devices which notification tokens are registered from
+const (
+	PlatformAndroid string = "android"
+	PlatformIOS     string = "ios"
+	PlatformWeb     string = "web"
+	// Browsers subscribed to the standard Web Push channel instead of FCM. The device ID of such a token
+	// is the endpoint of the subscription.
+	PlatformWebPush string = "webpush"
+)
+
+// Push represents the platform-specific configuration of push notifications.
+// The defaults apply to every notification and can be overridden per notification category and per message.
+type Push struct {
+	// Default configuration for all notifications.
+	Defaults PlatformConfig `yaml:"defaults"`
+	// Overrides per notification category (ex: reminder), applied on top of the defaults.
+	Categories map[string]PlatformConfig `yaml:"categories"`
+	// How many chunks of 500 tokens of a multicast message are sent to FCM at the same time.
+	MaxConcurrentBatches int `yaml:"max_concurrent_batches"`
+	// How tokens which failed with a transient error (ex: UNAVAILABLE) are sent again.
+	Retry Retry `yaml:"retry"`
+}
+
+// Retry represents the retry policy of transient failures. Zero values fall back to the defaults.
+type Retry struct {
+	// How many times a token is attempted in total.
+	MaxAttempts int `yaml:"max_attempts"`
+	// Delay before the first retry, doubled for every further retry and randomized with jitter (ex: "1s").
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// The longest delay between two attempts. If FCM asks to wait longer (Retry-After), the failure is final.
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// Resolve merges the platform configuration of a notification: the defaults, then the configuration
+// of the category of the notification and finally the overrides of the notification itself.
+func (p *Push) Resolve(category string, overrides *PlatformConfig) PlatformConfig {
+	var resolved PlatformConfig
+	if p != nil {
+		resolved = p.Defaults
+		if config, ok := p.Categories[category]; ok && category != "" {
+			resolved = resolved.Merge(config)
+		}
+	}
+	if overrides != nil {
+		resolved = resolved.Merge(*overrides)
+	}
+	return resolved
+}
+
+// PlatformConfig represents the configuration of a notification for each platform.
+// Empty fields do not override the configuration they are applied on.
+type PlatformConfig struct {
+	Android AndroidPush `yaml:"android" bson:"android,omitempty" json:"android,omitempty"`
+	APNS    APNSPush    `yaml:"apns" bson:"apns,omitempty" json:"apns,omitempty"`
+	Webpush WebPush     `yaml:"webpush" bson:"webpush,omitempty" json:"webpush,omitempty"`
+}
+
+// AndroidPush represents the configuration of notifications on Android devices.
+type AndroidPush struct {
+	// Delivery priority of the message: "normal" or "high".
+	Priority string `yaml:"priority" bson:"priority,omitempty" json:"priority,omitempty" example:"high" enums:"normal,high"`
+	// Notification channel which the notification is posted to.
+	ChannelID string `yaml:"channel_id" bson:"channelId,omitempty" json:"channelId,omitempty" example:"prices"`
+	// Sound to play when the notification is shown (ex: "default").
+	Sound string `yaml:"sound" bson:"sound,omitempty" json:"sound,omitempty" example:"default"`
+	// Color of the notification icon in format #rrggbb.
+	Color string `yaml:"color" bson:"color,omitempty" json:"color,omitempty" example:"#2ea043"`
+	// Number of items the notification represents, shown as the badge of the app by some launchers.
+	NotificationCount *int `yaml:"notification_count" bson:"notificationCount,omitempty" json:"notificationCount,omitempty" example:"1"`
+}
+
+// APNSPush represents the configuration of notifications on iOS devices.
+type APNSPush struct {
+	// Sound to play when the notification is shown (ex: "default").
+	Sound string `yaml:"sound" bson:"sound,omitempty" json:"sound,omitempty" example:"default"`
+	// Badge number shown on the app icon. Nil leaves the badge untouched, zero removes it.
+	Badge *int `yaml:"badge" bson:"badge,omitempty" json:"badge,omitempty" example:"1"`
+	// Identifier which groups the notifications of the same thread.
+	ThreadID string `yaml:"thread_id" bson:"threadId,omitempty" json:"threadId,omitempty" example:"prices"`
+}
+
+// WebPush represents the configuration of notifications in web browsers.
+type WebPush struct {
+	// URL of the icon shown in the notification.
+	Icon string `yaml:"icon" bson:"icon,omitempty" json:"icon,omitempty" example:"https://example.com/icon.png"`
+	// URL of the small monochrome badge image.
+	Badge string `yaml:"badge" bson:"badge,omitempty" json:"badge,omitempty" example:"https://example.com/badge.png"`
+}
+
+// Merge returns the configuration where the non-empty fields of the override replace the fields of c
+func (c PlatformConfig) Merge(override PlatformConfig) PlatformConfig {
+	merged := c
+	mergeString(&merged.Android.Priority, override.Android.Priority)
+	mergeString(&merged.Android.ChannelID, override.Android.ChannelID)
+	mergeString(&merged.Android.Sound, override.Android.Sound)
+	mergeString(&merged.Android.Color, override.Android.Color)
+	if override.Android.NotificationCount != nil {
+		merged.Android.NotificationCount = override.Android.NotificationCount
+	}
+	mergeString(&merged.APNS.Sound, override.APNS.Sound)
+	mergeString(&merged.APNS.ThreadID, override.APNS.ThreadID)
+	if override.APNS.Badge != nil {
+		merged.APNS.Badge = override.APNS.Badge
+	}
+	mergeString(&merged.Webpush.Icon, override.Webpush.Icon)
+	mergeString(&merged.Webpush.Badge, override.Webpush.Badge)
+	return merged
+}
+
+func mergeString(target *string, override string) {
+	if override != "" {
+		*target = override
+	}
+}
@@ -0,0 +1,64 @@
+// AnhCao 2024
+package models
+
+import "testing"
+
+func TestPushResolve(t *testing.T) {
+	badge := 3
+	push := &Push{
+		Defaults: PlatformConfig{
+			Android: AndroidPush{Priority: "normal", Sound: "default"},
+			APNS:    APNSPush{Sound: "default"},
+		},
+		Categories: map[string]PlatformConfig{
+			"reminder": {Android: AndroidPush{Priority: "high", ChannelID: "reminders"}},
+		},
+	}
+	tests := []struct {
+		name      string
+		push      *Push
+		category  string
+		overrides *PlatformConfig
+		want      PlatformConfig
+	}{
+		{
+			name:     "defaults",
+			push:     push,
+			category: "tips",
+			want:     push.Defaults,
+		},
+		{
+			name:     "category on top of the defaults",
+			push:     push,
+			category: "reminder",
+			want: PlatformConfig{
+				Android: AndroidPush{Priority: "high", ChannelID: "reminders", Sound: "default"},
+				APNS:    APNSPush{Sound: "default"},
+			},
+		},
+		{
+			name:      "message on top of the category",
+			push:      push,
+			category:  "reminder",
+			overrides: &PlatformConfig{Android: AndroidPush{ChannelID: "alarms"}, APNS: APNSPush{Badge: &badge}},
+			want: PlatformConfig{
+				Android: AndroidPush{Priority: "high", ChannelID: "alarms", Sound: "default"},
+				APNS:    APNSPush{Sound: "default", Badge: &badge},
+			},
+		},
+		{
+			name:      "without configuration",
+			overrides: &PlatformConfig{Webpush: WebPush{Icon: "https://example.com/icon.png"}},
+			want:      PlatformConfig{Webpush: WebPush{Icon: "https://example.com/icon.png"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.push.Resolve(tt.category, tt.overrides)
+			if got.Android != tt.want.Android || got.Webpush != tt.want.Webpush ||
+				got.APNS.Sound != tt.want.APNS.Sound || got.APNS.ThreadID != tt.want.APNS.ThreadID || got.APNS.Badge != tt.want.APNS.Badge {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ReminderType represents the kind of price event that user wants to be reminded about.
+type ReminderType string
+
+const (
+	// ReminderCheapestWindow reminds user before the cheapest window of consecutive hours begins.
+	ReminderCheapestWindow ReminderType = "cheapest_window"
+	// ReminderThreshold reminds user before the price drops below the given threshold.
+	ReminderThreshold ReminderType = "threshold"
+)
+
+// Reminder represents the request of a user to be reminded before a cheap price period begins.
+type Reminder struct {
+	// Unique identifier for the reminder.
+	ID bson.ObjectID `bson:"_id" json:"id" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// Identifier of the user who owns the reminder.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// The kind of price event to be reminded about.
+	Type ReminderType `bson:"type" json:"type" example:"cheapest_window" enums:"cheapest_window,threshold"`
+	// How many minutes before the period begins the reminder is sent.
+	MinutesBefore int `bson:"minutesBefore" json:"minutesBefore" example:"15"`
+	// Length of the cheapest window in hours. Only used with type `cheapest_window`.
+	WindowHours int `bson:"windowHours,omitempty" json:"windowHours,omitempty" example:"3"`
+	// Price threshold in the unit of the price series (ex: c/kWh), compared with the effective price of the user's contract.
+	// Only used with type `threshold`.
+	Threshold float64 `bson:"threshold,omitempty" json:"threshold,omitempty" example:"5"`
+	// The time when the reminder was created.
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt" example:"2025-01-02 14:00:00 +0200 EET"`
+}
@@ -0,0 +1,62 @@
+// AnhCao 2024
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Webhook represents an HTTPS endpoint of a user which receives the notifications of the user as signed JSON requests.
+type Webhook struct {
+	// Unique identifier for the webhook.
+	ID bson.ObjectID `bson:"_id" json:"id" example:"677e5c2b8f1b2c0a4d3e2f10"`
+	// Identifier of the user who owns the webhook.
+	UserId string `bson:"userId" json:"userId" example:"1234567890"`
+	// HTTPS URL which receives the notifications.
+	URL string `bson:"url" json:"url" example:"https://automation.example.com/hooks/electricity"`
+	// Secret which signs the requests. It is only returned when the webhook is created.
+	Secret string `bson:"secret" json:"secret,omitempty" example:"whsec_9f86d081884c7d659a2feaa0c55ad015"`
+	// Whether the webhook receives notifications. It is disabled automatically after too many consecutive failed deliveries.
+	Enabled bool `bson:"enabled" json:"enabled" example:"true"`
+	// Number of failed deliveries since the last successful one.
+	ConsecutiveFailures int `bson:"consecutiveFailures" json:"consecutiveFailures" example:"0"`
+	// HTTP status code of the last delivery attempt, zero if the endpoint could not be reached.
+	LastStatusCode int `bson:"lastStatusCode,omitempty" json:"lastStatusCode,omitempty" example:"200"`
+	// Reason of the last failed delivery.
+	LastError string `bson:"lastError,omitempty" json:"lastError,omitempty" example:"unexpected status code 500"`
+	// The time of the last delivery.
+	LastDeliveryAt *time.Time `bson:"lastDeliveryAt,omitempty" json:"lastDeliveryAt,omitempty" example:"2025-01-02 14:00:00 +0200 EET"`
+	// The time when the webhook was disabled automatically.
+	DisabledAt *time.Time `bson:"disabledAt,omitempty" json:"disabledAt,omitempty" example:"2025-01-02 14:00:00 +0200 EET"`
+	// The time when the webhook was created.
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt" example:"2025-01-02 14:00:00 +0200 EET"`
+}
+
+// WebhookRequest represents the webhook that a user registers.
+type WebhookRequest struct {
+	// HTTPS URL which receives the notifications.
+	URL string `json:"url" example:"https://automation.example.com/hooks/electricity"`
+}
+
+// WebhookPayload represents the JSON body which is POSTed to the webhooks of a user.
+type WebhookPayload struct {
+	// Unique identifier of the delivery. Retries of the same delivery have the same identifier.
+	DeliveryId string `json:"deliveryId" example:"4f9c2a7e0b1d4c3e8a6f5b2d1c0e9f8a"`
+	// Type of the notification (ex: daily_prices).
+	Type string `json:"type,omitempty" example:"daily_prices"`
+	// Identifier of the user who receives the notification.
+	UserId string `json:"userId" example:"1234567890"`
+	// Title of the notification.
+	Title string `json:"title,omitempty" example:"Electricity prices for tomorrow"`
+	// Body text of the notification.
+	Body string `json:"body,omitempty" example:"Tomorrow price is 2.47 c/kWh"`
+	// URL of an image of the notification, ex: the price chart.
+	ImageURL string `json:"imageUrl,omitempty" example:"https://example.com/v1/prices/2024-12-09/chart.png"`
+	// Category of the notification.
+	Category string `json:"category,omitempty" example:"daily_prices"`
+	// Custom key-value pairs of the notification.
+	Data map[string]string `json:"data,omitempty"`
+	// The time when the notification was created.
+	CreatedAt time.Time `json:"createdAt" example:"2025-01-02 14:00:00 +0200 EET"`
+}
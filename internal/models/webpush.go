@@ -0,0 +1,52 @@
+// AnhCao 2024
+package models
+
+import "time"
+
+// WebPushKeys represents the encryption keys of a standard Web Push subscription, as given by the browser.
+type WebPushKeys struct {
+	// Public key of the browser on the P-256 curve, base64url encoded.
+	P256dh string `bson:"p256dh" json:"p256dh" example:"BNcRdreALRFXTkOOUHK1EtK2wtaz5Ry4YfYCA_0QTpQtUbVlUls0VJXg7A8u-Ts1XbjhazAkj7I99e8QcYP7DkM"`
+	// Authentication secret of the subscription, base64url encoded.
+	Auth string `bson:"auth" json:"auth" example:"tBHItJI5svbpez7KI4CCXg"`
+}
+
+// WebPushSubscription represents the subscription of a browser to the standard Web Push channel.
+// It has the same shape as `PushSubscription.toJSON()` in the browser.
+type WebPushSubscription struct {
+	// URL of the push service which delivers the notifications to the browser.
+	Endpoint string `json:"endpoint" example:"https://fcm.googleapis.com/fcm/send/dpH5lCsTSSM:APA91bH"`
+	// Encryption keys of the subscription.
+	Keys WebPushKeys `json:"keys"`
+}
+
+// WebPushPayload represents the JSON which is delivered encrypted to the service worker of the browser.
+type WebPushPayload struct {
+	Title       string            `json:"title,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	Image       string            `json:"image,omitempty"`
+	Icon        string            `json:"icon,omitempty"`
+	Badge       string            `json:"badge,omitempty"`
+	ClickAction string            `json:"clickAction,omitempty"`
+	Category    string            `json:"category,omitempty"`
+	Data        map[string]string `json:"data,omitempty"`
+}
+
+// VAPIDKey represents the public key that browsers subscribe with (`applicationServerKey`).
+type VAPIDKey struct {
+	// Public VAPID key on the P-256 curve, base64url encoded.
+	PublicKey string `json:"publicKey" example:"BEl62iUYgUivxIkv69yViEuiBIa-Ib9-SkvMeAtA3LFgDzkrxZJjSgSnfckjBJuBkr3qBUYIHBQFLXYp5Nksh8U"`
+}
+
+// VAPID represents the configuration settings of the standard Web Push channel.
+// The channel is disabled if the private key is not set.
+type VAPID struct {
+	// Public VAPID key, base64url encoded uncompressed P-256 point. It must belong to the private key.
+	PublicKey string `yaml:"public_key"`
+	// Private VAPID key, base64url encoded P-256 scalar.
+	PrivateKey string `yaml:"private_key"`
+	// Contact of the sender for the push services, a "mailto:" or "https:" URL.
+	Subject string `yaml:"subject"`
+	// How long push services keep an undelivered notification (ex: "24h").
+	TTL time.Duration `yaml:"ttl"`
+}
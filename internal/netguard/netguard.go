@@ -0,0 +1,91 @@
+// AnhCao 2024
+//
+// Package netguard keeps the requests to URLs given by users (ex: webhooks) away from the internal network
+// of the service, which would otherwise allow server-side request forgery. The URLs are checked when they are
+// registered, and the addresses are checked again when the connection is made, so a hostname which later
+// resolves to an internal address (DNS rebinding) is rejected as well.
+package netguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const resolveTimeout = 5 * time.Second
+
+// blockedPrefixes are the non-public ranges which the methods of netip.Addr do not cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, including the broadcast address
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which maps to IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, which embeds IPv4 addresses
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// CheckAddr returns an error if the address is not a public unicast address:
+// loopback, private, link-local, unspecified, multicast and reserved addresses are rejected
+func CheckAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return fmt.Errorf("address %s is not public", addr)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("address %s is not public", addr)
+		}
+	}
+	return nil
+}
+
+// CheckHost returns an error if the host is not a public address or a hostname which only resolves to public addresses
+func CheckHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return CheckAddr(addr)
+	}
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve host %s", host)
+	}
+	for _, addr := range addrs {
+		if err := CheckAddr(addr); err != nil {
+			return fmt.Errorf("host %s resolves to a non-public address", host)
+		}
+	}
+	return nil
+}
+
+// Control rejects connections to non-public addresses. It is used as net.Dialer.Control,
+// which is called with the resolved address of every connection attempt.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %s: %s", address, err.Error())
+	}
+	return CheckAddr(addrPort.Addr())
+}
+
+// NewTransport returns an HTTP transport which only connects to public addresses.
+// It does not use a proxy, as the proxy would make the connections instead of the guarded dialer.
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   Control,
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
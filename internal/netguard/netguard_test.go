@@ -0,0 +1,65 @@
+// AnhCao 2024
+package netguard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestCheckAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.215.14", public: true},
+		{addr: "2606:4700:4700::1111", public: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "10.0.0.5"},
+		{addr: "172.16.3.4"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "fd00::1"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "224.0.0.1"},
+		{addr: "ff02::1"},
+		{addr: "100.64.0.1"},
+		{addr: "255.255.255.255"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "::ffff:169.254.169.254"},
+		{addr: "64:ff9b::a00:5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := CheckAddr(netip.MustParseAddr(tt.addr))
+			if (err == nil) != tt.public {
+				t.Errorf("CheckAddr(%s) error = %v, want public %v", tt.addr, err, tt.public)
+			}
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "::1", "localhost"} {
+		if err := CheckHost(context.Background(), host); err == nil {
+			t.Errorf("CheckHost(%s) accepted a non-public host", host)
+		}
+	}
+}
+
+func TestTransportRejectsInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the internal server")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport()}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("Get() of a loopback server succeeded, want an error")
+	}
+}
@@ -0,0 +1,99 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// Dispatcher routes every notification to all registered channels and combines their results.
+// It implements Notifier itself, so callers do not need to know which channels exist.
+type Dispatcher struct {
+	logger      *zap.Logger
+	preferences PreferenceStore
+	channels    []Notifier
+	// if set, every notification is sent in dry-run mode
+	dryRun bool
+}
+
+// NewDispatcher creates a new Dispatcher which delivers notifications through given channels.
+// Notifications to users only go through the channels that the user selected in the preference store;
+// if the preference store is nil, every channel is used.
+func NewDispatcher(logger *zap.Logger, preferences PreferenceStore, channels ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		logger:      logger,
+		preferences: preferences,
+		channels:    channels,
+	}
+}
+
+// SetDryRun switches the dry-run mode of all notifications on or off. In dry-run mode the channels only validate
+// and render the notifications, without delivering them.
+func (d *Dispatcher) SetDryRun(dryRun bool) {
+	d.dryRun = dryRun
+}
+
+// Register adds a new delivery channel to the dispatcher
+func (d *Dispatcher) Register(channel Notifier) {
+	d.channels = append(d.channels, channel)
+}
+
+// Channel returns the name of the dispatcher
+func (d *Dispatcher) Channel() string {
+	return "dispatcher"
+}
+
+// SendToUser sends the notification to the user through every channel that the user selected
+func (d *Dispatcher) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	var preferences *models.Preferences
+	if d.preferences != nil {
+		var err error
+		if preferences, err = d.preferences.GetPreferences(userId); err != nil {
+			return nil, fmt.Errorf("failed to get preferences of user %s: %s", userId, err.Error())
+		}
+	}
+	return d.dispatch(ctx, func(ctx context.Context, channel Notifier) ([]models.DeliveryResult, error) {
+		if d.preferences != nil && !preferences.Allows(channel.Channel()) {
+			return nil, nil
+		}
+		return channel.SendToUser(ctx, userId, message)
+	})
+}
+
+// SendToDevices sends the notification to given devices through every channel which handles them
+func (d *Dispatcher) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return d.dispatch(ctx, func(ctx context.Context, channel Notifier) ([]models.DeliveryResult, error) {
+		return channel.SendToDevices(ctx, devices, message)
+	})
+}
+
+// SendToTopic sends the notification to the topic through every channel
+func (d *Dispatcher) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return d.dispatch(ctx, func(ctx context.Context, channel Notifier) ([]models.DeliveryResult, error) {
+		return channel.SendToTopic(ctx, topic, message)
+	})
+}
+
+// dispatch calls send for every channel. A failing channel does not prevent the others from delivering;
+// the results of all channels are returned together with the errors of the failed channels.
+func (d *Dispatcher) dispatch(ctx context.Context, send func(ctx context.Context, channel Notifier) ([]models.DeliveryResult, error)) ([]models.DeliveryResult, error) {
+	if d.dryRun {
+		ctx = WithDryRun(ctx)
+	}
+	results := make([]models.DeliveryResult, 0)
+	var errs []error
+	for _, channel := range d.channels {
+		channelResults, err := send(ctx, channel)
+		results = append(results, channelResults...)
+		if err != nil {
+			d.logger.Error("failed to deliver notification", zap.String("channel", channel.Channel()), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %s", channel.Channel(), err.Error()))
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// preferenceStub returns the same preferences for every user
+type preferenceStub struct {
+	preferences *models.Preferences
+	err         error
+}
+
+func (p preferenceStub) GetPreferences(userId string) (*models.Preferences, error) {
+	return p.preferences, p.err
+}
+
+func TestDispatcherMergesResultsOfChannels(t *testing.T) {
+	push := &Recorder{Name: models.ChannelPush}
+	webhook := &Recorder{Name: models.ChannelWebhook}
+	dispatcher := NewDispatcher(zap.NewNop(), nil, push, webhook)
+
+	devices := []models.NotificationToken{{DeviceId: "a"}, {DeviceId: "b"}}
+	results, err := dispatcher.SendToDevices(context.Background(), devices, models.NotificationMessage{UserId: "user", Title: "title"})
+	if err != nil {
+		t.Fatalf("SendToDevices() error = %v", err)
+	}
+	want := []string{"push:a", "push:b", "webhook:a", "webhook:b"}
+	if len(results) != len(want) {
+		t.Fatalf("SendToDevices() = %d results, want %d", len(results), len(want))
+	}
+	for idx, result := range results {
+		if got := result.Channel + ":" + result.Recipient; got != want[idx] || !result.Success || result.UserId != "user" {
+			t.Errorf("SendToDevices() result %d = %+v, want successful %s", idx, result, want[idx])
+		}
+	}
+}
+
+func TestDispatcherContinuesAfterFailingChannel(t *testing.T) {
+	email := &Recorder{Name: models.ChannelEmail, Err: errors.New("smtp unavailable")}
+	push := &Recorder{Name: models.ChannelPush}
+	dispatcher := NewDispatcher(zap.NewNop(), nil, email, push)
+
+	results, err := dispatcher.SendToUser(context.Background(), "user", models.NotificationMessage{Title: "title"})
+	if err == nil || !strings.Contains(err.Error(), "email: smtp unavailable") {
+		t.Errorf("SendToUser() error = %v, want the error of the email channel", err)
+	}
+	if len(push.Calls()) != 1 {
+		t.Errorf("push channel got %d calls, want 1", len(push.Calls()))
+	}
+	if len(results) != 2 || results[0].Success || !results[1].Success {
+		t.Errorf("SendToUser() = %+v, want a failed email and a delivered push", results)
+	}
+}
+
+func TestDispatcherFiltersChannelsByPreferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences PreferenceStore
+		want        []string
+		wantErr     bool
+	}{
+		{name: "without preference store every channel is used", preferences: nil, want: []string{"push", "email", "custom"}},
+		{name: "without preferences the default channels are used", preferences: preferenceStub{}, want: []string{"push", "email"}},
+		{name: "selected channels only", preferences: preferenceStub{preferences: &models.Preferences{Channels: []string{models.ChannelEmail}}}, want: []string{"email"}},
+		{name: "failing preference store sends nothing", preferences: preferenceStub{err: errors.New("database down")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels := []*Recorder{{Name: models.ChannelPush}, {Name: models.ChannelEmail}, {Name: "custom"}}
+			dispatcher := NewDispatcher(zap.NewNop(), tt.preferences, channels[0], channels[1], channels[2])
+
+			_, err := dispatcher.SendToUser(context.Background(), "user", models.NotificationMessage{Title: "title"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendToUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var used []string
+			for _, channel := range channels {
+				if len(channel.Calls()) > 0 {
+					used = append(used, channel.Channel())
+				}
+			}
+			if strings.Join(used, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SendToUser() used channels %v, want %v", used, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherPropagatesDryRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceWide bool
+		perRequest  bool
+		want        bool
+	}{
+		{name: "off", want: false},
+		{name: "per request", perRequest: true, want: true},
+		{name: "service wide", serviceWide: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push := &Recorder{Name: models.ChannelPush}
+			dispatcher := NewDispatcher(zap.NewNop(), nil, push)
+			dispatcher.SetDryRun(tt.serviceWide)
+			ctx := context.Background()
+			if tt.perRequest {
+				ctx = WithDryRun(ctx)
+			}
+
+			if _, err := dispatcher.SendToTopic(ctx, "prices", models.NotificationMessage{Title: "title"}); err != nil {
+				t.Fatalf("SendToTopic() error = %v", err)
+			}
+			calls := push.Calls()
+			if len(calls) != 1 || calls[0].DryRun != tt.want || calls[0].Topic != "prices" {
+				t.Errorf("SendToTopic() calls = %+v, want a single call with dry-run %v", calls, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnhCaooo/electric-notifications/internal/email"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// Email delivers notifications as emails to the verified email address of the user
+type Email struct {
+	mailer      *email.Mailer
+	preferences PreferenceStore
+}
+
+// NewEmail creates a new email notifier which looks up the email addresses of users from given preference store
+func NewEmail(mailer *email.Mailer, preferences PreferenceStore) *Email {
+	return &Email{
+		mailer:      mailer,
+		preferences: preferences,
+	}
+}
+
+// Channel returns the name of the email channel
+func (e *Email) Channel() string {
+	return email.Channel
+}
+
+// SendToUser sends the notification to the email address of the user. Users without a verified address are skipped,
+// and so are broadcasts which only target matching devices.
+func (e *Email) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	if skipsDevicelessChannels(ctx) {
+		return []models.DeliveryResult{}, nil
+	}
+	preferences, err := e.preferences.GetPreferences(userId)
+	if err != nil {
+		return nil, err
+	}
+	if preferences == nil || preferences.Email == "" || !preferences.EmailVerified {
+		return []models.DeliveryResult{}, nil
+	}
+
+	result := models.DeliveryResult{Channel: email.Channel, UserId: userId, Recipient: preferences.Email}
+	mail, err := email.RenderNotification(preferences.Email, message)
+	if err == nil && IsDryRun(ctx) {
+		result.Preview = fmt.Sprintf("Subject: %s\n\n%s", mail.Subject, mail.Text)
+	} else if err == nil {
+		result.MessageId, err = e.mailer.Send(ctx, mail)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return []models.DeliveryResult{result}, err
+	}
+	result.Success = true
+	return []models.DeliveryResult{result}, nil
+}
+
+// SendToDevices does nothing, as devices are not recipients of the email channel
+func (e *Email) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return []models.DeliveryResult{}, nil
+}
+
+// SendToTopic does nothing, as the email channel has no topics
+func (e *Email) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return []models.DeliveryResult{}, nil
+}
@@ -0,0 +1,78 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/email"
+	"github.com/AnhCaooo/electric-notifications/internal/email/emailtest"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestEmailDryRun(t *testing.T) {
+	verified := &models.Preferences{UserId: "user", Email: "user@example.com", EmailVerified: true}
+	tests := []struct {
+		name        string
+		preferences *models.Preferences
+		dryRun      bool
+		wantResults int
+		wantSent    bool
+		wantPreview bool
+	}{
+		{
+			name:        "email is sent",
+			preferences: verified,
+			wantResults: 1,
+			wantSent:    true,
+		},
+		{
+			name:        "email is rendered but not sent in dry-run mode",
+			preferences: verified,
+			dryRun:      true,
+			wantResults: 1,
+			wantPreview: true,
+		},
+		{
+			name:        "unconfirmed address is skipped",
+			preferences: &models.Preferences{UserId: "user", Email: "user@example.com"},
+			dryRun:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := emailtest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+			channel := NewEmail(email.NewMailer(server.Config("noreply@example.com"), nil), preferenceStub{preferences: tt.preferences})
+			ctx := context.Background()
+			if tt.dryRun {
+				ctx = WithDryRun(ctx)
+			}
+
+			results, err := channel.SendToUser(ctx, "user", models.NotificationMessage{Title: "Cheap hours", Body: "03:00 - 06:00"})
+			if err != nil {
+				t.Fatalf("SendToUser() error = %v", err)
+			}
+			if len(results) != tt.wantResults {
+				t.Fatalf("SendToUser() results = %+v, want %d", results, tt.wantResults)
+			}
+			if sent := len(server.Messages()) == 1; sent != tt.wantSent {
+				t.Errorf("email sent %v, want sent %v", sent, tt.wantSent)
+			}
+			if tt.wantResults == 0 {
+				return
+			}
+			result := results[0]
+			if !result.Success || result.Recipient != "user@example.com" || (result.MessageId != "") != tt.wantSent {
+				t.Errorf("SendToUser() result = %+v, want a successful delivery to the address", result)
+			}
+			if preview := strings.HasPrefix(result.Preview, "Subject: Cheap hours") && strings.Contains(result.Preview, "03:00 - 06:00"); preview != tt.wantPreview {
+				t.Errorf("SendToUser() preview = %q, want preview %v", result.Preview, tt.wantPreview)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+
+	"github.com/AnhCaooo/electric-notifications/internal/firebase"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// FCM delivers notifications as push notifications through Firebase Cloud Messaging
+type FCM struct {
+	firebase *firebase.Firebase
+	tokens   TokenStore
+}
+
+// NewFCM creates a new FCM notifier which looks up the devices of users from given token store
+func NewFCM(firebase *firebase.Firebase, tokens TokenStore) *FCM {
+	return &FCM{
+		firebase: firebase,
+		tokens:   tokens,
+	}
+}
+
+// Channel returns the name of the FCM channel
+func (f *FCM) Channel() string {
+	return firebase.Channel
+}
+
+// SendToUser sends the notification to all devices of the user, or to the devices which match the segment of a broadcast
+func (f *FCM) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	devices, err := f.tokens.GetTokens(userId)
+	if err != nil {
+		return nil, err
+	}
+	return f.SendToDevices(ctx, segmentDevices(ctx, devices), message)
+}
+
+// SendToDevices sends the notification to given devices. Subscriptions of the standard Web Push channel are skipped.
+func (f *FCM) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	fcmDevices := make([]models.NotificationToken, 0, len(devices))
+	for _, device := range devices {
+		if device.Platform != models.PlatformWebPush {
+			fcmDevices = append(fcmDevices, device)
+		}
+	}
+	if len(fcmDevices) == 0 {
+		return []models.DeliveryResult{}, nil
+	}
+	return f.firebase.SendToMultiTokens(fcmDevices, message, IsDryRun(ctx))
+}
+
+// SendToTopic sends the notification to all devices subscribed to the FCM topic
+func (f *FCM) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	result, err := f.firebase.SendToTopic(topic, message, IsDryRun(ctx))
+	return []models.DeliveryResult{result}, err
+}
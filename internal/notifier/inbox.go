@@ -0,0 +1,109 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// InboxStore persists the notifications in the in-app inbox of the users
+type InboxStore interface {
+	InsertInboxItem(item models.InboxItem) (*models.InboxItem, error)
+	CountUnread(userId string) (int64, error)
+}
+
+// Inbox stores every notification sent to a user in the in-app inbox of the user before it is delivered,
+// and sends the number of unread notifications as the badge of the app. It wraps another Notifier
+// (ex: the Dispatcher) and implements Notifier itself. Notifications to devices and topics are not stored,
+// as they are not addressed to a single user, and neither are notifications in dry-run mode.
+type Inbox struct {
+	logger *zap.Logger
+	next   Notifier
+	store  InboxStore
+	// if set, every notification is sent in dry-run mode
+	dryRun bool
+}
+
+// NewInbox creates a new Inbox which stores the notifications sent through given notifier
+func NewInbox(logger *zap.Logger, next Notifier, store InboxStore) *Inbox {
+	return &Inbox{
+		logger: logger,
+		next:   next,
+		store:  store,
+	}
+}
+
+// SetDryRun switches the dry-run mode of all notifications on or off. In dry-run mode nothing is stored in the inbox,
+// and the notifiers it wraps (ex: the Dispatcher) see the mode as well.
+func (i *Inbox) SetDryRun(dryRun bool) {
+	i.dryRun = dryRun
+}
+
+// Channel returns the name of the wrapped notifier
+func (i *Inbox) Channel() string {
+	return i.next.Channel()
+}
+
+// SendToUser stores the notification in the inbox of the user and sends it together with the unread count.
+// A failure to store the notification is only logged, so the notification is still delivered.
+func (i *Inbox) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	if i.dryRun {
+		ctx = WithDryRun(ctx)
+	}
+	if IsDryRun(ctx) {
+		return i.next.SendToUser(ctx, userId, message)
+	}
+
+	item, err := i.store.InsertInboxItem(models.InboxItem{
+		UserId:         userId,
+		NotificationId: NotificationId(ctx),
+		Title:          message.Title,
+		Body:           message.GetBody(),
+		ImageURL:       message.ImageURL,
+		ClickAction:    message.ClickAction,
+		Category:       message.Category,
+		Data:           message.Data,
+	})
+	if err != nil {
+		i.logger.Error("failed to store notification in inbox", zap.String("user_id", userId), zap.Error(err))
+		return i.next.SendToUser(ctx, userId, message)
+	}
+	unread, err := i.store.CountUnread(userId)
+	if err != nil {
+		i.logger.Error("failed to count unread notifications", zap.String("user_id", userId), zap.Error(err))
+		return i.next.SendToUser(ctx, userId, message)
+	}
+
+	// the data of the caller is copied, as the same message may be sent to other users
+	data := make(map[string]string, len(message.Data)+2)
+	for key, value := range message.Data {
+		data[key] = value
+	}
+	data[constants.DataKeyInboxId] = item.ID.Hex()
+	data[constants.DataKeyBadge] = strconv.FormatInt(unread, 10)
+	message.Data = data
+	badge := int(unread)
+	message.Badge = &badge
+	return i.next.SendToUser(ctx, userId, message)
+}
+
+// SendToDevices sends the notification to given devices without storing it
+func (i *Inbox) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	if i.dryRun {
+		ctx = WithDryRun(ctx)
+	}
+	return i.next.SendToDevices(ctx, devices, message)
+}
+
+// SendToTopic sends the notification to the topic without storing it
+func (i *Inbox) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	if i.dryRun {
+		ctx = WithDryRun(ctx)
+	}
+	return i.next.SendToTopic(ctx, topic, message)
+}
@@ -0,0 +1,48 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestInboxDryRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		dryRun    bool
+		wantItems int
+		wantBadge bool
+	}{
+		{name: "stored with the badge", wantItems: 1, wantBadge: true},
+		{name: "dry-run of the service", dryRun: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push := &Recorder{Name: "push"}
+			store := &inboxStub{}
+			// the RabbitMQ consumer and the scheduler do not mark their context as dry-run
+			inbox := NewInbox(zap.NewNop(), push, store)
+			inbox.SetDryRun(tt.dryRun)
+
+			if _, err := inbox.SendToUser(context.Background(), "user-1", models.NotificationMessage{UserId: "user-1", Title: "Cheap hours"}); err != nil {
+				t.Fatalf("SendToUser() error = %v", err)
+			}
+
+			if len(store.items) != tt.wantItems {
+				t.Errorf("stored %d inbox items, want %d", len(store.items), tt.wantItems)
+			}
+			calls := push.Calls()
+			if len(calls) != 1 || calls[0].DryRun != tt.dryRun {
+				t.Fatalf("channel calls = %+v, want one call with dry-run %v", calls, tt.dryRun)
+			}
+			if _, ok := calls[0].Message.Data[constants.DataKeyBadge]; ok != tt.wantBadge {
+				t.Errorf("message data = %v, want badge %v", calls[0].Message.Data, tt.wantBadge)
+			}
+		})
+	}
+}
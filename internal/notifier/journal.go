@@ -0,0 +1,121 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// DeliveryStore persists the delivery log of the notifications
+type DeliveryStore interface {
+	InsertDeliveries(deliveries []models.DeliveryLog) error
+}
+
+// Journal records the outcome of every notification in the delivery log, so it can be answered later on
+// whether a user received a notification. It wraps another Notifier (ex: the Dispatcher) and implements Notifier itself.
+// Notifications in dry-run mode are not recorded.
+type Journal struct {
+	logger *zap.Logger
+	next   Notifier
+	store  DeliveryStore
+	// if set, every notification is sent in dry-run mode
+	dryRun bool
+}
+
+// NewJournal creates a new Journal which records the notifications sent through given notifier
+func NewJournal(logger *zap.Logger, next Notifier, store DeliveryStore) *Journal {
+	return &Journal{
+		logger: logger,
+		next:   next,
+		store:  store,
+	}
+}
+
+// SetDryRun switches the dry-run mode of all notifications on or off. As the Journal is the outermost notifier,
+// the notifiers it wraps (ex: the Inbox) see the mode as well and do not store anything.
+func (j *Journal) SetDryRun(dryRun bool) {
+	j.dryRun = dryRun
+}
+
+// Channel returns the name of the wrapped notifier
+func (j *Journal) Channel() string {
+	return j.next.Channel()
+}
+
+// SendToUser sends the notification to the user and records the outcome
+func (j *Journal) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return j.record(ctx, message, func(ctx context.Context) ([]models.DeliveryResult, error) {
+		return j.next.SendToUser(ctx, userId, message)
+	})
+}
+
+// SendToDevices sends the notification to given devices and records the outcome
+func (j *Journal) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return j.record(ctx, message, func(ctx context.Context) ([]models.DeliveryResult, error) {
+		return j.next.SendToDevices(ctx, devices, message)
+	})
+}
+
+// SendToTopic sends the notification to the topic and records the outcome
+func (j *Journal) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return j.record(ctx, message, func(ctx context.Context) ([]models.DeliveryResult, error) {
+		return j.next.SendToTopic(ctx, topic, message)
+	})
+}
+
+// record sends the notification and stores a delivery record for every recipient. The notification gets a new ID
+// unless the context already has one. A failure to store the records is only logged, as the notification was already sent.
+func (j *Journal) record(ctx context.Context, message models.NotificationMessage, send func(ctx context.Context) ([]models.DeliveryResult, error)) ([]models.DeliveryResult, error) {
+	if j.dryRun {
+		ctx = WithDryRun(ctx)
+	}
+	notificationId := NotificationId(ctx)
+	if notificationId == "" {
+		notificationId = bson.NewObjectID().Hex()
+		ctx = WithNotificationId(ctx, notificationId)
+	}
+	sentAt := time.Now().UTC()
+	results, err := send(ctx)
+	if IsDryRun(ctx) || len(results) == 0 {
+		return results, err
+	}
+
+	completedAt := time.Now().UTC()
+	deliveries := make([]models.DeliveryLog, 0, len(results))
+	for _, result := range results {
+		status := models.DeliveryStatusDelivered
+		if !result.Success {
+			status = models.DeliveryStatusFailed
+		}
+		userId := result.UserId
+		if userId == "" {
+			userId = message.UserId
+		}
+		deliveries = append(deliveries, models.DeliveryLog{
+			NotificationId: notificationId,
+			Source:         Source(ctx),
+			Service:        Service(ctx),
+			UserId:         userId,
+			Channel:        result.Channel,
+			Recipient:      result.Recipient,
+			Title:          message.Title,
+			Category:       message.Category,
+			Status:         status,
+			MessageId:      result.MessageId,
+			ErrorCode:      result.ErrorCode,
+			Error:          result.Error,
+			Attempts:       result.Attempts,
+			SentAt:         sentAt,
+			CompletedAt:    completedAt,
+		})
+	}
+	if storeErr := j.store.InsertDeliveries(deliveries); storeErr != nil {
+		j.logger.Error("failed to record deliveries", zap.String("notification_id", notificationId), zap.Error(storeErr))
+	}
+	return results, err
+}
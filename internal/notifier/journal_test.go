@@ -0,0 +1,93 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// deliveryStub stores the delivery log in memory
+type deliveryStub struct {
+	mu         sync.Mutex
+	deliveries []models.DeliveryLog
+}
+
+func (s *deliveryStub) InsertDeliveries(deliveries []models.DeliveryLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, deliveries...)
+	return nil
+}
+
+// inboxStub stores the inbox items in memory
+type inboxStub struct {
+	mu    sync.Mutex
+	items []models.InboxItem
+}
+
+func (s *inboxStub) InsertInboxItem(item models.InboxItem) (*models.InboxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item.ID = bson.NewObjectID()
+	s.items = append(s.items, item)
+	return &item, nil
+}
+
+func (s *inboxStub) CountUnread(userId string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var unread int64
+	for _, item := range s.items {
+		if item.UserId == userId && !item.Read {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func TestJournalDryRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		dryRun         bool
+		ctxDryRun      bool
+		wantDeliveries int
+		wantItems      int
+	}{
+		{name: "delivered", wantDeliveries: 1, wantItems: 1},
+		{name: "dry-run of the service", dryRun: true},
+		{name: "dry-run of the request", ctxDryRun: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push := &Recorder{Name: "push"}
+			deliveries, inbox := &deliveryStub{}, &inboxStub{}
+			journal := NewJournal(zap.NewNop(), NewInbox(zap.NewNop(), NewDispatcher(zap.NewNop(), nil, push), inbox), deliveries)
+			journal.SetDryRun(tt.dryRun)
+			ctx := context.Background()
+			if tt.ctxDryRun {
+				ctx = WithDryRun(ctx)
+			}
+
+			if _, err := journal.SendToUser(ctx, "user-1", models.NotificationMessage{UserId: "user-1", Title: "Cheap hours"}); err != nil {
+				t.Fatalf("SendToUser() error = %v", err)
+			}
+
+			if len(deliveries.deliveries) != tt.wantDeliveries {
+				t.Errorf("recorded %d deliveries, want %d", len(deliveries.deliveries), tt.wantDeliveries)
+			}
+			if len(inbox.items) != tt.wantItems {
+				t.Errorf("stored %d inbox items, want %d", len(inbox.items), tt.wantItems)
+			}
+			calls := push.Calls()
+			if len(calls) != 1 || calls[0].DryRun != (tt.dryRun || tt.ctxDryRun) {
+				t.Errorf("channel calls = %+v, want one call with dry-run %v", calls, tt.dryRun || tt.ctxDryRun)
+			}
+		})
+	}
+}
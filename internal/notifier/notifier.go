@@ -0,0 +1,123 @@
+// AnhCao 2024
+//
+// Package notifier decouples the sending of notifications from the delivery channels.
+// Every channel (ex: FCM push) implements the Notifier interface, and the Dispatcher routes the notifications
+// to all registered channels, so new channels can be added without touching the callers.
+package notifier
+
+import (
+	"context"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// Notifier delivers notifications through a channel and reports the outcome for every recipient.
+type Notifier interface {
+	// Channel returns the name of the delivery channel (ex: push).
+	Channel() string
+	// SendToUser sends the notification to every recipient that the user has in the channel.
+	SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error)
+	// SendToDevices sends the notification to given devices. Devices that the channel does not handle are skipped.
+	SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error)
+	// SendToTopic sends the notification to everyone subscribed to the topic.
+	SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error)
+}
+
+// TokenStore provides the device tokens of users
+type TokenStore interface {
+	GetTokens(userId string) ([]models.NotificationToken, error)
+}
+
+// PreferenceStore provides the notification preferences of users.
+// It returns nil preferences without an error if the user has not configured any.
+type PreferenceStore interface {
+	GetPreferences(userId string) (*models.Preferences, error)
+}
+
+// WithDryRun returns a context in which the notifications are only validated and rendered by the channels,
+// without being delivered to anyone
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, constants.DryRunKey, true)
+}
+
+// IsDryRun reports whether the notifications of the context are only validated and rendered
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(constants.DryRunKey).(bool)
+	return dryRun
+}
+
+// WithSource returns a context which records where its notifications came from (ex: api)
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, constants.SourceKey, source)
+}
+
+// Source returns where the notifications of the context came from, or an empty string if unknown
+func Source(ctx context.Context) string {
+	source, _ := ctx.Value(constants.SourceKey).(string)
+	return source
+}
+
+// WithService returns a context which records the service that sent its notifications with an API key
+func WithService(ctx context.Context, service string) context.Context {
+	return context.WithValue(ctx, constants.ServiceKey, service)
+}
+
+// Service returns the name of the service which sent the notifications of the context, or an empty string if
+// the notifications were not sent by a service
+func Service(ctx context.Context) string {
+	service, _ := ctx.Value(constants.ServiceKey).(string)
+	return service
+}
+
+// WithNotificationId returns a context in which the notification is sent with given ID,
+// so the caller knows the ID of the notification in the delivery log
+func WithNotificationId(ctx context.Context, notificationId string) context.Context {
+	return context.WithValue(ctx, constants.NotificationIdKey, notificationId)
+}
+
+// NotificationId returns the ID of the notification of the context, or an empty string if it is not set
+func NotificationId(ctx context.Context) string {
+	notificationId, _ := ctx.Value(constants.NotificationIdKey).(string)
+	return notificationId
+}
+
+// WithSegment returns a context in which the notifications are only sent to the devices of the users
+// which match the device filters of the segment of a broadcast
+func WithSegment(ctx context.Context, segment models.Segment) context.Context {
+	return context.WithValue(ctx, constants.SegmentKey, segment)
+}
+
+// skipsDevicelessChannels reports whether the notifications of the context are only sent to matching devices,
+// so the channels without devices (ex: email) do not send them
+func skipsDevicelessChannels(ctx context.Context) bool {
+	segment, ok := ctx.Value(constants.SegmentKey).(models.Segment)
+	return ok && segment.FiltersDevices()
+}
+
+// segmentDevices returns the devices which match the segment of the context, or all devices if there is no segment
+func segmentDevices(ctx context.Context, devices []models.NotificationToken) []models.NotificationToken {
+	segment, ok := ctx.Value(constants.SegmentKey).(models.Segment)
+	if !ok || !segment.FiltersDevices() {
+		return devices
+	}
+	matching := make([]models.NotificationToken, 0, len(devices))
+	for _, device := range devices {
+		if segment.MatchesDevice(device) {
+			matching = append(matching, device)
+		}
+	}
+	return matching
+}
+
+// CountResults returns how many deliveries succeeded and failed
+func CountResults(results []models.DeliveryResult) (success, failure int) {
+	for _, result := range results {
+		if result.Success {
+			success++
+		} else {
+			failure++
+		}
+	}
+	return success, failure
+}
@@ -0,0 +1,53 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestSegmentDevices(t *testing.T) {
+	devices := []models.NotificationToken{
+		{DeviceId: "android-fi", Platform: "android", Locale: "fi-FI", AppVersion: "1.4.0", Area: "FI"},
+		{DeviceId: "ios-fi", Platform: "ios", Locale: "fi-FI", AppVersion: "1.3.0", Area: "FI"},
+		{DeviceId: "android-se", Platform: "android", Locale: "sv-SE", AppVersion: "1.4.0", Area: "SE3"},
+		{DeviceId: "web", Platform: models.PlatformWebPush},
+	}
+	tests := []struct {
+		name        string
+		segment     *models.Segment
+		wantDevices []string
+		// whether the channels without devices skip the notification
+		wantSkip bool
+	}{
+		{name: "no segment", wantDevices: []string{"android-fi", "ios-fi", "android-se", "web"}},
+		{name: "empty segment", segment: &models.Segment{}, wantDevices: []string{"android-fi", "ios-fi", "android-se", "web"}},
+		{name: "category only", segment: &models.Segment{Category: "tips"}, wantDevices: []string{"android-fi", "ios-fi", "android-se", "web"}},
+		{name: "platform", segment: &models.Segment{Platforms: []string{"android"}}, wantDevices: []string{"android-fi", "android-se"}, wantSkip: true},
+		{name: "any value of a filter", segment: &models.Segment{AppVersions: []string{"1.3.0", "1.4.0"}}, wantDevices: []string{"android-fi", "ios-fi", "android-se"}, wantSkip: true},
+		{name: "every filter", segment: &models.Segment{Platforms: []string{"android"}, Areas: []string{"FI"}}, wantDevices: []string{"android-fi"}, wantSkip: true},
+		{name: "locale", segment: &models.Segment{Locales: []string{"sv-SE"}}, wantDevices: []string{"android-se"}, wantSkip: true},
+		{name: "no matching device", segment: &models.Segment{Platforms: []string{"ios"}, Areas: []string{"SE3"}}, wantDevices: []string{}, wantSkip: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.segment != nil {
+				ctx = WithSegment(ctx, *tt.segment)
+			}
+			got := make([]string, 0)
+			for _, device := range segmentDevices(ctx, devices) {
+				got = append(got, device.DeviceId)
+			}
+			if !slices.Equal(got, tt.wantDevices) {
+				t.Errorf("segmentDevices() = %v, want %v", got, tt.wantDevices)
+			}
+			if skip := skipsDevicelessChannels(ctx); skip != tt.wantSkip {
+				t.Errorf("skipsDevicelessChannels() = %v, want %v", skip, tt.wantSkip)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// RecordedCall represents a single call made to the Recorder
+type RecordedCall struct {
+	Method  string // SendToUser, SendToDevices or SendToTopic
+	UserId  string
+	Devices []models.NotificationToken
+	Topic   string
+	Message models.NotificationMessage
+	// Whether the call was made in dry-run mode.
+	DryRun bool
+}
+
+// Recorder is a fake Notifier for tests. It records every call and reports a successful delivery
+// to every recipient, unless Err is set, in which case every call fails with Err.
+type Recorder struct {
+	// Name of the fake channel, defaults to "recorder".
+	Name string
+	// Error returned by every call.
+	Err error
+
+	lock  sync.Mutex
+	calls []RecordedCall
+}
+
+// Calls returns a copy of the calls recorded so far
+func (r *Recorder) Calls() []RecordedCall {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]RecordedCall(nil), r.calls...)
+}
+
+// Channel returns the name of the fake channel
+func (r *Recorder) Channel() string {
+	if r.Name == "" {
+		return "recorder"
+	}
+	return r.Name
+}
+
+// SendToUser records the call and reports a single delivery to the user
+func (r *Recorder) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	r.record(RecordedCall{Method: "SendToUser", UserId: userId, Message: message, DryRun: IsDryRun(ctx)})
+	return r.results(userId, userId)
+}
+
+// SendToDevices records the call and reports a delivery to every device
+func (r *Recorder) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	r.record(RecordedCall{Method: "SendToDevices", Devices: devices, Message: message, DryRun: IsDryRun(ctx)})
+	recipients := make([]string, 0, len(devices))
+	for _, device := range devices {
+		recipients = append(recipients, device.DeviceId)
+	}
+	return r.results(message.UserId, recipients...)
+}
+
+// SendToTopic records the call and reports a single delivery to the topic
+func (r *Recorder) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	r.record(RecordedCall{Method: "SendToTopic", Topic: topic, Message: message, DryRun: IsDryRun(ctx)})
+	return r.results(message.UserId, topic)
+}
+
+func (r *Recorder) record(call RecordedCall) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *Recorder) results(userId string, recipients ...string) ([]models.DeliveryResult, error) {
+	results := make([]models.DeliveryResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		result := models.DeliveryResult{
+			Channel:   r.Channel(),
+			UserId:    userId,
+			Recipient: recipient,
+			Success:   r.Err == nil,
+		}
+		if r.Err != nil {
+			result.Error = r.Err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, r.Err
+}
@@ -0,0 +1,153 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/webhook"
+)
+
+// WebhookStore provides the webhooks of users and tracks the outcome of their deliveries
+type WebhookStore interface {
+	GetEnabledWebhooks(userId string) ([]models.Webhook, error)
+	RecordWebhookSuccess(id bson.ObjectID, statusCode int) error
+	RecordWebhookFailure(id bson.ObjectID, statusCode int, deliveryErr string, maxConsecutiveFailures int) (*models.Webhook, error)
+}
+
+// Webhook delivers notifications to the enabled webhooks of the user
+type Webhook struct {
+	logger *zap.Logger
+	sender *webhook.Sender
+	store  WebhookStore
+}
+
+// NewWebhook creates a new webhook notifier which looks up the webhooks of users from given store
+func NewWebhook(logger *zap.Logger, sender *webhook.Sender, store WebhookStore) *Webhook {
+	return &Webhook{
+		logger: logger,
+		sender: sender,
+		store:  store,
+	}
+}
+
+// Channel returns the name of the webhook channel
+func (w *Webhook) Channel() string {
+	return webhook.Channel
+}
+
+// SendToUser posts the notification to every enabled webhook of the user concurrently.
+// Broadcasts which only target matching devices are skipped.
+func (w *Webhook) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	if skipsDevicelessChannels(ctx) {
+		return []models.DeliveryResult{}, nil
+	}
+	webhooks, err := w.store.GetEnabledWebhooks(userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) == 0 {
+		return []models.DeliveryResult{}, nil
+	}
+
+	deliveryId, err := webhook.NewDeliveryId()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(models.WebhookPayload{
+		DeliveryId: deliveryId,
+		Type:       message.Data[constants.DataKeyType],
+		UserId:     userId,
+		Title:      message.Title,
+		Body:       message.GetBody(),
+		ImageURL:   message.ImageURL,
+		Category:   message.Category,
+		Data:       message.Data,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %s", err.Error())
+	}
+
+	if IsDryRun(ctx) {
+		results := make([]models.DeliveryResult, 0, len(webhooks))
+		for _, hook := range webhooks {
+			results = append(results, models.DeliveryResult{
+				Channel:   webhook.Channel,
+				UserId:    hook.UserId,
+				Recipient: hook.URL,
+				Success:   true,
+				MessageId: deliveryId,
+				Preview:   string(payload),
+			})
+		}
+		return results, nil
+	}
+
+	results := make([]models.DeliveryResult, len(webhooks))
+	errs := make([]error, len(webhooks))
+	var wg sync.WaitGroup
+	for i, hook := range webhooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = w.deliver(ctx, hook, deliveryId, payload)
+		}()
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
+
+// SendToDevices does nothing, as devices are not recipients of the webhook channel
+func (w *Webhook) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return []models.DeliveryResult{}, nil
+}
+
+// SendToTopic does nothing, as the webhook channel has no topics
+func (w *Webhook) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return []models.DeliveryResult{}, nil
+}
+
+// deliver posts the payload to a single webhook and records the outcome of the delivery on the webhook
+func (w *Webhook) deliver(ctx context.Context, hook models.Webhook, deliveryId string, payload []byte) (models.DeliveryResult, error) {
+	result := models.DeliveryResult{
+		Channel:   webhook.Channel,
+		UserId:    hook.UserId,
+		Recipient: hook.URL,
+		MessageId: deliveryId,
+	}
+	statusCode, err := w.sender.Deliver(ctx, hook, deliveryId, payload)
+	if err == nil {
+		result.Success = true
+		if err = w.store.RecordWebhookSuccess(hook.ID, statusCode); err != nil {
+			w.logger.Error("failed to record webhook delivery", zap.String("webhook_id", hook.ID.Hex()), zap.Error(err))
+		}
+		return result, nil
+	}
+
+	result.Error = err.Error()
+	if statusCode != 0 {
+		result.ErrorCode = fmt.Sprintf("HTTP_%d", statusCode)
+	}
+	updated, recordErr := w.store.RecordWebhookFailure(hook.ID, statusCode, err.Error(), w.sender.MaxConsecutiveFailures(statusCode))
+	if recordErr != nil {
+		w.logger.Error("failed to record webhook delivery", zap.String("webhook_id", hook.ID.Hex()), zap.Error(recordErr))
+	} else if !updated.Enabled {
+		w.logger.Warn(
+			"webhook disabled after failed deliveries",
+			zap.String("webhook_id", hook.ID.Hex()),
+			zap.Int("status_code", statusCode),
+			zap.Int("consecutive_failures", updated.ConsecutiveFailures),
+		)
+	}
+	return result, fmt.Errorf("webhook %s: %s", hook.ID.Hex(), err.Error())
+}
@@ -0,0 +1,135 @@
+// AnhCao 2024
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/webpush"
+)
+
+// SubscriptionStore provides the device tokens of users, including their Web Push subscriptions,
+// and removes subscriptions which are no longer valid
+type SubscriptionStore interface {
+	TokenStore
+	DeleteToken(userId, deviceId string) error
+}
+
+// WebPush delivers notifications to the browsers of the user which subscribed to the standard Web Push channel
+type WebPush struct {
+	logger *zap.Logger
+	client *webpush.Client
+	tokens SubscriptionStore
+	config *models.Push
+}
+
+// NewWebPush creates a new Web Push notifier. The web push configuration of the push notifications
+// (ex: icon) also applies to the notifications of this channel.
+func NewWebPush(logger *zap.Logger, client *webpush.Client, tokens SubscriptionStore, config *models.Push) *WebPush {
+	return &WebPush{
+		logger: logger,
+		client: client,
+		tokens: tokens,
+		config: config,
+	}
+}
+
+// Channel returns the name of the Web Push channel
+func (wp *WebPush) Channel() string {
+	return webpush.Channel
+}
+
+// SendToUser sends the notification to every Web Push subscription of the user,
+// or to the subscriptions which match the segment of a broadcast
+func (wp *WebPush) SendToUser(ctx context.Context, userId string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	devices, err := wp.tokens.GetTokens(userId)
+	if err != nil {
+		return nil, err
+	}
+	return wp.SendToDevices(ctx, segmentDevices(ctx, devices), message)
+}
+
+// SendToDevices sends the notification to given devices which are Web Push subscriptions. Other devices are skipped.
+// Subscriptions which the push service reports as expired are removed.
+func (wp *WebPush) SendToDevices(ctx context.Context, devices []models.NotificationToken, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	results := make([]models.DeliveryResult, 0)
+	var payload []byte
+	var errs []error
+	for _, device := range devices {
+		if device.Platform != models.PlatformWebPush || device.Keys == nil {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = wp.buildPayload(message); err != nil {
+				return nil, err
+			}
+		}
+
+		result := models.DeliveryResult{Channel: webpush.Channel, UserId: device.UserId, Recipient: device.DeviceId}
+		subscription := models.WebPushSubscription{Endpoint: device.DeviceId, Keys: *device.Keys}
+		if IsDryRun(ctx) {
+			// the encryption validates the keys of the subscription
+			if _, err := webpush.Encrypt(subscription.Keys, payload); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Success = true
+				result.Preview = string(payload)
+			}
+			results = append(results, result)
+			continue
+		}
+		messageId, err := wp.client.Send(ctx, subscription, payload)
+		if err == nil {
+			result.Success = true
+			result.MessageId = messageId
+			results = append(results, result)
+			continue
+		}
+
+		result.Error = err.Error()
+		if errors.Is(err, webpush.ErrSubscriptionGone) {
+			// same code as FCM reports for tokens of uninstalled apps
+			result.ErrorCode = "UNREGISTERED"
+			if err := wp.tokens.DeleteToken(device.UserId, device.DeviceId); err != nil {
+				wp.logger.Error("failed to delete expired web push subscription", zap.Error(err))
+			}
+		} else {
+			errs = append(errs, fmt.Errorf("subscription %s: %s", device.ID.Hex(), err.Error()))
+		}
+		results = append(results, result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// SendToTopic does nothing, as topics are a feature of FCM which the standard Web Push channel does not have
+func (wp *WebPush) SendToTopic(ctx context.Context, topic string, message models.NotificationMessage) ([]models.DeliveryResult, error) {
+	return []models.DeliveryResult{}, nil
+}
+
+// buildPayload encodes the notification as the JSON which the service worker of the browser receives
+func (wp *WebPush) buildPayload(message models.NotificationMessage) ([]byte, error) {
+	config := wp.config.Resolve(message.Category, message.PlatformOverrides)
+	payload, err := json.Marshal(models.WebPushPayload{
+		Title:       message.Title,
+		Body:        message.GetBody(),
+		Image:       message.ImageURL,
+		Icon:        config.Webpush.Icon,
+		Badge:       config.Webpush.Badge,
+		ClickAction: message.ClickAction,
+		Category:    message.Category,
+		Data:        message.Data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode web push payload: %s", err.Error())
+	}
+	if len(payload) > webpush.MaxPayloadSize {
+		return nil, fmt.Errorf("web push payload of %d bytes exceeds the limit of %d bytes", len(payload), webpush.MaxPayloadSize)
+	}
+	return payload, nil
+}
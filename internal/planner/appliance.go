@@ -0,0 +1,175 @@
+// AnhCao 2024
+//
+// Package planner plans electricity consumption to the cheapest hours of the price series.
+package planner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/analysis"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// clockLayout is the layout of the allowed time range of an appliance
+const clockLayout = "15:04"
+
+// ValidateAppliance checks that the appliance has a valid duration, energy consumption and allowed time range.
+// The duration is in whole hours, as the runs are planned in the entries of the price series.
+func ValidateAppliance(appliance models.Appliance) error {
+	if strings.TrimSpace(appliance.Name) == "" {
+		return fmt.Errorf("`name` is required")
+	}
+	if appliance.DurationHours < 1 || appliance.DurationHours > 24 {
+		return fmt.Errorf("`durationHours` must be between 1 and 24")
+	}
+	if appliance.EnergyKWh <= 0 {
+		return fmt.Errorf("`energyKWh` must be greater than 0")
+	}
+	if _, err := time.Parse(clockLayout, appliance.AllowedFrom); err != nil {
+		return fmt.Errorf("`allowedFrom` must be in format HH:MM")
+	}
+	if _, err := time.Parse(clockLayout, appliance.AllowedTo); err != nil {
+		return fmt.Errorf("`allowedTo` must be in format HH:MM")
+	}
+	return nil
+}
+
+// PlanAppliance finds the cheapest start time within the allowed time range of the appliance
+// and estimates the cost of the run, assuming the energy is consumed evenly during the run.
+// It returns nil if the run does not fit into the allowed time range of the price series.
+func PlanAppliance(appliance models.Appliance, series models.PriceSeries) (*models.Recommendation, error) {
+	slot, err := analysis.Slot(series)
+	if err != nil {
+		return nil, err
+	}
+	size := int(time.Duration(appliance.DurationHours) * time.Hour / slot)
+	if size <= 0 {
+		return nil, fmt.Errorf("duration of %d hours is shorter than a single price entry", appliance.DurationHours)
+	}
+
+	allowed, err := allowedEntries(appliance, series)
+	if err != nil {
+		return nil, err
+	}
+
+	bestStart, bestSum := -1, math.Inf(1)
+	for start := 0; start+size <= len(series.Data); start++ {
+		sum, fits := 0.0, true
+		for idx := start; idx < start+size; idx++ {
+			if !allowed[idx] {
+				fits = false
+				break
+			}
+			sum += series.Data[idx].Price
+		}
+		if fits && sum < bestSum {
+			bestStart, bestSum = start, sum
+		}
+	}
+	if bestStart < 0 {
+		return nil, nil
+	}
+
+	startTime, err := time.Parse(constants.PriceTimeLayout, series.Data[bestStart].Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse price time '%s': %s", series.Data[bestStart].Time, err.Error())
+	}
+	energyPerEntry := appliance.EnergyKWh / float64(size)
+	return &models.Recommendation{
+		ApplianceId:   appliance.ID.Hex(),
+		Name:          appliance.Name,
+		StartTime:     series.Data[bestStart].Time,
+		EndTime:       startTime.Add(time.Duration(size) * slot).Format(constants.PriceTimeLayout),
+		EstimatedCost: bestSum * energyPerEntry,
+		Unit:          CostUnit(series),
+	}, nil
+}
+
+// PlanAppliances plans all the given appliances. Appliances which run does not fit into the price series are skipped.
+func PlanAppliances(appliances []models.Appliance, series models.PriceSeries) ([]models.Recommendation, error) {
+	recommendations := make([]models.Recommendation, 0, len(appliances))
+	for _, appliance := range appliances {
+		recommendation, err := PlanAppliance(appliance, series)
+		if err != nil {
+			return nil, err
+		}
+		if recommendation != nil {
+			recommendations = append(recommendations, *recommendation)
+		}
+	}
+	return recommendations, nil
+}
+
+// UpcomingPrices joins the price series of consecutive days (ex: today and tomorrow) into a single series
+// from given time on, so that a run in an allowed time range over midnight (ex: 22:00 - 07:00) is not cut
+// at the end of a day. Entries which started before given time are left out, as a run cannot start in the past.
+func UpcomingPrices(now time.Time, days ...models.PriceSeries) (models.PriceSeries, error) {
+	upcoming := models.PriceSeries{}
+	for _, day := range days {
+		for _, data := range day.Data {
+			t, err := analysis.ParseTimeUTC(data)
+			if err != nil {
+				return models.PriceSeries{}, err
+			}
+			if t.Before(now) {
+				continue
+			}
+			upcoming.Name = day.Name
+			upcoming.Data = append(upcoming.Data, data)
+		}
+	}
+	return upcoming, nil
+}
+
+// CostUnit returns the currency unit of the price series, ex: "c" for "c/kWh"
+func CostUnit(series models.PriceSeries) string {
+	unit, _, _ := strings.Cut(series.Name, "/")
+	return unit
+}
+
+// allowedEntries marks the entries of the price series that are entirely inside of the allowed time range of the appliance.
+// The range wraps over midnight when `allowedTo` is not after `allowedFrom`.
+func allowedEntries(appliance models.Appliance, series models.PriceSeries) ([]bool, error) {
+	from, err := minuteOfDay(appliance.AllowedFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := minuteOfDay(appliance.AllowedTo)
+	if err != nil {
+		return nil, err
+	}
+	slot, err := analysis.Slot(series)
+	if err != nil {
+		return nil, err
+	}
+	slotMinutes := int(slot / time.Minute)
+
+	allowed := make([]bool, len(series.Data))
+	for idx, data := range series.Data {
+		t, err := time.Parse(constants.PriceTimeLayout, data.Time)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse price time '%s': %s", data.Time, err.Error())
+		}
+		start := t.Hour()*60 + t.Minute()
+		end := start + slotMinutes
+		if from < to {
+			allowed[idx] = start >= from && end <= to
+		} else {
+			// wrapping range, ex: 22:00 - 07:00
+			allowed[idx] = start >= from || end <= to
+		}
+	}
+	return allowed, nil
+}
+
+func minuteOfDay(clock string) (int, error) {
+	t, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s', expected format HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
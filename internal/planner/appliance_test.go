@@ -0,0 +1,226 @@
+// AnhCao 2024
+package planner
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// hourlySeries returns a price series of hourly entries which starts at 2024-12-09 00:00 local time (UTC+2)
+func hourlySeries(prices ...float64) models.PriceSeries {
+	start := time.Date(2024, 12, 9, 0, 0, 0, 0, time.UTC)
+	series := models.PriceSeries{Name: "c/kWh"}
+	for idx, price := range prices {
+		local := start.Add(time.Duration(idx) * time.Hour)
+		series.Data = append(series.Data, models.Data{
+			TimeUTC: local.Add(-2 * time.Hour).Format(constants.PriceTimeLayout),
+			Time:    local.Format(constants.PriceTimeLayout),
+			Price:   price,
+		})
+	}
+	return series
+}
+
+// twoDays returns 48 hourly prices which are 10 everywhere except of given hours
+func twoDays(cheap map[int]float64) []float64 {
+	prices := make([]float64, 48)
+	for idx := range prices {
+		prices[idx] = 10
+		if price, ok := cheap[idx]; ok {
+			prices[idx] = price
+		}
+	}
+	return prices
+}
+
+func TestPlanAppliance(t *testing.T) {
+	tests := []struct {
+		name      string
+		appliance models.Appliance
+		prices    []float64
+		// empty start means that the run does not fit
+		wantStart string
+		wantEnd   string
+		wantCost  float64
+	}{
+		{
+			name:      "cheapest window inside of a daytime range",
+			appliance: models.Appliance{DurationHours: 2, EnergyKWh: 2, AllowedFrom: "08:00", AllowedTo: "16:00"},
+			// the cheapest hours 03-05 are outside of the range
+			prices:    twoDays(map[int]float64{3: 1, 4: 1, 12: 3, 13: 5}),
+			wantStart: "2024-12-09 12:00:00",
+			wantEnd:   "2024-12-09 14:00:00",
+			wantCost:  8,
+		},
+		{
+			name:      "range wrapping over midnight skips the cheaper noon",
+			appliance: models.Appliance{DurationHours: 3, EnergyKWh: 3, AllowedFrom: "22:00", AllowedTo: "07:00"},
+			prices:    twoDays(map[int]float64{12: 0, 13: 0, 14: 0, 23: 2, 24: 2, 25: 2}),
+			wantStart: "2024-12-09 23:00:00",
+			wantEnd:   "2024-12-10 02:00:00",
+			wantCost:  6,
+		},
+		{
+			name:      "wrapping range ends exactly at the end of the run",
+			appliance: models.Appliance{DurationHours: 1, EnergyKWh: 1, AllowedFrom: "22:00", AllowedTo: "07:00"},
+			prices:    twoDays(map[int]float64{6: 1, 7: 0}),
+			wantStart: "2024-12-09 06:00:00",
+			wantEnd:   "2024-12-09 07:00:00",
+			wantCost:  1,
+		},
+		{
+			name:      "00:00 - 00:00 allows the whole day",
+			appliance: models.Appliance{DurationHours: 2, EnergyKWh: 1, AllowedFrom: "00:00", AllowedTo: "00:00"},
+			prices:    twoDays(map[int]float64{12: 1, 13: 3}),
+			wantStart: "2024-12-09 12:00:00",
+			wantEnd:   "2024-12-09 14:00:00",
+			wantCost:  2,
+		},
+		{
+			name:      "run longer than the range does not fit",
+			appliance: models.Appliance{DurationHours: 2, EnergyKWh: 1, AllowedFrom: "10:00", AllowedTo: "11:00"},
+			prices:    twoDays(nil),
+		},
+		{
+			name:      "run longer than the price series does not fit",
+			appliance: models.Appliance{DurationHours: 4, EnergyKWh: 1, AllowedFrom: "00:00", AllowedTo: "00:00"},
+			prices:    []float64{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.appliance.Name = "Dishwasher"
+			got, err := PlanAppliance(tt.appliance, hourlySeries(tt.prices...))
+			if err != nil {
+				t.Fatalf("PlanAppliance() error = %v", err)
+			}
+			if tt.wantStart == "" {
+				if got != nil {
+					t.Fatalf("PlanAppliance() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("PlanAppliance() = nil, want a recommendation")
+			}
+			if got.StartTime != tt.wantStart || got.EndTime != tt.wantEnd {
+				t.Errorf("PlanAppliance() run = %s - %s, want %s - %s", got.StartTime, got.EndTime, tt.wantStart, tt.wantEnd)
+			}
+			if math.Abs(got.EstimatedCost-tt.wantCost) > 1e-9 {
+				t.Errorf("PlanAppliance() cost = %v, want %v", got.EstimatedCost, tt.wantCost)
+			}
+			if got.Unit != "c" {
+				t.Errorf("PlanAppliance() unit = %s, want c", got.Unit)
+			}
+		})
+	}
+}
+
+func TestAllowedEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		// hours of the day which are allowed
+		want []int
+	}{
+		{name: "daytime range", from: "08:00", to: "11:00", want: []int{8, 9, 10}},
+		{name: "wrapping range", from: "22:00", to: "03:00", want: []int{0, 1, 2, 22, 23}},
+		{name: "range until midnight", from: "21:00", to: "00:00", want: []int{21, 22, 23}},
+		{name: "whole day", from: "00:00", to: "00:00", want: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}},
+		{name: "range shorter than an entry", from: "08:30", to: "09:00", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := allowedEntries(models.Appliance{AllowedFrom: tt.from, AllowedTo: tt.to}, hourlySeries(make([]float64, 24)...))
+			if err != nil {
+				t.Fatalf("allowedEntries() error = %v", err)
+			}
+			want := make([]bool, 24)
+			for _, hour := range tt.want {
+				want[hour] = true
+			}
+			for hour := range want {
+				if allowed[hour] != want[hour] {
+					t.Errorf("allowedEntries() hour %d = %v, want %v", hour, allowed[hour], want[hour])
+				}
+			}
+		})
+	}
+}
+
+func TestUpcomingPrices(t *testing.T) {
+	// the callers pass the price series of every date separately, as they are stored
+	prices := hourlySeries(twoDays(map[int]float64{5: 1, 23: 2, 24: 2, 25: 2})...)
+	today := models.PriceSeries{Name: prices.Name, Data: prices.Data[:24]}
+	tomorrow := models.PriceSeries{Name: prices.Name, Data: prices.Data[24:]}
+	// 2024-12-09 12:00 local time
+	noon := time.Date(2024, 12, 9, 10, 0, 0, 0, time.UTC)
+	night := models.Appliance{Name: "Dishwasher", DurationHours: 3, EnergyKWh: 3, AllowedFrom: "22:00", AllowedTo: "07:00"}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		days      []models.PriceSeries
+		wantLen   int
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "today and tomorrow are planned over midnight",
+			now:       noon,
+			days:      []models.PriceSeries{today, tomorrow},
+			wantLen:   36,
+			wantStart: "2024-12-09 23:00:00",
+			wantEnd:   "2024-12-10 02:00:00",
+		},
+		{
+			name:    "only today's prices are available",
+			now:     noon,
+			days:    []models.PriceSeries{today},
+			wantLen: 12,
+			// the run does not fit between 22:00 and midnight
+		},
+		{
+			name:      "the morning has not passed yet",
+			now:       time.Date(2024, 12, 8, 22, 0, 0, 0, time.UTC),
+			days:      []models.PriceSeries{today},
+			wantLen:   24,
+			wantStart: "2024-12-09 03:00:00",
+			wantEnd:   "2024-12-09 06:00:00",
+		},
+		{
+			name: "every price has passed",
+			now:  time.Date(2024, 12, 11, 0, 0, 0, 0, time.UTC),
+			days: []models.PriceSeries{today, tomorrow},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := UpcomingPrices(tt.now, tt.days...)
+			if err != nil {
+				t.Fatalf("UpcomingPrices() error = %v", err)
+			}
+			if len(series.Data) != tt.wantLen {
+				t.Fatalf("UpcomingPrices() has %d entries, want %d", len(series.Data), tt.wantLen)
+			}
+
+			got, err := PlanAppliance(night, series)
+			if err != nil {
+				t.Fatalf("PlanAppliance() error = %v", err)
+			}
+			if tt.wantStart == "" {
+				if got != nil {
+					t.Errorf("PlanAppliance() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.StartTime != tt.wantStart || got.EndTime != tt.wantEnd {
+				t.Errorf("PlanAppliance() = %+v, want a run %s - %s", got, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
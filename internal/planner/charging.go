@@ -0,0 +1,122 @@
+// AnhCao 2024
+package planner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/analysis"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// ValidateChargingRequest checks that the charging request has positive energy and power, and a deadline in the future
+func ValidateChargingRequest(request models.ChargingRequest, now time.Time) error {
+	if request.EnergyKWh <= 0 {
+		return fmt.Errorf("`energyKWh` must be greater than 0")
+	}
+	if request.MaxPowerKW <= 0 {
+		return fmt.Errorf("`maxPowerKW` must be greater than 0")
+	}
+	if !request.Deadline.After(now) {
+		return fmt.Errorf("`deadline` must be in the future")
+	}
+	return nil
+}
+
+// PlanCharging allocates the requested energy to the cheapest price entries between now and the deadline.
+// The hours do not need to be contiguous, and an entry may be used only partially: the entry which is in progress
+// or cut by the deadline only has the remaining time available, and the last allocated entry charges only what is left.
+// The given series may be the concatenation of several days, ex: today and tomorrow.
+func PlanCharging(request models.ChargingRequest, series models.PriceSeries, now time.Time) (*models.ChargingPlan, error) {
+	slot, err := analysis.Slot(series)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		start     time.Time
+		startTime string
+		available time.Duration
+		data      models.Data
+	}
+	candidates := make([]candidate, 0, len(series.Data))
+	for _, data := range series.Data {
+		entryStart, err := analysis.ParseTimeUTC(data)
+		if err != nil {
+			return nil, err
+		}
+		start := maxTime(entryStart, now)
+		end := minTime(entryStart.Add(slot), request.Deadline)
+		if !end.After(start) {
+			continue
+		}
+		localStart, err := time.Parse(constants.PriceTimeLayout, data.Time)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse price time '%s': %s", data.Time, err.Error())
+		}
+		candidates = append(candidates, candidate{
+			start:     start,
+			startTime: localStart.Add(start.Sub(entryStart)).Format(constants.PriceTimeLayout),
+			available: end.Sub(start),
+			data:      data,
+		})
+	}
+	// cheapest first, earlier first on equal price
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].data.Price == candidates[j].data.Price {
+			return candidates[i].start.Before(candidates[j].start)
+		}
+		return candidates[i].data.Price < candidates[j].data.Price
+	})
+
+	plan := &models.ChargingPlan{
+		Schedule: make([]models.ChargingSlot, 0),
+		Unit:     CostUnit(series),
+	}
+	remaining := request.EnergyKWh
+	for _, c := range candidates {
+		if remaining <= 0 {
+			break
+		}
+		capacity := request.MaxPowerKW * c.available.Hours()
+		energy := math.Min(capacity, remaining)
+		duration := time.Duration(energy / request.MaxPowerKW * float64(time.Hour)).Round(time.Second)
+		cost := energy * c.data.Price
+
+		plan.Schedule = append(plan.Schedule, models.ChargingSlot{
+			Start:     c.start,
+			End:       c.start.Add(duration),
+			StartTime: c.startTime,
+			EnergyKWh: energy,
+			Price:     c.data.Price,
+			Cost:      cost,
+		})
+		plan.TotalEnergyKWh += energy
+		plan.TotalCost += cost
+		remaining -= energy
+	}
+	// tolerate floating point rounding of the allocated energy
+	plan.Fulfilled = remaining <= 1e-9
+
+	sort.Slice(plan.Schedule, func(i, j int) bool {
+		return plan.Schedule[i].Start.Before(plan.Schedule[j].Start)
+	})
+	return plan, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,112 @@
+// AnhCao 2024
+package planner
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestPlanCharging(t *testing.T) {
+	// UTC start of the first entry of hourlySeries
+	base := time.Date(2024, 12, 8, 22, 0, 0, 0, time.UTC)
+	type slot struct {
+		startTime string
+		duration  time.Duration
+		energy    float64
+	}
+	tests := []struct {
+		name          string
+		prices        []float64
+		request       models.ChargingRequest
+		now           time.Time
+		want          []slot
+		wantCost      float64
+		wantFulfilled bool
+	}{
+		{
+			name:          "cheapest hours are not contiguous",
+			prices:        []float64{5, 1, 5, 2, 5, 5},
+			request:       models.ChargingRequest{EnergyKWh: 20, MaxPowerKW: 10, Deadline: base.Add(6 * time.Hour)},
+			now:           base,
+			want:          []slot{{"2024-12-09 01:00:00", time.Hour, 10}, {"2024-12-09 03:00:00", time.Hour, 10}},
+			wantCost:      30,
+			wantFulfilled: true,
+		},
+		{
+			name:          "last entry is used partially",
+			prices:        []float64{1, 2, 5},
+			request:       models.ChargingRequest{EnergyKWh: 15, MaxPowerKW: 10, Deadline: base.Add(3 * time.Hour)},
+			now:           base,
+			want:          []slot{{"2024-12-09 00:00:00", time.Hour, 10}, {"2024-12-09 01:00:00", 30 * time.Minute, 5}},
+			wantCost:      20,
+			wantFulfilled: true,
+		},
+		{
+			name:          "entry in progress only has the remaining time",
+			prices:        []float64{1, 5, 5},
+			request:       models.ChargingRequest{EnergyKWh: 10, MaxPowerKW: 10, Deadline: base.Add(3 * time.Hour)},
+			now:           base.Add(30 * time.Minute),
+			want:          []slot{{"2024-12-09 00:30:00", 30 * time.Minute, 5}, {"2024-12-09 01:00:00", 30 * time.Minute, 5}},
+			wantCost:      30,
+			wantFulfilled: true,
+		},
+		{
+			name:          "entry cut by the deadline only has the time until the deadline",
+			prices:        []float64{5, 1, 0},
+			request:       models.ChargingRequest{EnergyKWh: 10, MaxPowerKW: 10, Deadline: base.Add(90 * time.Minute)},
+			now:           base,
+			want:          []slot{{"2024-12-09 00:00:00", 30 * time.Minute, 5}, {"2024-12-09 01:00:00", 30 * time.Minute, 5}},
+			wantCost:      30,
+			wantFulfilled: true,
+		},
+		{
+			name:          "deadline shorter than the charging needs",
+			prices:        []float64{1, 2, 3},
+			request:       models.ChargingRequest{EnergyKWh: 30, MaxPowerKW: 10, Deadline: base.Add(2 * time.Hour)},
+			now:           base,
+			want:          []slot{{"2024-12-09 00:00:00", time.Hour, 10}, {"2024-12-09 01:00:00", time.Hour, 10}},
+			wantCost:      30,
+			wantFulfilled: false,
+		},
+		{
+			name:   "rounding of the allocated energy is tolerated",
+			prices: []float64{1, 1, 1, 1},
+			// 0.4 - 4 * 0.1 leaves 2.8e-17 kWh
+			request:       models.ChargingRequest{EnergyKWh: 0.4, MaxPowerKW: 0.1, Deadline: base.Add(4 * time.Hour)},
+			now:           base,
+			want:          []slot{{"2024-12-09 00:00:00", time.Hour, 0.1}, {"2024-12-09 01:00:00", time.Hour, 0.1}, {"2024-12-09 02:00:00", time.Hour, 0.1}, {"2024-12-09 03:00:00", time.Hour, 0.1}},
+			wantCost:      0.4,
+			wantFulfilled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanCharging(tt.request, hourlySeries(tt.prices...), tt.now)
+			if err != nil {
+				t.Fatalf("PlanCharging() error = %v", err)
+			}
+			if len(plan.Schedule) != len(tt.want) {
+				t.Fatalf("PlanCharging() schedule = %+v, want %d slots", plan.Schedule, len(tt.want))
+			}
+			for idx, want := range tt.want {
+				got := plan.Schedule[idx]
+				if got.StartTime != want.startTime || got.End.Sub(got.Start) != want.duration || math.Abs(got.EnergyKWh-want.energy) > 1e-9 {
+					t.Errorf("PlanCharging() slot %d = %s for %s with %v kWh, want %s for %s with %v kWh",
+						idx, got.StartTime, got.End.Sub(got.Start), got.EnergyKWh, want.startTime, want.duration, want.energy)
+				}
+			}
+			if math.Abs(plan.TotalCost-tt.wantCost) > 1e-9 {
+				t.Errorf("PlanCharging() cost = %v, want %v", plan.TotalCost, tt.wantCost)
+			}
+			if plan.Fulfilled != tt.wantFulfilled {
+				t.Errorf("PlanCharging() fulfilled = %v, want %v", plan.Fulfilled, tt.wantFulfilled)
+			}
+			if plan.Unit != "c" {
+				t.Errorf("PlanCharging() unit = %s, want c", plan.Unit)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// AnhCao 2024
+//
+// Package pricing turns spot prices into the effective total price that a user pays according to the contract,
+// so that analysis and notifications report what the electricity really costs for the user.
+package pricing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// clockLayout is the layout of the night time range of the transfer tariff
+const clockLayout = "15:04"
+
+// Validate checks that the contract does not have negative fees and that the night time range is complete and valid
+func Validate(contract models.Contract) error {
+	if contract.FixedPrice < 0 || contract.MonthlyFees < 0 || contract.MonthlyConsumptionKWh < 0 {
+		return fmt.Errorf("`fixedPrice`, `monthlyFees` and `monthlyConsumptionKWh` must not be negative")
+	}
+	if contract.Transfer.DayPrice < 0 || contract.Transfer.NightPrice < 0 {
+		return fmt.Errorf("transfer prices must not be negative")
+	}
+	night := contract.Transfer
+	if (night.NightFrom == "") != (night.NightTo == "") {
+		return fmt.Errorf("both `nightFrom` and `nightTo` must be given for the night tariff")
+	}
+	if night.NightFrom != "" {
+		if _, err := time.Parse(clockLayout, night.NightFrom); err != nil {
+			return fmt.Errorf("`nightFrom` must be in format HH:MM")
+		}
+		if _, err := time.Parse(clockLayout, night.NightTo); err != nil {
+			return fmt.Errorf("`nightTo` must be in format HH:MM")
+		}
+	}
+	return nil
+}
+
+// Effective returns a copy of the price series where every price is the effective total price of the hour:
+// spot price + retailer margin + transfer fee of the hour + monthly fees spread over the monthly consumption.
+// If the contract is nil, the series is returned as is.
+func Effective(contract *models.Contract, series models.PriceSeries) (models.PriceSeries, error) {
+	if contract == nil {
+		return series, nil
+	}
+
+	fixedFees := contract.Margin
+	if contract.MonthlyConsumptionKWh > 0 {
+		fixedFees += contract.MonthlyFees / contract.MonthlyConsumptionKWh
+	}
+
+	effective := models.PriceSeries{
+		Name: series.Name,
+		Data: make([]models.Data, len(series.Data)),
+	}
+	for idx, data := range series.Data {
+		transfer, err := transferPrice(contract.Transfer, data)
+		if err != nil {
+			return models.PriceSeries{}, err
+		}
+		data.Price += fixedFees + transfer
+		effective.Data[idx] = data
+	}
+	return effective, nil
+}
+
+// FixedContractCheaper tells whether the fixed-price contract of the comparison would have been cheaper than the spot
+// contract on given day, comparing the average energy price (spot + margin) with the fixed price. Transfer and monthly
+// fees are left out because they are the same for both contracts. It also returns the average energy price of the spot contract.
+func FixedContractCheaper(contract *models.Contract, series models.PriceSeries) (bool, float64) {
+	if contract == nil || contract.FixedPrice <= 0 || len(series.Data) == 0 {
+		return false, 0
+	}
+	var sum float64
+	for _, data := range series.Data {
+		sum += data.Price + contract.Margin
+	}
+	average := sum / float64(len(series.Data))
+	return contract.FixedPrice < average, average
+}
+
+// transferPrice returns the transfer fee of the hour of given price entry
+func transferPrice(tariff models.TransferTariff, data models.Data) (float64, error) {
+	if tariff.NightFrom == "" || tariff.NightTo == "" {
+		return tariff.DayPrice, nil
+	}
+	t, err := time.Parse(constants.PriceTimeLayout, data.Time)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse price time '%s': %s", data.Time, err.Error())
+	}
+	from, err := time.Parse(clockLayout, tariff.NightFrom)
+	if err != nil {
+		return 0, fmt.Errorf("invalid night tariff start '%s'", tariff.NightFrom)
+	}
+	to, err := time.Parse(clockLayout, tariff.NightTo)
+	if err != nil {
+		return 0, fmt.Errorf("invalid night tariff end '%s'", tariff.NightTo)
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	fromMinute, toMinute := from.Hour()*60+from.Minute(), to.Hour()*60+to.Minute()
+	isNight := minute >= fromMinute && minute < toMinute
+	if fromMinute > toMinute {
+		// wrapping range, ex: 22:00 - 07:00
+		isNight = minute >= fromMinute || minute < toMinute
+	}
+	if isNight {
+		return tariff.NightPrice, nil
+	}
+	return tariff.DayPrice, nil
+}
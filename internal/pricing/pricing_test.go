@@ -0,0 +1,118 @@
+// AnhCao 2024
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestTransferPrice(t *testing.T) {
+	tariff := func(from, to string) models.TransferTariff {
+		return models.TransferTariff{DayPrice: 4, NightPrice: 2, NightFrom: from, NightTo: to}
+	}
+	tests := []struct {
+		name   string
+		tariff models.TransferTariff
+		time   string
+		want   float64
+	}{
+		{name: "no night range", tariff: models.TransferTariff{DayPrice: 4, NightPrice: 2}, time: "2024-12-09 23:00:00", want: 4},
+		{name: "night begins at nightFrom", tariff: tariff("22:00", "07:00"), time: "2024-12-09 22:00:00", want: 2},
+		{name: "day until nightFrom", tariff: tariff("22:00", "07:00"), time: "2024-12-09 21:59:00", want: 4},
+		{name: "night over midnight", tariff: tariff("22:00", "07:00"), time: "2024-12-09 00:00:00", want: 2},
+		{name: "night until nightTo", tariff: tariff("22:00", "07:00"), time: "2024-12-09 06:45:00", want: 2},
+		{name: "day begins at nightTo", tariff: tariff("22:00", "07:00"), time: "2024-12-09 07:00:00", want: 4},
+		{name: "night range within a day", tariff: tariff("01:00", "05:00"), time: "2024-12-09 01:00:00", want: 2},
+		{name: "day after a night range within a day", tariff: tariff("01:00", "05:00"), time: "2024-12-09 05:00:00", want: 4},
+		{name: "day before a night range within a day", tariff: tariff("01:00", "05:00"), time: "2024-12-09 00:00:00", want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transferPrice(tt.tariff, models.Data{Time: tt.time})
+			if err != nil {
+				t.Fatalf("transferPrice() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("transferPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffective(t *testing.T) {
+	series := models.PriceSeries{Name: "c/kWh", Data: []models.Data{
+		{Time: "2024-12-09 06:00:00", Price: 1},
+		{Time: "2024-12-09 07:00:00", Price: 2},
+		{Time: "2024-12-09 23:00:00", Price: -1},
+	}}
+	tests := []struct {
+		name     string
+		contract *models.Contract
+		want     []float64
+	}{
+		{name: "without contract", contract: nil, want: []float64{1, 2, -1}},
+		{
+			name:     "margin and transfer tariff",
+			contract: &models.Contract{Margin: 0.5, Transfer: models.TransferTariff{DayPrice: 4, NightPrice: 2, NightFrom: "22:00", NightTo: "07:00"}},
+			want:     []float64{3.5, 6.5, 1.5},
+		},
+		{
+			name: "monthly fees spread over the monthly consumption",
+			// 1000 c over 500 kWh is 2 c/kWh
+			contract: &models.Contract{Margin: 0.5, MonthlyFees: 1000, MonthlyConsumptionKWh: 500, Transfer: models.TransferTariff{DayPrice: 4}},
+			want:     []float64{7.5, 8.5, 5.5},
+		},
+		{
+			name:     "monthly fees without consumption are left out",
+			contract: &models.Contract{MonthlyFees: 1000, Transfer: models.TransferTariff{DayPrice: 4}},
+			want:     []float64{5, 6, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Effective(tt.contract, series)
+			if err != nil {
+				t.Fatalf("Effective() error = %v", err)
+			}
+			for idx, want := range tt.want {
+				if math.Abs(got.Data[idx].Price-want) > 1e-9 {
+					t.Errorf("Effective() price of %s = %v, want %v", got.Data[idx].Time, got.Data[idx].Price, want)
+				}
+			}
+			if got.Name != series.Name {
+				t.Errorf("Effective() name = %s, want %s", got.Name, series.Name)
+			}
+		})
+	}
+	if series.Data[0].Price != 1 {
+		t.Errorf("Effective() modified the given series")
+	}
+}
+
+func TestFixedContractCheaper(t *testing.T) {
+	series := models.PriceSeries{Data: []models.Data{{Price: 6}, {Price: 10}}}
+	tests := []struct {
+		name        string
+		contract    *models.Contract
+		series      models.PriceSeries
+		wantCheaper bool
+		wantAverage float64
+	}{
+		{name: "without contract", contract: nil, series: series},
+		{name: "without fixed price", contract: &models.Contract{Margin: 1}, series: series},
+		{name: "without prices", contract: &models.Contract{FixedPrice: 5}, series: models.PriceSeries{}},
+		{name: "fixed price is cheaper than spot and margin", contract: &models.Contract{Margin: 1, FixedPrice: 8.5}, series: series, wantCheaper: true, wantAverage: 9},
+		{name: "fixed price equal to the average is not cheaper", contract: &models.Contract{Margin: 1, FixedPrice: 9}, series: series, wantAverage: 9},
+		{name: "spot is cheaper", contract: &models.Contract{FixedPrice: 9}, series: series, wantAverage: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cheaper, average := FixedContractCheaper(tt.contract, tt.series)
+			if cheaper != tt.wantCheaper || math.Abs(average-tt.wantAverage) > 1e-9 {
+				t.Errorf("FixedContractCheaper() = %v, %v, want %v, %v", cheaper, average, tt.wantCheaper, tt.wantAverage)
+			}
+		})
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/AnhCaooo/electric-notifications/internal/constants"
 	"github.com/AnhCaooo/electric-notifications/internal/db"
-	"github.com/AnhCaooo/electric-notifications/internal/firebase"
 	"github.com/AnhCaooo/electric-notifications/internal/helpers"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
+	"github.com/AnhCaooo/electric-notifications/internal/planner"
+	"github.com/AnhCaooo/electric-notifications/internal/pricing"
+	"github.com/AnhCaooo/electric-notifications/internal/reminders"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
@@ -24,6 +29,8 @@ const (
 type Consumer struct {
 	// The AMQP channel used for communication with RabbitMQ.
 	channel *amqp.Channel
+	// Configuration settings of the application.
+	config *models.Config
 	// The context for managing the consumer's lifecycle and cancellation.
 	ctx context.Context
 	// The name of the RabbitMQ exchange to bind the consumer to.
@@ -32,8 +39,8 @@ type Consumer struct {
 	logger *zap.Logger
 	// The MongoDB instance for database operations.
 	mongo *db.Mongo
-	// The Firebase instance for sending push notifications.
-	firebase *firebase.Firebase
+	// The notifier which delivers the notifications through every channel.
+	notifier notifier.Notifier
 	// The RabbitMQ queue to consume messages from.
 	queue *amqp.Queue
 	// The identifier for the worker handling the consumer.
@@ -132,6 +139,17 @@ func (c *Consumer) Listen(stopChan <-chan struct{}, errChan chan<- error) {
 				var notificationMessage models.PricesMessage
 				json.Unmarshal(msg.Body, &notificationMessage)
 
+				chartURL, err := c.storePrices(&notificationMessage)
+				if err != nil {
+					// notifications are still sent, only without the price chart
+					c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to store prices", c.workerID, constants.Server), zap.Error(err))
+				}
+				if notificationMessage.Data.Tomorrow.Available {
+					if err := c.scheduleReminders(notificationMessage.Data.Tomorrow.Prices); err != nil {
+						c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to schedule reminders", c.workerID, constants.Server), zap.Error(err))
+					}
+				}
+
 				userIDs, err := c.mongo.GetAllUserIDs()
 				if err != nil {
 					errMsg := fmt.Errorf("[worker_%d] %s failed to get all user IDs: %s", c.workerID, constants.Server, err.Error())
@@ -139,23 +157,32 @@ func (c *Consumer) Listen(stopChan <-chan struct{}, errChan chan<- error) {
 					return
 				}
 
+				var success, failure int
 				for _, userID := range userIDs {
-					// retrieve all associated device tokens with given userId
-					tokens, err := c.mongo.GetTokens(userID)
-					if err != nil {
-						errMsg := fmt.Errorf("[worker_%d] %s failed to get tokens: %s", c.workerID, constants.Server, err.Error())
-						errChan <- errMsg
-						return
-					}
-					err = c.firebase.SendToMultiTokens(tokens, userID, helpers.GenerateNotificationMessageForSpotPrice(&notificationMessage))
+					message := c.generateMessage(userID, notificationMessage)
+					results, err := c.notifier.SendToUser(notifier.WithSource(c.ctx, models.SourceRabbitMQ), userID, models.NotificationMessage{
+						UserId:   userID,
+						Title:    "Electricity prices for tomorrow",
+						Body:     message,
+						ImageURL: chartURL,
+						Data:     map[string]string{constants.DataKeyType: constants.NotificationTypeDailyPrices},
+						Category: constants.NotificationTypeDailyPrices,
+					})
+					userSuccess, userFailure := notifier.CountResults(results)
+					success, failure = success+userSuccess, failure+userFailure
 					if err != nil {
-						errMsg := fmt.Errorf("[worker_%d] %s failed to send multi tokens: %s", c.workerID, constants.Server, err.Error())
+						// a failure of one user does not prevent the others from receiving the notification
+						errMsg := fmt.Errorf("[worker_%d] %s failed to send notifications to user %s: %s", c.workerID, constants.Server, userID, err.Error())
 						errChan <- errMsg
-						return
 					}
 				}
 
-				c.logger.Info(fmt.Sprintf("[worker_%d] sent tokens successfully: %s", c.workerID, helpers.GenerateNotificationMessageForSpotPrice(&notificationMessage)))
+				c.logger.Info(
+					fmt.Sprintf("[worker_%d] sent notifications: %s", c.workerID, helpers.GenerateNotificationMessageForSpotPrice(&notificationMessage)),
+					zap.Int("users", len(userIDs)),
+					zap.Int("success", success),
+					zap.Int("failure", failure),
+				)
 			default:
 				c.logger.Info(fmt.Sprintf("[worker_%d] received an message from undefined routing key: '%s' with message: %v", c.workerID, msg.RoutingKey, msg.Body))
 			}
@@ -163,3 +190,136 @@ func (c *Consumer) Listen(stopChan <-chan struct{}, errChan chan<- error) {
 		}
 	}
 }
+
+// storePrices stores the price series of today and tomorrow (if available) from given message into the database,
+// so they can be served later on. It returns the public URL of tomorrow's price chart, or an empty string
+// if tomorrow's prices are not available or the public URL of the service is not configured.
+func (c *Consumer) storePrices(message *models.PricesMessage) (string, error) {
+	prices := []models.DailyPrice{message.Data.Today, message.Data.Tomorrow}
+	for _, dailyPrice := range prices {
+		if len(dailyPrice.Prices.Data) == 0 {
+			continue
+		}
+		date, err := helpers.GetPriceSeriesDate(dailyPrice.Prices)
+		if err != nil {
+			return "", err
+		}
+		if err = c.mongo.UpsertDailyPrices(date, dailyPrice.Prices); err != nil {
+			return "", err
+		}
+	}
+
+	if !message.Data.Tomorrow.Available {
+		return "", nil
+	}
+	date, err := helpers.GetPriceSeriesDate(message.Data.Tomorrow.Prices)
+	if err != nil {
+		return "", err
+	}
+	return helpers.BuildPriceChartURL(c.config.Server.PublicURL, date), nil
+}
+
+// scheduleReminders schedules the reminders of all users for given price series, using the effective prices of each user.
+// A reminder which cannot be scheduled does not prevent the others from being scheduled.
+func (c *Consumer) scheduleReminders(series models.PriceSeries) error {
+	allReminders, err := c.mongo.GetAllReminders()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	scheduled := 0
+	for _, reminder := range allReminders {
+		contract, err := c.mongo.GetContract(reminder.UserId)
+		if err != nil {
+			return err
+		}
+		effective, err := pricing.Effective(contract, series)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to compute effective prices", c.workerID, constants.Server), zap.String("user_id", reminder.UserId), zap.Error(err))
+			continue
+		}
+		jobs, err := reminders.BuildJobs(reminder, effective, now)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to build reminder jobs", c.workerID, constants.Server), zap.String("reminder_id", reminder.ID.Hex()), zap.Error(err))
+			continue
+		}
+		for _, job := range jobs {
+			if err = c.mongo.InsertJob(job); err != nil {
+				return err
+			}
+			scheduled++
+		}
+	}
+	c.logger.Info(fmt.Sprintf("[worker_%d] scheduled reminders", c.workerID), zap.Int("jobs", scheduled))
+	return nil
+}
+
+// generateMessage generates the daily price notification of a user. When tomorrow's prices are available, the prices
+// are reported as effective total prices according to the contract of the user, together with a hint if the
+// fixed-price contract would be cheaper and the scheduling recommendations for the appliances of the user.
+func (c *Consumer) generateMessage(userID string, message models.PricesMessage) string {
+	tomorrow := message.Data.Tomorrow
+	if !tomorrow.Available || len(tomorrow.Prices.Data) == 0 {
+		return helpers.GenerateNotificationMessageForSpotPrice(&message)
+	}
+
+	contract, err := c.mongo.GetContract(userID)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get contract", c.workerID, constants.Server), zap.Error(err))
+	}
+	effective, err := pricing.Effective(contract, tomorrow.Prices)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to compute effective prices", c.workerID, constants.Server), zap.Error(err))
+		effective = tomorrow.Prices
+	}
+
+	// message with the effective prices of the user
+	userMessage := message
+	userMessage.Data.Tomorrow.Prices = effective
+	lines := []string{helpers.GenerateNotificationMessageForSpotPrice(&userMessage)}
+	if cheaper, average := pricing.FixedContractCheaper(contract, tomorrow.Prices); cheaper {
+		lines = append(lines, helpers.GenerateFixedContractHint(contract.FixedPrice, average, tomorrow.Prices.Name))
+	}
+	if recommendations := c.recommendAppliances(userID, contract, message.Data); recommendations != "" {
+		lines = append(lines, recommendations)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// recommendAppliances returns the scheduling recommendations for the appliances of a user as notification text.
+// The runs are planned from now on over today's and tomorrow's effective prices, so a time range over midnight
+// (ex: 22:00 - 07:00) is planned from tonight until tomorrow morning.
+// It returns an empty string if the user has no appliances or planning fails.
+func (c *Consumer) recommendAppliances(userID string, contract *models.Contract, prices models.TodayTomorrowPrice) string {
+	appliances, err := c.mongo.GetAppliances(userID)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to get appliances", c.workerID, constants.Server), zap.Error(err))
+		return ""
+	}
+	if len(appliances) == 0 {
+		return ""
+	}
+	days := make([]models.PriceSeries, 0, 2)
+	for _, day := range []models.DailyPrice{prices.Today, prices.Tomorrow} {
+		if day.Available {
+			days = append(days, day.Prices)
+		}
+	}
+	series, err := planner.UpcomingPrices(time.Now().UTC(), days...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to join prices", c.workerID, constants.Server), zap.Error(err))
+		return ""
+	}
+	series, err = pricing.Effective(contract, series)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to compute effective prices", c.workerID, constants.Server), zap.Error(err))
+		return ""
+	}
+	recommendations, err := planner.PlanAppliances(appliances, series)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("[worker_%d] %s failed to plan appliances", c.workerID, constants.Server), zap.Error(err))
+		return ""
+	}
+	return helpers.GenerateRecommendationsMessage(recommendations)
+}
@@ -20,8 +20,8 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/AnhCaooo/electric-notifications/internal/db"
-	"github.com/AnhCaooo/electric-notifications/internal/firebase"
 	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -30,10 +30,10 @@ const (
 )
 
 // RabbitMQ represents a RabbitMQ broker instance with its configuration,
-// connection, channels, context, logger, and MongoDB instance.
+// connection, channels, context, logger, MongoDB instance and notifier.
 type RabbitMQ struct {
-	// Configuration settings for the RabbitMQ broker.
-	config *models.Broker
+	// Configuration settings of the application. It includes the settings for the RabbitMQ broker.
+	config *models.Config
 	// The AMQP connection to the RabbitMQ server.
 	connection *amqp.Connection
 	// A slice of AMQP channels for communication with RabbitMQ.
@@ -44,8 +44,8 @@ type RabbitMQ struct {
 	logger *zap.Logger
 	// The MongoDB instance for database operations.
 	mongo *db.Mongo
-	// The Firebase instance for sending push notifications.
-	firbase *firebase.Firebase
+	// The notifier which delivers the notifications through every channel.
+	notifier notifier.Notifier
 	//  A channel to send errors encountered during the consumer setup and operation.
 	errChan chan<- error
 	// A channel to signal the consumer to stop listening for messages.
@@ -56,13 +56,13 @@ type RabbitMQ struct {
 
 // NewRabbit creates a new instance of RabbitMQ with the provided context, configuration, logger, and MongoDB client.
 // It initializes the RabbitMQ struct with the given parameters.
-func NewRabbit(ctx context.Context, config *models.Broker, logger *zap.Logger, mongo *db.Mongo, firebase *firebase.Firebase) *RabbitMQ {
+func NewRabbit(ctx context.Context, config *models.Config, logger *zap.Logger, mongo *db.Mongo, notifier notifier.Notifier) *RabbitMQ {
 	return &RabbitMQ{
-		ctx:     ctx,
-		config:  config,
-		logger:  logger,
-		mongo:   mongo,
-		firbase: firebase,
+		ctx:      ctx,
+		config:   config,
+		logger:   logger,
+		mongo:    mongo,
+		notifier: notifier,
 	}
 }
 
@@ -78,7 +78,8 @@ func (r *RabbitMQ) EstablishConnection() (err error) {
 }
 
 func (r *RabbitMQ) getURI() string {
-	return fmt.Sprintf("amqp://%s:%s@%s:%s/", r.config.Username, r.config.Password, r.config.Host, r.config.Port)
+	broker := r.config.MessageBroker
+	return fmt.Sprintf("amqp://%s:%s@%s:%s/", broker.Username, broker.Password, broker.Host, broker.Port)
 }
 
 // CloseConnection closes first all channels then the connection with RabbitMQ server.
@@ -205,10 +206,12 @@ func (r *RabbitMQ) newConsumer(workerID int, exchange string) (*Consumer, error)
 	r.channels = append(r.channels, ch)
 	return &Consumer{
 		channel:  ch,
+		config:   r.config,
 		ctx:      r.ctx,
 		exchange: exchange,
 		logger:   r.logger,
 		mongo:    r.mongo,
+		notifier: r.notifier,
 		workerID: workerID,
 	}, nil
 }
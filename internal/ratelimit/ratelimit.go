@@ -0,0 +1,94 @@
+// AnhCao 2024
+//
+// Package ratelimit bounds the rate of outbound requests of the delivery channels with token buckets.
+// The time that senders wait for the buckets is published as expvar metrics under "rate_limit".
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// metrics of all limiters, by channel
+var metrics = expvar.NewMap("rate_limit")
+
+// Limiter is a token bucket which bounds the rate of outbound requests of a delivery channel.
+// A nil Limiter does not limit, so channels without a configured budget need no special handling.
+type Limiter struct {
+	bucket *rate.Limiter
+
+	requests     atomic.Int64
+	waited       atomic.Int64
+	waitTotalNs  atomic.Int64
+	maxWaitNs    atomic.Int64
+	lastWaitedAt atomic.Int64
+}
+
+// New creates the limiter of a channel with given budget. It returns nil if the budget does not limit the channel.
+func New(channel string, config models.RateLimit) *Limiter {
+	if config.Rate <= 0 {
+		return nil
+	}
+	burst := config.Burst
+	if burst <= 0 {
+		burst = max(1, int(config.Rate))
+	}
+	limiter := &Limiter{bucket: rate.NewLimiter(rate.Limit(config.Rate), burst)}
+	metrics.Set(channel, expvar.Func(limiter.snapshot))
+	return limiter
+}
+
+// Wait blocks until n requests are allowed by the budget of the channel, or the context is done.
+// Requests above the burst size are admitted in steps of the burst size.
+func (l *Limiter) Wait(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	start := time.Now()
+	for remaining := n; remaining > 0; {
+		step := min(remaining, l.bucket.Burst())
+		if err := l.bucket.WaitN(ctx, step); err != nil {
+			return err
+		}
+		remaining -= step
+	}
+	l.record(n, time.Since(start))
+	return nil
+}
+
+// record updates the metrics of the limiter with a wait for n requests
+func (l *Limiter) record(n int, wait time.Duration) {
+	l.requests.Add(int64(n))
+	// waits below a millisecond are only the overhead of the bucket
+	if wait < time.Millisecond {
+		return
+	}
+	l.waited.Add(1)
+	l.waitTotalNs.Add(int64(wait))
+	l.lastWaitedAt.Store(time.Now().Unix())
+	for {
+		current := l.maxWaitNs.Load()
+		if int64(wait) <= current || l.maxWaitNs.CompareAndSwap(current, int64(wait)) {
+			break
+		}
+	}
+}
+
+// snapshot returns the metrics of the limiter
+func (l *Limiter) snapshot() any {
+	return map[string]any{
+		"rate":                float64(l.bucket.Limit()),
+		"burst":               l.bucket.Burst(),
+		"requests":            l.requests.Load(),
+		"waits":               l.waited.Load(),
+		"wait_seconds_total":  time.Duration(l.waitTotalNs.Load()).Seconds(),
+		"max_wait_seconds":    time.Duration(l.maxWaitNs.Load()).Seconds(),
+		"last_wait_timestamp": l.lastWaitedAt.Load(),
+	}
+}
@@ -0,0 +1,128 @@
+// AnhCao 2024
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.RateLimit
+		// requests sent before the measured wait, through the same channel or through another channel
+		before      int
+		beforeOther int
+		n           int
+		// cancels the context of the measured wait
+		cancel   bool
+		timeout  time.Duration
+		wantErr  bool
+		minWait  time.Duration
+		maxWait  time.Duration
+		wantNil  bool
+		requests int64
+	}{
+		{
+			name:    "channel without a rate is not limited",
+			config:  models.RateLimit{},
+			n:       1000,
+			maxWait: 20 * time.Millisecond,
+			wantNil: true,
+		},
+		{
+			name:     "burst is admitted at once",
+			config:   models.RateLimit{Rate: 1, Burst: 5},
+			n:        5,
+			maxWait:  20 * time.Millisecond,
+			requests: 5,
+		},
+		{
+			name:     "requests above the burst wait for the rate",
+			config:   models.RateLimit{Rate: 50, Burst: 2},
+			n:        6,
+			minWait:  60 * time.Millisecond,
+			maxWait:  time.Second,
+			requests: 6,
+		},
+		{
+			name:     "burst defaults to the rate",
+			config:   models.RateLimit{Rate: 10},
+			n:        10,
+			maxWait:  20 * time.Millisecond,
+			requests: 10,
+		},
+		{
+			name:    "wait is cancelled through the context",
+			config:  models.RateLimit{Rate: 1, Burst: 1},
+			before:  1,
+			n:       1,
+			cancel:  true,
+			wantErr: true,
+			maxWait: 20 * time.Millisecond,
+		},
+		{
+			name:    "wait beyond the deadline fails right away",
+			config:  models.RateLimit{Rate: 1, Burst: 1},
+			before:  1,
+			n:       1,
+			timeout: 100 * time.Millisecond,
+			wantErr: true,
+			maxWait: 50 * time.Millisecond,
+		},
+		{
+			name:        "other channel does not use the budget",
+			config:      models.RateLimit{Rate: 1, Burst: 1},
+			beforeOther: 1,
+			n:           1,
+			maxWait:     20 * time.Millisecond,
+			requests:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := New("test", tt.config)
+			if (limiter == nil) != tt.wantNil {
+				t.Fatalf("New() = %v, want nil %v", limiter, tt.wantNil)
+			}
+			other := New("other", tt.config)
+			if err := limiter.Wait(context.Background(), tt.before); err != nil {
+				t.Fatalf("Wait() before error = %v", err)
+			}
+			if err := other.Wait(context.Background(), tt.beforeOther); err != nil {
+				t.Fatalf("Wait() of other channel error = %v", err)
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if tt.cancel {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			start := time.Now()
+			err := limiter.Wait(ctx, tt.n)
+			wait := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Wait() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if wait < tt.minWait || wait > tt.maxWait {
+				t.Errorf("Wait() took %s, want between %s and %s", wait, tt.minWait, tt.maxWait)
+			}
+			if limiter != nil {
+				// the requests sent before are counted as well
+				if got := limiter.requests.Load() - int64(tt.before); got != tt.requests {
+					t.Errorf("Wait() counted %d requests, want %d", got, tt.requests)
+				}
+			}
+		})
+	}
+}
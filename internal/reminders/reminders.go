@@ -0,0 +1,90 @@
+// AnhCao 2024
+//
+// Package reminders turns the reminders of users into scheduled jobs based on the price series.
+package reminders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/analysis"
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// Validate checks that the reminder has the settings required by its type
+func Validate(reminder models.Reminder) error {
+	if reminder.MinutesBefore < 0 || reminder.MinutesBefore > 24*60 {
+		return fmt.Errorf("`minutesBefore` must be between 0 and %d", 24*60)
+	}
+	switch reminder.Type {
+	case models.ReminderCheapestWindow:
+		if reminder.WindowHours < 1 || reminder.WindowHours > 24 {
+			return fmt.Errorf("`windowHours` must be between 1 and 24")
+		}
+	case models.ReminderThreshold:
+	default:
+		return fmt.Errorf("unsupported reminder type '%s'", reminder.Type)
+	}
+	return nil
+}
+
+// BuildJobs computes when the reminder has to be sent for given price series and returns the jobs
+// that send them. Reminders which time has already passed are skipped.
+func BuildJobs(reminder models.Reminder, series models.PriceSeries, now time.Time) ([]models.Job, error) {
+	var periods []analysis.Period
+	switch reminder.Type {
+	case models.ReminderCheapestWindow:
+		period, err := analysis.CheapestWindow(series, reminder.WindowHours)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, *period)
+	case models.ReminderThreshold:
+		belowPeriods, err := analysis.PeriodsBelow(series, reminder.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		periods = belowPeriods
+	default:
+		return nil, fmt.Errorf("unsupported reminder type '%s'", reminder.Type)
+	}
+
+	jobs := make([]models.Job, 0, len(periods))
+	for _, period := range periods {
+		runAt := period.Start.Add(-time.Duration(reminder.MinutesBefore) * time.Minute)
+		if runAt.Before(now) {
+			continue
+		}
+		jobs = append(jobs, models.Job{
+			Kind:        models.JobKindReminder,
+			ReferenceId: reminder.ID.Hex(),
+			DedupKey:    fmt.Sprintf("%s:%s:%d", models.JobKindReminder, reminder.ID.Hex(), period.Start.Unix()),
+			RunAt:       runAt,
+			Message: models.NotificationMessage{
+				UserId:   reminder.UserId,
+				Title:    "Cheap electricity soon",
+				Body:     generateMessage(reminder, period, series.Name),
+				Category: constants.NotificationTypeReminder,
+				Data: map[string]string{
+					constants.DataKeyType: constants.NotificationTypeReminder,
+					"reminderId":          reminder.ID.Hex(),
+				},
+			},
+		})
+	}
+	return jobs, nil
+}
+
+// generateMessage generates the text of a reminder, for example:
+// "Cheapest 3h period starts at 03:00 (average 1.23 c/kWh)"
+func generateMessage(reminder models.Reminder, period analysis.Period, unit string) string {
+	startTime := period.StartTime
+	if t, err := time.Parse(constants.PriceTimeLayout, period.StartTime); err == nil {
+		startTime = t.Format("15:04")
+	}
+	if reminder.Type == models.ReminderThreshold {
+		return fmt.Sprintf("Price drops below %.2f %s at %s (average %.2f %s)", reminder.Threshold, unit, startTime, period.Average, unit)
+	}
+	return fmt.Sprintf("Cheapest %dh period starts at %s (average %.2f %s)", reminder.WindowHours, startTime, period.Average, unit)
+}
@@ -0,0 +1,92 @@
+// AnhCao 2024
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
+)
+
+// broadcastBatchSize is how many users receive a broadcast between the updates of its progress
+const broadcastBatchSize = 100
+
+// errInterrupted is returned when a job is interrupted by the shutdown of the scheduler
+var errInterrupted = errors.New("job interrupted")
+
+// broadcast sends the broadcast of the job to every user of its segment. The progress is stored after every batch of users,
+// so an interrupted broadcast continues after the last processed user instead of sending the notification twice.
+// The failures of single users are counted in the progress and do not fail the broadcast.
+func (s *Scheduler) broadcast(job *models.Job) error {
+	id, err := bson.ObjectIDFromHex(job.ReferenceId)
+	if err != nil {
+		return fmt.Errorf("invalid broadcast ID '%s': %s", job.ReferenceId, err.Error())
+	}
+	broadcast, err := s.mongo.GetBroadcast(id)
+	if err != nil {
+		return err
+	}
+	if broadcast == nil {
+		return fmt.Errorf("broadcast %s not found", job.ReferenceId)
+	}
+	if broadcast.Status == models.BroadcastDone || broadcast.Status == models.BroadcastFailed {
+		return nil
+	}
+	userIDs, err := s.mongo.GetSegmentUserIDs(broadcast.Segment)
+	if err != nil {
+		return err
+	}
+	if err = s.mongo.StartBroadcast(id, len(userIDs)); err != nil {
+		return err
+	}
+	// the users are sorted, so the users up to the last processed one have already received the broadcast
+	if broadcast.LastUserId != "" {
+		userIDs = userIDs[sort.SearchStrings(userIDs, broadcast.LastUserId+"\x00"):]
+	}
+
+	ctx := notifier.WithNotificationId(notifier.WithSource(s.ctx, models.SourceBroadcast), broadcast.ID.Hex())
+	// the users are chosen by their devices, and only the matching devices receive the broadcast
+	ctx = notifier.WithSegment(ctx, broadcast.Segment)
+	for start := 0; start < len(userIDs); start += broadcastBatchSize {
+		select {
+		case <-s.stopChan:
+			return errInterrupted
+		default:
+		}
+
+		batch := userIDs[start:min(start+broadcastBatchSize, len(userIDs))]
+		var success, failure int
+		for _, userId := range batch {
+			message := broadcast.Message
+			message.UserId = userId
+			results, err := s.notifier.SendToUser(ctx, userId, message)
+			userSuccess, userFailure := notifier.CountResults(results)
+			if err != nil && len(results) == 0 {
+				userFailure++
+			}
+			success, failure = success+userSuccess, failure+userFailure
+		}
+		if err = s.mongo.UpdateBroadcastProgress(id, batch[len(batch)-1], len(batch), success, failure); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info(fmt.Sprintf("[worker_%d] broadcast sent successfully", s.workerID), zap.String("broadcast_id", job.ReferenceId), zap.Int("users", len(userIDs)))
+	return s.mongo.FinishBroadcast(id, models.BroadcastDone, "")
+}
+
+// failBroadcast marks the broadcast of a job which ran out of attempts as failed
+func (s *Scheduler) failBroadcast(job *models.Job, sendErr error) {
+	id, err := bson.ObjectIDFromHex(job.ReferenceId)
+	if err == nil {
+		err = s.mongo.FinishBroadcast(id, models.BroadcastFailed, sendErr.Error())
+	}
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("[worker_%d] failed to mark broadcast as failed", s.workerID), zap.String("broadcast_id", job.ReferenceId), zap.Error(err))
+	}
+}
@@ -0,0 +1,227 @@
+// AnhCao 2024
+//
+// Package scheduler sends the notifications that are persisted as jobs in the database once their time has come.
+// Because jobs live in the database, they survive restarts of the service, and because a job is claimed
+// atomically before it is executed, several replicas can run the scheduler without sending a job twice.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+
+	"github.com/AnhCaooo/electric-notifications/internal/constants"
+	"github.com/AnhCaooo/electric-notifications/internal/db"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	defaultLockTimeout  = time.Minute
+	defaultMaxAttempts  = 3
+	// retryDelay is the delay before the first retry of a failed job, it doubles after each attempt
+	retryDelay = 30 * time.Second
+)
+
+// JobStore persists the jobs which the scheduler claims and finishes. A job can only be finished with the claim token
+// of its latest claim, so a scheduler which lost a job to another one cannot overwrite its outcome.
+type JobStore interface {
+	ClaimDueJob(now time.Time, lockTimeout time.Duration) (*models.Job, error)
+	ExtendJobLock(id bson.ObjectID, claimToken string, lockedUntil time.Time) error
+	CompleteJob(id bson.ObjectID, claimToken string) error
+	RescheduleJob(id bson.ObjectID, claimToken string, runAt time.Time, lastError string) error
+	FailJob(id bson.ObjectID, claimToken string, lastError string) error
+	ReleaseJob(id bson.ObjectID, claimToken string) error
+}
+
+// Scheduler periodically claims the due jobs from the database and sends their notifications.
+type Scheduler struct {
+	// The context for managing the lifecycle of the scheduler.
+	ctx context.Context
+	// The logger for logging scheduler activities.
+	logger *zap.Logger
+	// The MongoDB instance where the broadcasts are persisted.
+	mongo *db.Mongo
+	// The store where the jobs are persisted.
+	jobs JobStore
+	// The notifier which delivers the notifications through every channel.
+	notifier notifier.Notifier
+	// How often the scheduler looks for due jobs.
+	pollInterval time.Duration
+	// How long a claimed job is reserved for this scheduler.
+	lockTimeout time.Duration
+	// How many times a job is attempted before it is marked as failed.
+	maxAttempts int
+	// The identifier for the worker running the scheduler.
+	workerID int
+	// Closed when the scheduler is stopped, so long running jobs (ex: broadcasts) can be interrupted.
+	stopChan <-chan struct{}
+}
+
+// NewScheduler creates a new Scheduler instance. Zero values in the configuration fall back to the defaults.
+func NewScheduler(ctx context.Context, config *models.Scheduler, logger *zap.Logger, mongo *db.Mongo, notifier notifier.Notifier) *Scheduler {
+	s := &Scheduler{
+		ctx:          ctx,
+		logger:       logger,
+		mongo:        mongo,
+		jobs:         mongo,
+		notifier:     notifier,
+		pollInterval: config.PollInterval,
+		lockTimeout:  config.LockTimeout,
+		maxAttempts:  config.MaxAttempts,
+	}
+	if s.pollInterval <= 0 {
+		s.pollInterval = defaultPollInterval
+	}
+	if s.lockTimeout <= 0 {
+		s.lockTimeout = defaultLockTimeout
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultMaxAttempts
+	}
+	return s
+}
+
+// Start runs the scheduler in a separate goroutine for a given worker until a stop signal is received on stopChan.
+// Errors encountered while processing jobs are sent to errChan.
+func (s *Scheduler) Start(workerID int, wg *sync.WaitGroup, errChan chan<- error, stopChan <-chan struct{}) {
+	s.workerID = workerID
+	s.stopChan = stopChan
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.logger.Info(fmt.Sprintf("[worker_%d] scheduler starting...", s.workerID), zap.Duration("poll_interval", s.pollInterval))
+
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			// run the jobs which became due while the service was down right away
+			s.runDueJobs(errChan, stopChan)
+			select {
+			case <-stopChan:
+				s.logger.Info(fmt.Sprintf("[worker_%d] scheduler stopped", s.workerID))
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runDueJobs claims and executes the due jobs one by one until there is none left
+func (s *Scheduler) runDueJobs(errChan chan<- error, stopChan <-chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+
+		job, err := s.jobs.ClaimDueJob(time.Now().UTC(), s.lockTimeout)
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		if err != nil {
+			errChan <- fmt.Errorf("[worker_%d] %s %s", s.workerID, constants.Server, err.Error())
+			return
+		}
+		if err = s.execute(job); err != nil {
+			errChan <- fmt.Errorf("[worker_%d] %s %s", s.workerID, constants.Server, err.Error())
+		}
+	}
+}
+
+// execute sends the notification of a claimed job and records the outcome.
+// A failed job is retried with an increasing delay until it runs out of attempts.
+// The job stays reserved while it runs, however long the delivery takes.
+func (s *Scheduler) execute(job *models.Job) error {
+	stopLocking := s.keepLocked(job)
+	sendErr := s.send(job)
+	stopLocking()
+	if errors.Is(sendErr, errInterrupted) {
+		s.logger.Info(fmt.Sprintf("[worker_%d] job interrupted, it continues after restart", s.workerID), zap.String("job_id", job.ID.Hex()))
+		return s.jobs.ReleaseJob(job.ID, job.ClaimToken)
+	}
+	if sendErr == nil {
+		s.logger.Info(fmt.Sprintf("[worker_%d] job executed successfully", s.workerID), zap.String("job_id", job.ID.Hex()), zap.String("kind", job.Kind))
+		return s.jobs.CompleteJob(job.ID, job.ClaimToken)
+	}
+
+	if job.Attempts >= s.maxAttempts {
+		if err := s.jobs.FailJob(job.ID, job.ClaimToken, sendErr.Error()); err != nil {
+			return err
+		}
+		if job.Kind == models.JobKindBroadcast {
+			s.failBroadcast(job, sendErr)
+		}
+		return fmt.Errorf("job %s failed after %d attempts: %s", job.ID.Hex(), job.Attempts, sendErr.Error())
+	}
+
+	delay := retryDelay * time.Duration(1<<(job.Attempts-1))
+	if err := s.jobs.RescheduleJob(job.ID, job.ClaimToken, time.Now().UTC().Add(delay), sendErr.Error()); err != nil {
+		return err
+	}
+	return fmt.Errorf("job %s failed, retrying in %s: %s", job.ID.Hex(), delay, sendErr.Error())
+}
+
+// keepLocked extends the lock of a running job periodically, so that no other scheduler claims the job
+// while it is still running. It returns a function which stops extending the lock.
+func (s *Scheduler) keepLocked(job *models.Job) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// the lock is extended well before it expires, so a slow database call does not let it lapse
+		ticker := time.NewTicker(s.lockTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := s.jobs.ExtendJobLock(job.ID, job.ClaimToken, time.Now().UTC().Add(s.lockTimeout))
+				if err == db.ErrJobLost {
+					s.logger.Warn(fmt.Sprintf("[worker_%d] job was claimed by another scheduler", s.workerID), zap.String("job_id", job.ID.Hex()))
+					return
+				}
+				if err != nil {
+					s.logger.Error(fmt.Sprintf("[worker_%d] failed to extend lock of job", s.workerID), zap.String("job_id", job.ID.Hex()), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// send delivers the notification of the job to the user through every channel,
+// or to every user of the segment if the job is a broadcast
+func (s *Scheduler) send(job *models.Job) error {
+	if job.Kind == models.JobKindBroadcast {
+		return s.broadcast(job)
+	}
+	// the deliveries of every attempt are recorded under the ID of the job
+	ctx := notifier.WithNotificationId(notifier.WithSource(s.ctx, models.SourceScheduler), job.ID.Hex())
+	if job.Service != "" {
+		ctx = notifier.WithService(ctx, job.Service)
+	}
+	results, err := s.notifier.SendToUser(ctx, job.Message.UserId, job.Message)
+	if err != nil && slices.ContainsFunc(results, func(result models.DeliveryResult) bool { return result.Success }) {
+		// the other channels already delivered the notification, and sending it again would deliver it twice
+		// through them (and add it twice to the inbox), so the failures of the other channels are only logged
+		s.logger.Warn(fmt.Sprintf("[worker_%d] job delivered through some channels only, it is not retried", s.workerID),
+			zap.String("job_id", job.ID.Hex()), zap.Error(err))
+		return nil
+	}
+	return err
+}
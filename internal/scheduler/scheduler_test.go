@@ -0,0 +1,203 @@
+// AnhCao 2024
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/AnhCaooo/electric-notifications/internal/db"
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/notifier"
+)
+
+func TestSend(t *testing.T) {
+	channelErr := errors.New("channel down")
+	tests := []struct {
+		name     string
+		channels []*notifier.Recorder
+		wantErr  bool
+	}{
+		{
+			name:     "every channel delivers",
+			channels: []*notifier.Recorder{{Name: "push"}, {Name: "webhook"}},
+		},
+		{
+			name:     "some channels deliver",
+			channels: []*notifier.Recorder{{Name: "push"}, {Name: "webhook", Err: channelErr}},
+		},
+		{
+			name:     "every channel fails",
+			channels: []*notifier.Recorder{{Name: "push", Err: channelErr}, {Name: "webhook", Err: channelErr}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels := make([]notifier.Notifier, 0, len(tt.channels))
+			for _, channel := range tt.channels {
+				channels = append(channels, channel)
+			}
+			s := &Scheduler{
+				ctx:      context.Background(),
+				logger:   zap.NewNop(),
+				notifier: notifier.NewDispatcher(zap.NewNop(), nil, channels...),
+			}
+			job := &models.Job{ID: bson.NewObjectID(), Message: models.NotificationMessage{UserId: "user-1", Title: "Cheap hours"}}
+
+			err := s.send(job)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, channel := range tt.channels {
+				if calls := channel.Calls(); len(calls) != 1 {
+					t.Errorf("%s was called %d times, want once", channel.Channel(), len(calls))
+				}
+			}
+		})
+	}
+}
+
+// jobStub stores a single job in memory, claimed the same way as in the database
+type jobStub struct {
+	mu  sync.Mutex
+	job models.Job
+	// number of claims so far, used as the claim token
+	claims int
+}
+
+func (s *jobStub) ClaimDueJob(now time.Time, lockTimeout time.Duration) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	due := s.job.Status == models.JobPending && !s.job.RunAt.After(now)
+	expired := s.job.Status == models.JobRunning && s.job.LockedUntil.Before(now)
+	if !due && !expired {
+		return nil, mongo.ErrNoDocuments
+	}
+	s.claims++
+	s.job.Status = models.JobRunning
+	s.job.LockedUntil = now.Add(lockTimeout)
+	s.job.ClaimToken = strconv.Itoa(s.claims)
+	s.job.Attempts++
+	job := s.job
+	return &job, nil
+}
+
+// update applies given change to the job if it is still held by the claim with given token
+func (s *jobStub) update(id bson.ObjectID, claimToken string, change func(job *models.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.job.ID != id || s.job.Status != models.JobRunning || s.job.ClaimToken != claimToken {
+		return db.ErrJobLost
+	}
+	change(&s.job)
+	return nil
+}
+
+func (s *jobStub) ExtendJobLock(id bson.ObjectID, claimToken string, lockedUntil time.Time) error {
+	return s.update(id, claimToken, func(job *models.Job) { job.LockedUntil = lockedUntil })
+}
+
+func (s *jobStub) CompleteJob(id bson.ObjectID, claimToken string) error {
+	return s.update(id, claimToken, func(job *models.Job) { job.Status = models.JobDone })
+}
+
+func (s *jobStub) RescheduleJob(id bson.ObjectID, claimToken string, runAt time.Time, lastError string) error {
+	return s.update(id, claimToken, func(job *models.Job) {
+		job.Status, job.RunAt, job.LastError = models.JobPending, runAt, lastError
+	})
+}
+
+func (s *jobStub) FailJob(id bson.ObjectID, claimToken string, lastError string) error {
+	return s.update(id, claimToken, func(job *models.Job) { job.Status, job.LastError = models.JobFailed, lastError })
+}
+
+func (s *jobStub) ReleaseJob(id bson.ObjectID, claimToken string) error {
+	return s.update(id, claimToken, func(job *models.Job) { job.Status, job.Attempts = models.JobPending, job.Attempts-1 })
+}
+
+func TestExecuteClaimedJob(t *testing.T) {
+	channelErr := errors.New("channel down")
+	tests := []struct {
+		name string
+		// error of the only channel
+		channelErr error
+		// whether another scheduler claims the job after the lock of the first one expired
+		takenOver  bool
+		wantErr    error
+		wantStatus models.JobStatus
+	}{
+		{
+			name:       "owner completes the job",
+			wantStatus: models.JobDone,
+		},
+		{
+			name:       "owner reschedules the job",
+			channelErr: channelErr,
+			wantStatus: models.JobPending,
+		},
+		{
+			name:       "job taken over is not completed",
+			takenOver:  true,
+			wantErr:    db.ErrJobLost,
+			wantStatus: models.JobRunning,
+		},
+		{
+			name:       "job taken over is not rescheduled",
+			channelErr: channelErr,
+			takenOver:  true,
+			wantErr:    db.ErrJobLost,
+			wantStatus: models.JobRunning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().UTC()
+			store := &jobStub{job: models.Job{
+				ID:      bson.NewObjectID(),
+				Kind:    models.JobKindNotification,
+				Status:  models.JobPending,
+				RunAt:   now,
+				Message: models.NotificationMessage{UserId: "user-1", Title: "Cheap hours"},
+			}}
+			s := &Scheduler{
+				ctx:         context.Background(),
+				logger:      zap.NewNop(),
+				jobs:        store,
+				notifier:    notifier.NewDispatcher(zap.NewNop(), nil, &notifier.Recorder{Name: "push", Err: tt.channelErr}),
+				lockTimeout: time.Minute,
+				maxAttempts: defaultMaxAttempts,
+			}
+			job, err := store.ClaimDueJob(now, s.lockTimeout)
+			if err != nil {
+				t.Fatalf("ClaimDueJob() error = %v", err)
+			}
+			if tt.takenOver {
+				if _, err := store.ClaimDueJob(now.Add(2*s.lockTimeout), s.lockTimeout); err != nil {
+					t.Fatalf("second ClaimDueJob() error = %v", err)
+				}
+			}
+
+			err = s.execute(job)
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("execute() error = %v, want %v", err, tt.wantErr)
+			}
+			if store.job.Status != tt.wantStatus {
+				t.Errorf("job status = %s, want %s", store.job.Status, tt.wantStatus)
+			}
+			if tt.takenOver && store.job.ClaimToken == job.ClaimToken {
+				t.Errorf("claim token = %s, want the token of the second claim", store.job.ClaimToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// AnhCao 2024
+//
+// Package webhook delivers notifications to the webhooks of users as signed JSON requests.
+//
+// Every request carries the headers:
+//   - X-Webhook-Id: identifier of the webhook
+//   - X-Webhook-Delivery: identifier of the delivery, the same for every retry of the delivery
+//   - X-Webhook-Timestamp: unix time in seconds when the request was sent
+//   - X-Webhook-Signature: "sha256=" followed by the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+//     computed with the secret of the webhook
+//
+// Receivers should verify the signature and reject requests whose timestamp is too old to prevent replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/netguard"
+	"github.com/AnhCaooo/electric-notifications/internal/ratelimit"
+)
+
+// Channel is the name of the delivery channel that webhook deliveries are reported under
+const Channel string = models.ChannelWebhook
+
+// Headers of the webhook requests
+const (
+	HeaderWebhookId = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	defaultTimeout                = 5 * time.Second
+	defaultMaxAttempts            = 3
+	defaultInitialBackoff         = time.Second
+	defaultMaxConsecutiveFailures = 10
+	defaultMaxPerUser             = 10
+	// how much of the response body is read, so the connection can be reused
+	maxResponseBody = 64 << 10
+)
+
+// Sender posts payloads to webhooks and retries failed deliveries with exponential backoff
+type Sender struct {
+	client                 *http.Client
+	maxAttempts            int
+	initialBackoff         time.Duration
+	maxConsecutiveFailures int
+	// Budget of the requests to webhooks, nil if not limited
+	limiter *ratelimit.Limiter
+}
+
+// NewSender creates a new Sender with given configuration, falling back to defaults for zero values
+func NewSender(config *models.Webhooks, limiter *ratelimit.Limiter) *Sender {
+	sender := &Sender{
+		client: &http.Client{
+			Timeout: defaultTimeout,
+			// the webhooks are given by users, so the requests must not reach the internal network,
+			// even if the hostname of a webhook resolves to an internal address after it was registered
+			Transport: netguard.NewTransport(),
+			// a redirect is treated as a failed delivery instead of following it to an unverified location
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts:            defaultMaxAttempts,
+		initialBackoff:         defaultInitialBackoff,
+		maxConsecutiveFailures: defaultMaxConsecutiveFailures,
+		limiter:                limiter,
+	}
+	if config.Timeout > 0 {
+		sender.client.Timeout = config.Timeout
+	}
+	if config.MaxAttempts > 0 {
+		sender.maxAttempts = config.MaxAttempts
+	}
+	if config.InitialBackoff > 0 {
+		sender.initialBackoff = config.InitialBackoff
+	}
+	if config.MaxConsecutiveFailures > 0 {
+		sender.maxConsecutiveFailures = config.MaxConsecutiveFailures
+	}
+	return sender
+}
+
+// MaxConsecutiveFailures returns after how many consecutive failed deliveries a webhook is disabled,
+// given the status code of the latest failed delivery. A webhook which responds that it is gone (410)
+// is disabled right away.
+func (s *Sender) MaxConsecutiveFailures(statusCode int) int {
+	if statusCode == http.StatusGone {
+		return 0
+	}
+	return s.maxConsecutiveFailures
+}
+
+// MaxPerUser returns how many webhooks a user may register
+func MaxPerUser(config *models.Webhooks) int {
+	if config.MaxPerUser > 0 {
+		return config.MaxPerUser
+	}
+	return defaultMaxPerUser
+}
+
+// Deliver posts the payload to the webhook until it responds with a 2xx status code or the attempts run out.
+// Only the failures which may pass are retried (see retryable); any other response fails the delivery right away.
+// It returns the status code of the last attempt, which is zero if the webhook could not be reached.
+func (s *Sender) Deliver(ctx context.Context, webhook models.Webhook, deliveryId string, payload []byte) (int, error) {
+	var statusCode int
+	var err error
+	backoff := s.initialBackoff
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		statusCode, err = s.post(ctx, webhook, deliveryId, payload)
+		if err == nil {
+			return statusCode, nil
+		}
+		if !retryable(statusCode) {
+			return statusCode, err
+		}
+		if attempt == s.maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return statusCode, fmt.Errorf("%s (delivery cancelled: %s)", err.Error(), ctx.Err().Error())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return statusCode, fmt.Errorf("failed after %d attempts: %s", s.maxAttempts, err.Error())
+}
+
+// retryable tells whether a failed delivery with given status code may pass when it is retried: the webhook
+// could not be reached (zero), it is rate limited (429) or it failed on its side (5xx). Other responses, ex: 404
+// or a redirect, fail in the same way again.
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// post sends a single signed request to the webhook
+func (s *Sender) post(ctx context.Context, webhook models.Webhook, deliveryId string, payload []byte) (int, error) {
+	if err := s.limiter.Wait(ctx, 1); err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	// every attempt is signed with a fresh timestamp, so receivers which reject old requests accept the retries
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "electric-notifications-webhook/1.0")
+	req.Header.Set(HeaderWebhookId, webhook.ID.Hex())
+	req.Header.Set(HeaderDelivery, deliveryId)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the value of the signature header of a request with given timestamp and body
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random secret for signing the requests of a webhook
+func NewSecret() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %s", err.Error())
+	}
+	return "whsec_" + hex.EncodeToString(random), nil
+}
+
+// NewDeliveryId generates a random identifier for a delivery
+func NewDeliveryId() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate delivery id: %s", err.Error())
+	}
+	return hex.EncodeToString(random), nil
+}
+
+// ValidateURL checks that the webhook URL is an absolute HTTPS URL without credentials,
+// whose host is a public address or a hostname which only resolves to public addresses
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %s", err.Error())
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute https url")
+	}
+	if u.User != nil {
+		return fmt.Errorf("webhook url must not contain credentials")
+	}
+	if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+		return fmt.Errorf("webhook url must point to a public host: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// AnhCao 2024
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+const (
+	// size of the single record of the encrypted content, see RFC 8188
+	recordSize = 4096
+	// push services accept at most 4096 bytes of encrypted content, which leaves this much for the payload
+	// after the header (86 bytes), the padding delimiter (1 byte) and the authentication tag (16 bytes)
+	MaxPayloadSize = recordSize - 86 - 1 - 16
+)
+
+// Encrypt encrypts the payload for the browser of the subscription with the "aes128gcm" content encoding
+// as specified in RFC 8291 (Message Encryption for Web Push) and RFC 8188.
+func Encrypt(keys models.WebPushKeys, payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("payload of %d bytes exceeds the limit of %d bytes", len(payload), MaxPayloadSize)
+	}
+	uaPublicBytes, err := decodeBase64(keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %s", err.Error())
+	}
+	authSecret, err := decodeBase64(keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %s", err.Error())
+	}
+	if len(authSecret) != 16 {
+		return nil, fmt.Errorf("invalid auth secret: expected 16 bytes, got %d", len(authSecret))
+	}
+
+	// a new key pair of the application server and a new salt for every message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %s", err.Error())
+	}
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %s", err.Error())
+	}
+	return encrypt(uaPublicBytes, authSecret, asPrivate, salt, payload)
+}
+
+// encrypt encrypts the payload with given key pair of the application server and salt
+func encrypt(uaPublicBytes, authSecret []byte, asPrivate *ecdh.PrivateKey, salt, payload []byte) ([]byte, error) {
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %s", err.Error())
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared secret: %s", err.Error())
+	}
+
+	// RFC 8291 section 3.4: combine the shared secret with the authentication secret
+	prkKey := hkdfExtract(authSecret, ecdhSecret)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm := hkdfExpand(prkKey, keyInfo, 32)
+
+	// RFC 8188 section 2.2: derive the content encryption key and the nonce
+	prk := hkdfExtract(salt, ikm)
+	cek := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// a single record, which is the last one and therefore ends with the padding delimiter 0x02
+	record := append(append(make([]byte, 0, len(payload)+1), payload...), 0x02)
+
+	// header: salt (16) | record size (4) | key id length (1) | key id = public key of the application server (65)
+	header := make([]byte, 0, 16+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// hkdfExtract is the HKDF-Extract function of RFC 5869 with SHA-256
+func hkdfExtract(salt, ikm []byte) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// hkdfExpand is the HKDF-Expand function of RFC 5869 with SHA-256, for outputs of at most one hash length
+func hkdfExpand(prk, info []byte, length int) []byte {
+	mac := hmac.New(sha256.New, prk)
+	mac.Write(info)
+	mac.Write([]byte{0x01})
+	return mac.Sum(nil)[:length]
+}
+
+// decodeBase64 decodes base64url or standard base64, with or without padding, as browsers and tools use both
+func decodeBase64(value string) ([]byte, error) {
+	value = strings.TrimRight(value, "=")
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.RawStdEncoding.DecodeString(value)
+}
@@ -0,0 +1,46 @@
+// AnhCao 2024
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"testing"
+)
+
+// TestEncryptRFC8291 checks the encryption against the example of RFC 8291 Appendix A
+func TestEncryptRFC8291(t *testing.T) {
+	mustDecode := func(value string) []byte {
+		decoded, err := decodeBase64(value)
+		if err != nil {
+			t.Fatalf("invalid test vector %q: %v", value, err)
+		}
+		return decoded
+	}
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatalf("invalid private key of the application server: %v", err)
+	}
+	uaPublic := mustDecode("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	authSecret := mustDecode("BTBZMqHH6r4Tts7J_aSIgg")
+	salt := mustDecode("DGv6ra1nlYgDCS1FRnbzlw")
+	payload := []byte("When I grow up, I want to be a watermelon")
+	want := mustDecode("DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+
+	got, err := encrypt(uaPublic, authSecret, asPrivate, salt, payload)
+	if err != nil {
+		t.Fatalf("encrypt() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encrypt() =\n%x\nwant\n%x", got, want)
+	}
+}
+
+func TestEncryptPayloadLimit(t *testing.T) {
+	keys := validKeys()
+	if _, err := Encrypt(keys, make([]byte, MaxPayloadSize)); err != nil {
+		t.Errorf("Encrypt() of %d bytes error = %v", MaxPayloadSize, err)
+	}
+	if _, err := Encrypt(keys, make([]byte, MaxPayloadSize+1)); err == nil {
+		t.Errorf("Encrypt() of %d bytes succeeded, want error", MaxPayloadSize+1)
+	}
+}
@@ -0,0 +1,95 @@
+// AnhCao 2024
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// how long a VAPID token is valid, push services accept at most 24 hours
+const vapidTokenLifetime = 12 * time.Hour
+
+// vapid signs the requests to push services with the key pair of the application server, see RFC 8292
+type vapid struct {
+	privateKey *ecdsa.PrivateKey
+	// base64url encoded uncompressed public key
+	publicKey string
+	subject   string
+}
+
+// newVAPID parses the base64url encoded private key and checks that given public key belongs to it
+func newVAPID(publicKey, privateKey, subject string) (*vapid, error) {
+	d, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %s", err.Error())
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %s", err.Error())
+	}
+	// uncompressed point: 0x04 | X (32) | Y (32)
+	point := key.PublicKey().Bytes()
+	configured, err := decodeBase64(publicKey)
+	if err != nil || !bytes.Equal(configured, point) {
+		return nil, fmt.Errorf("vapid public key does not belong to the private key")
+	}
+	if subject == "" {
+		return nil, fmt.Errorf("vapid subject is required")
+	}
+	return &vapid{
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(point[1:33]),
+				Y:     new(big.Int).SetBytes(point[33:]),
+			},
+			D: new(big.Int).SetBytes(d),
+		},
+		publicKey: base64.RawURLEncoding.EncodeToString(point),
+		subject:   subject,
+	}, nil
+}
+
+// authorization returns the value of the Authorization header of a request to given push service endpoint
+func (v *vapid) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %s", err.Error())
+	}
+	header, err := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+		"sub": v.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, v.privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign vapid token: %s", err.Error())
+	}
+	// JWS encodes ES256 signatures as the fixed size concatenation of r and s
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, v.publicKey), nil
+}
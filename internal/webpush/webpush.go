@@ -0,0 +1,147 @@
+// AnhCao 2024
+//
+// Package webpush delivers notifications to browsers through the standard Web Push protocol (RFC 8030),
+// authenticated with VAPID (RFC 8292) and encrypted as specified in RFC 8291.
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+	"github.com/AnhCaooo/electric-notifications/internal/netguard"
+	"github.com/AnhCaooo/electric-notifications/internal/ratelimit"
+)
+
+// Channel is the name of the delivery channel that Web Push deliveries are reported under
+const Channel string = models.ChannelWebPush
+
+// ErrSubscriptionGone is returned when the push service reports that the subscription has expired or was removed
+var ErrSubscriptionGone = errors.New("web push subscription is no longer valid")
+
+const (
+	defaultTTL     = 24 * time.Hour
+	requestTimeout = 10 * time.Second
+	// how much of the response body is read for the error message
+	maxResponseBody = 4 << 10
+)
+
+// pushServiceHosts are the push services of the browsers, which host the endpoints of the subscriptions.
+// An entry starting with a dot matches its subdomains.
+var pushServiceHosts = []string{
+	"fcm.googleapis.com",         // Chrome, Edge, Opera and other Chromium based browsers
+	".push.services.mozilla.com", // Firefox (Mozilla autopush)
+	".push.apple.com",            // Safari
+	".notify.windows.com",        // legacy Edge (Windows Push Notification Services)
+}
+
+// Client sends encrypted notifications to the push services of browsers
+type Client struct {
+	vapid      *vapid
+	httpClient *http.Client
+	ttl        time.Duration
+	// Budget of the requests to push services, nil if not limited
+	limiter *ratelimit.Limiter
+}
+
+// NewClient creates a new Web Push client with the VAPID key pair of given configuration
+func NewClient(config *models.VAPID, limiter *ratelimit.Limiter) (*Client, error) {
+	vapid, err := newVAPID(config.PublicKey, config.PrivateKey, config.Subject)
+	if err != nil {
+		return nil, err
+	}
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	return &Client{
+		vapid: vapid,
+		// the endpoints are given by the browsers of the users, so the requests must not reach the internal network
+		httpClient: &http.Client{Timeout: requestTimeout, Transport: netguard.NewTransport()},
+		ttl:        ttl,
+		limiter:    limiter,
+	}, nil
+}
+
+// Send encrypts the payload for the subscription and posts it to the push service of the subscription.
+// It returns the location of the created message, or `ErrSubscriptionGone` if the subscription should be removed.
+func (c *Client) Send(ctx context.Context, subscription models.WebPushSubscription, payload []byte) (string, error) {
+	body, err := Encrypt(subscription.Keys, payload)
+	if err != nil {
+		return "", err
+	}
+	authorization, err := c.vapid.authorization(subscription.Endpoint, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	if err = c.limiter.Wait(ctx, 1); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(c.ttl.Seconds())))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return "", ErrSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", fmt.Errorf("push service responded with status code %d: %s", resp.StatusCode, bytes.TrimSpace(responseBody))
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+// ValidateSubscription checks that the subscription has an HTTPS endpoint of a known push service and valid encryption keys
+func ValidateSubscription(subscription models.WebPushSubscription) error {
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || endpoint.User != nil {
+		return fmt.Errorf("endpoint must be an absolute https url")
+	}
+	if !isPushService(endpoint.Hostname()) {
+		return fmt.Errorf("endpoint host '%s' is not a known push service", endpoint.Hostname())
+	}
+	uaPublic, err := decodeBase64(subscription.Keys.P256dh)
+	if err != nil {
+		return fmt.Errorf("invalid p256dh key: %s", err.Error())
+	}
+	if _, err = ecdh.P256().NewPublicKey(uaPublic); err != nil {
+		return fmt.Errorf("invalid p256dh key: %s", err.Error())
+	}
+	authSecret, err := decodeBase64(subscription.Keys.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return fmt.Errorf("invalid auth secret: expected 16 base64url encoded bytes")
+	}
+	return nil
+}
+
+// isPushService reports whether the host belongs to one of the known push services
+func isPushService(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pushHost := range pushServiceHosts {
+		if host == pushHost || (strings.HasPrefix(pushHost, ".") && strings.HasSuffix(host, pushHost)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+// AnhCao 2024
+package webpush
+
+import (
+	"testing"
+
+	"github.com/AnhCaooo/electric-notifications/internal/models"
+)
+
+// validKeys returns the keys of the user agent of RFC 8291 Appendix A
+func validKeys() models.WebPushKeys {
+	return models.WebPushKeys{
+		P256dh: "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4",
+		Auth:   "BTBZMqHH6r4Tts7J_aSIgg",
+	}
+}
+
+func TestValidateSubscription(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		keys     models.WebPushKeys
+		wantErr  bool
+	}{
+		{name: "fcm", endpoint: "https://fcm.googleapis.com/fcm/send/abc", keys: validKeys()},
+		{name: "mozilla", endpoint: "https://updates.push.services.mozilla.com/wpush/v2/abc", keys: validKeys()},
+		{name: "apple", endpoint: "https://web.push.apple.com/abc", keys: validKeys()},
+		{name: "wns", endpoint: "https://wns2-par02p.notify.windows.com/w/?token=abc", keys: validKeys()},
+		{name: "upper case host", endpoint: "https://FCM.googleapis.com/fcm/send/abc", keys: validKeys()},
+		{name: "plain http", endpoint: "http://fcm.googleapis.com/fcm/send/abc", keys: validKeys(), wantErr: true},
+		{name: "unknown host", endpoint: "https://push.example.com/abc", keys: validKeys(), wantErr: true},
+		{name: "suffix without dot", endpoint: "https://evilpush.apple.com.example.com/abc", keys: validKeys(), wantErr: true},
+		{name: "lookalike domain", endpoint: "https://fcm.googleapis.com.evil.com/abc", keys: validKeys(), wantErr: true},
+		{name: "internal address", endpoint: "https://169.254.169.254/latest/meta-data", keys: validKeys(), wantErr: true},
+		{name: "credentials", endpoint: "https://user@fcm.googleapis.com/fcm/send/abc", keys: validKeys(), wantErr: true},
+		{name: "invalid p256dh", endpoint: "https://fcm.googleapis.com/fcm/send/abc", keys: models.WebPushKeys{P256dh: "AAAA", Auth: validKeys().Auth}, wantErr: true},
+		{name: "short auth", endpoint: "https://fcm.googleapis.com/fcm/send/abc", keys: models.WebPushKeys{P256dh: validKeys().P256dh, Auth: "AAAA"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSubscription(models.WebPushSubscription{Endpoint: tt.endpoint, Keys: tt.keys})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSubscription(%q) error = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+			}
+		})
+	}
+}